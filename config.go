@@ -1,10 +1,15 @@
 package gopipeline
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // PipelineConfig 定义了管道的配置参数
 type PipelineConfig struct {
 	// BufferSize 缓冲通道的容量
+	// 0 表示无缓冲通道（同步交接）：生产者每次发送都会阻塞，直到主循环真正取走该数据，
+	// 提供最强的背压语义，适用于延迟敏感、不可丢失的场景（吞吐会明显低于带缓冲通道）
 	BufferSize uint32
 	// FlushSize 批处理数据的最大容量
 	FlushSize uint32
@@ -16,10 +21,139 @@ type PipelineConfig struct {
 	// DrainGracePeriod 启用 DrainOnCancel 时用于收尾刷新的最长时间窗口
 	// 若未设置或 <=0，将在使用时采用一个保守的短时默认值
 	DrainGracePeriod time.Duration
+	// DrainMode 启用 DrainOnCancel 时抽干数据通道的方式（默认 Snapshot：仅非阻塞带走取消瞬间已缓冲的数据）
+	// UntilDeadline 在宽限期的前一半内持续阻塞接收，捕获取消后仍在写入的迟到数据，直到通道关闭或该期限到达；后一半留给最终 flush
+	DrainMode DrainMode
 	// MaxConcurrentFlushes 限制异步 flush 的最大并发数（0 表示不限制）
 	MaxConcurrentFlushes uint32
 	// FinalFlushOnCloseTimeout 关闭数据通道路径的“最终 flush”超时（0 表示不限时，使用 Background）
 	FinalFlushOnCloseTimeout time.Duration
+	// MaxFlushLingerTimeout 主循环退出后等待在途异步 flush 完成的最长时间（0 表示不等待，保持原有行为）
+	// 超时后取消这些 flush 的 ctx 并上报 FlushLingerHook；Done 在等待结束后才关闭，从而约束关闭被卡住的 flush 拖住的时长
+	MaxFlushLingerTimeout time.Duration
+	// DropOnCloseAfterCancel 数据通道关闭时若 ctx 已被取消，是否丢弃未满批次而不做最终 flush
+	// 默认 false：关闭路径总会 flush 剩余数据（即便 ctx 已取消）；true：取消表示“放弃一切”，
+	// 关闭时直接丢弃并返回 ErrContextIsClosed。ctx 未取消时关闭路径不受影响
+	DropOnCloseAfterCancel bool
+	// MaxFlushChunk 单次调用 flush 函数的最大元素数（0 表示不拆分）
+	// 批次超过该值时按顺序拆分为多个分片依次调用 flush 函数并聚合错误，使累计批大小与下游单次调用上限解耦；
+	// 去重管道按键数拆分为子 map
+	MaxFlushChunk uint32
+	// StaticTuning 声明运行期间不依赖 UpdateFlushInterval 的即时生效（默认 false）
+	// 为 true 时 SyncPerform 使用去掉 nudge 分支的精简循环，降低每次 select 的开销；
+	// 此时运行中调用 UpdateFlushInterval 会在下一次定时器重置时才生效。对 AsyncPerform 无影响。
+	// 运行期调参无法从配置推断，故需显式声明；已调用 SetAsync、启用重试队列或按键亲和时自动回退到通用循环
+	StaticTuning bool
+	// PanicPolicy flush 发生 panic 时的处理策略（默认 PanicRecover：恢复并记录日志）
+	PanicPolicy PanicPolicy
+	// PanicStackTrace flush panic 被恢复时是否通过日志器额外输出完整调用栈（默认 false）
+	// 用于开发期定位 flush 函数中的缺陷；输出在按 PanicPolicy 处理之前，PanicRethrow 时同样生效
+	PanicStackTrace bool
+	// MaxBufferedBytes 在途数据估算字节数上限（0 表示不限制），需配合 WithSizeOf 注入估算函数
+	// 超限时 Add 阻塞、TryAdd 返回 ErrMemoryLimit，额度在批次 flush 完成后释放
+	MaxBufferedBytes int64
+	// MaxDedupKeys 去重窗口内不同键数的上限（0 表示仅按 FlushSize 判满）
+	// 去重 map 的键数达到该值时立即视为满批并 flush，用于约束高基数窗口的内存；仅对去重管道生效
+	MaxDedupKeys uint32
+	// MaxDedupItems 去重窗口内写入条数的上限（含被覆盖的重复数据，0 表示不限制）
+	// 写入条数达到该值时立即视为满批并 flush，在重复度很高时约束单个窗口的工作量与延迟；仅对去重管道生效
+	MaxDedupItems uint32
+	// UseMapReuse 去重管道在同步 flush 完成后清空并复用批处理 map，而非重新分配（默认 false）
+	// 异步 flush 或启用重试队列时不生效；flush 函数不得在返回后继续持有该 map
+	UseMapReuse bool
+	// FlushCondition 定时触发的 flush 条件（默认 SizeOrInterval：批满或定时到期任一满足即 flush）
+	FlushCondition FlushCondition
+	// MinFlushSize FlushCondition 为 SizeThenInterval 时，定时与高水位触发 flush 所需的最小批大小（0 等同于 1）
+	// 未达该大小的批次跨定时周期继续累计，以额外延迟换取下游批处理效率；关闭与取消收尾路径不受影响
+	MinFlushSize uint32
+	// BufferHighWatermark 缓冲占用率高水位（0 表示禁用，取值 (0, 1]）
+	// 主循环每次收到数据后检查 len(dataChan)/cap(dataChan)，达到该比例时提前 flush 当前批次，
+	// 用于在生产者突发、缓冲接近饱和前主动排空；无缓冲通道下不生效
+	BufferHighWatermark float64
+	// StrictConfig 配置校验失败时是否拒绝运行（默认 false）
+	// false：Validate 发现的问题（如 FlushSize > BufferSize）仅在构造管道时通过日志告警一次；
+	// true：Perform/Start/Run 直接返回 Validate 的错误（包装 ErrInvalidConfig），不进入主循环
+	StrictConfig bool
+	// FlushEmptyOnInterval 定时触发时批次为空也调用 flush 函数（传入空批次），默认 false 跳过空批
+	// 用于心跳/水位推进等需要周期性调用下游的场景，flush 函数可通过 len(batch) == 0 识别
+	// 注意: 空批次同样计入 MetricsHook.Flush（items=0）；KeyedPipeline 的空批次没有分组，不会调用刷新函数
+	FlushEmptyOnInterval bool
+	// HeartbeatInterval 不可重置的心跳周期（0 表示禁用）
+	// 与随批满 flush 重置的 FlushInterval 定时器不同，心跳按固定周期触发：非空批次立即 flush（不受 MinFlushSize 约束），
+	// 空批次在启用 FlushEmptyOnInterval 时以空批次调用 flush；用于持续批满 flush 时仍需“至少每隔 X 一次”推进水位的场景
+	HeartbeatInterval time.Duration
+	// OverloadPolicy Add 遇到数据通道缓冲已满时的处理策略（默认 Block：阻塞直到有空位）
+	// DropOldest/DropNewest 用于实时遥测等宁可丢数据也要保证延迟有界的场景，丢弃条数计入 Stats().DroppedTotal
+	OverloadPolicy OverloadPolicy
+	// StartupGracePeriod 每次运行开始后的启动宽限期（0 表示禁用）
+	// 宽限期内主循环照常接收并累计数据，但不因批满、定时或高水位触发 flush；当前批次已满后新数据暂存在主循环内
+	// （同 PauseFlush，不经通道缓冲形成背压）。宽限期结束时立即 flush 已累计的数据，之后恢复正常节奏。
+	// 用于下游在服务启动后需要一段时间才就绪的场景；关闭与取消收尾路径不受影响
+	StartupGracePeriod time.Duration
+	// ReceiveBatch 主循环每次 select 收到一条数据后，最多再以非阻塞方式连续接收的条数（0 表示禁用）
+	// 高吞吐下摊薄每条数据一次 select 的开销；通道为空时立即回到 select，不会增加延迟。
+	// 连续接收期间定时器、轻推与取消分支最多推迟 ReceiveBatch 条数据的处理时间
+	ReceiveBatch uint32
+}
+
+// OverloadPolicy 定义了 Add 遇到缓冲已满时的处理策略
+type OverloadPolicy uint8
+
+const (
+	// Block 阻塞生产者直到缓冲有空位、ctx 结束或通道关闭（默认，保持兼容）
+	Block OverloadPolicy = iota
+	// DropOldest 从缓冲中取出并丢弃最旧的一条数据，再写入新数据；Add 不再因缓冲满而阻塞
+	DropOldest
+	// DropNewest 丢弃本次要写入的新数据并返回 nil，缓冲中的数据保持不变
+	DropNewest
+)
+
+// DrainMode 定义了取消收尾（DrainOnCancel）抽干数据通道的方式
+type DrainMode uint8
+
+const (
+	// Snapshot 非阻塞抽干取消瞬间已缓冲的数据，通道暂时为空即停止（默认，保持兼容）
+	Snapshot DrainMode = iota
+	// UntilDeadline 阻塞接收直到通道关闭或宽限期的前一半耗尽，捕获取消后仍在写入的迟到数据
+	UntilDeadline
+)
+
+// FlushCondition 定义了定时触发 flush 的条件
+type FlushCondition uint8
+
+const (
+	// SizeOrInterval 批满或定时到期任一满足即 flush（默认，保持兼容）
+	SizeOrInterval FlushCondition = iota
+	// SizeThenInterval 批满立即 flush；定时到期时仅当批大小不少于 MinFlushSize 才 flush，否则继续累计
+	// 关闭与取消收尾路径不受影响，仍会 flush 剩余数据
+	SizeThenInterval
+)
+
+// PanicPolicy 定义了 flush 函数发生 panic 时的处理策略
+type PanicPolicy uint8
+
+const (
+	// PanicRecover 恢复 panic 并记录日志，管道继续运行（默认，保持兼容）
+	PanicRecover PanicPolicy = iota
+	// PanicRethrow 记录日志后重新抛出 panic，适用于希望在编程错误时快速失败的场景
+	PanicRethrow
+	// PanicRecoverAndReport 恢复 panic 并将包装了 ErrFlushPanic 的错误发送到错误通道
+	PanicRecoverAndReport
+)
+
+// Validate 检查配置中容易误用的组合，返回包装了 ErrInvalidConfig 的错误（无问题时返回 nil）
+// 当前检查项:
+//   - FlushSize > BufferSize（BufferSize 为 0 的无缓冲模式除外）：一次缓冲填满无法凑满一批，
+//     按批大小触发的 flush 依赖生产者持续阻塞写入，行为与预期不同；建议 BufferSize >= FlushSize
+func (c PipelineConfig) Validate() error {
+	c = c.ValidateOrDefault()
+	if c.BufferSize > 0 && c.FlushSize > c.BufferSize {
+		return fmt.Errorf("%w: FlushSize (%d) exceeds BufferSize (%d)", ErrInvalidConfig, c.FlushSize, c.BufferSize)
+	}
+	if c.FlushCondition == SizeThenInterval && c.MinFlushSize > c.FlushSize {
+		return fmt.Errorf("%w: MinFlushSize (%d) exceeds FlushSize (%d)", ErrInvalidConfig, c.MinFlushSize, c.FlushSize)
+	}
+	return nil
 }
 
 // ValidateOrDefault 规范化配置：非法/未设置值回退到默认
@@ -27,9 +161,7 @@ func (c PipelineConfig) ValidateOrDefault() PipelineConfig {
 	if c.FlushInterval <= 0 {
 		c.FlushInterval = defaultFlushInterval
 	}
-	if c.BufferSize == 0 {
-		c.BufferSize = defaultBufferSize
-	}
+	// BufferSize == 0 保留为无缓冲通道（同步交接），不做回退
 	if c.FlushSize == 0 {
 		c.FlushSize = defaultFlushSize
 	}
@@ -53,8 +185,28 @@ func NewPipelineConfig() PipelineConfig {
 		FlushInterval:            defaultFlushInterval,
 		DrainOnCancel:            defaultDrainOnCancel,
 		DrainGracePeriod:         defaultDrainGracePeriod,
+		DrainMode:                Snapshot,
 		MaxConcurrentFlushes:     0,
 		FinalFlushOnCloseTimeout: 0,
+		MaxFlushLingerTimeout:    0,
+		DropOnCloseAfterCancel:   false,
+		MaxFlushChunk:            0,
+		StaticTuning:             false,
+		PanicPolicy:              PanicRecover,
+		PanicStackTrace:          false,
+		MaxBufferedBytes:         0,
+		MaxDedupKeys:             0,
+		MaxDedupItems:            0,
+		UseMapReuse:              false,
+		FlushCondition:           SizeOrInterval,
+		MinFlushSize:             0,
+		BufferHighWatermark:      0,
+		StrictConfig:             false,
+		FlushEmptyOnInterval:     false,
+		HeartbeatInterval:        0,
+		OverloadPolicy:           Block,
+		StartupGracePeriod:       0,
+		ReceiveBatch:             0,
 	}
 }
 
@@ -99,3 +251,127 @@ func (c PipelineConfig) WithFinalFlushOnCloseTimeout(d time.Duration) PipelineCo
 	c.FinalFlushOnCloseTimeout = d
 	return c
 }
+
+// WithDropOnCloseAfterCancel 设置数据通道关闭时若 ctx 已取消是否丢弃未满批次
+func (c PipelineConfig) WithDropOnCloseAfterCancel(enabled bool) PipelineConfig {
+	c.DropOnCloseAfterCancel = enabled
+	return c
+}
+
+// WithMaxFlushChunk 设置单次调用 flush 函数的最大元素数（0 表示不拆分）
+func (c PipelineConfig) WithMaxFlushChunk(n uint32) PipelineConfig {
+	c.MaxFlushChunk = n
+	return c
+}
+
+// WithStaticTuning 设置是否为同步模式启用精简循环（运行期间 UpdateFlushInterval 不再即时生效）
+func (c PipelineConfig) WithStaticTuning(enabled bool) PipelineConfig {
+	c.StaticTuning = enabled
+	return c
+}
+
+// WithPanicPolicy 设置 flush 发生 panic 时的处理策略
+func (c PipelineConfig) WithPanicPolicy(policy PanicPolicy) PipelineConfig {
+	c.PanicPolicy = policy
+	return c
+}
+
+// WithMaxBufferedBytes 设置在途数据估算字节数上限（0 表示不限制，需配合 WithSizeOf）
+func (c PipelineConfig) WithMaxBufferedBytes(n int64) PipelineConfig {
+	c.MaxBufferedBytes = n
+	return c
+}
+
+// WithMaxDedupKeys 设置去重窗口内不同键数的上限（0 表示仅按 FlushSize 判满）
+func (c PipelineConfig) WithMaxDedupKeys(n uint32) PipelineConfig {
+	c.MaxDedupKeys = n
+	return c
+}
+
+// WithFlushCondition 设置定时触发 flush 的条件，minSize 为 SizeThenInterval 下定时 flush 的最小批大小
+func (c PipelineConfig) WithFlushCondition(cond FlushCondition, minSize uint32) PipelineConfig {
+	c.FlushCondition = cond
+	c.MinFlushSize = minSize
+	return c
+}
+
+// WithBufferHighWatermark 设置缓冲占用率高水位（0 表示禁用）
+func (c PipelineConfig) WithBufferHighWatermark(ratio float64) PipelineConfig {
+	c.BufferHighWatermark = ratio
+	return c
+}
+
+// WithStrictConfig 设置配置校验失败时是否拒绝运行（默认仅告警）
+func (c PipelineConfig) WithStrictConfig(strict bool) PipelineConfig {
+	c.StrictConfig = strict
+	return c
+}
+
+// WithFlushEmptyOnInterval 设置定时触发时是否对空批次调用 flush 函数（心跳）
+func (c PipelineConfig) WithFlushEmptyOnInterval(enabled bool) PipelineConfig {
+	c.FlushEmptyOnInterval = enabled
+	return c
+}
+
+// WithOverloadPolicy 设置 Add 遇到缓冲已满时的处理策略（默认 Block）
+func (c PipelineConfig) WithOverloadPolicy(policy OverloadPolicy) PipelineConfig {
+	c.OverloadPolicy = policy
+	return c
+}
+
+// WithStartupGracePeriod 设置每次运行开始后的启动宽限期（0 表示禁用）
+func (c PipelineConfig) WithStartupGracePeriod(d time.Duration) PipelineConfig {
+	c.StartupGracePeriod = d
+	return c
+}
+
+// WithReceiveBatch 设置每次 select 后最多额外连续接收的条数（0 表示禁用）
+func (c PipelineConfig) WithReceiveBatch(k uint32) PipelineConfig {
+	c.ReceiveBatch = k
+	return c
+}
+
+// WithPanicStackTrace 设置 flush panic 被恢复时是否输出完整调用栈（默认 false）
+func (c PipelineConfig) WithPanicStackTrace(enabled bool) PipelineConfig {
+	c.PanicStackTrace = enabled
+	return c
+}
+
+// WithMinFlushSize 设置批次最小大小（0 表示禁用），等价于 WithFlushCondition(SizeThenInterval, n)
+// 未达 n 条的批次不会因定时或高水位触发 flush，仅在批满、关闭、取消收尾或显式 Flush/FlushSync 时写出
+func (c PipelineConfig) WithMinFlushSize(n uint32) PipelineConfig {
+	if n == 0 {
+		return c.WithFlushCondition(SizeOrInterval, 0)
+	}
+	return c.WithFlushCondition(SizeThenInterval, n)
+}
+
+// WithMaxDedupItems 设置去重窗口内写入条数（含重复数据）的上限（0 表示不限制）
+func (c PipelineConfig) WithMaxDedupItems(n uint32) PipelineConfig {
+	c.MaxDedupItems = n
+	return c
+}
+
+// WithUseMapReuse 设置去重管道是否在同步 flush 后复用批处理 map（默认 false）
+func (c PipelineConfig) WithUseMapReuse(enabled bool) PipelineConfig {
+	c.UseMapReuse = enabled
+	return c
+}
+
+// WithHeartbeatInterval 设置不可重置的心跳周期（0 表示禁用）
+func (c PipelineConfig) WithHeartbeatInterval(d time.Duration) PipelineConfig {
+	c.HeartbeatInterval = d
+	return c
+}
+
+// WithDrainMode 设置取消收尾抽干数据通道的方式（仅在 DrainOnCancel 启用时生效）
+func (c PipelineConfig) WithDrainMode(mode DrainMode) PipelineConfig {
+	c.DrainMode = mode
+	return c
+}
+
+// WithMaxFlushLingerTimeout 设置主循环退出后等待在途异步 flush 的最长时间（0 表示不等待）
+func (c PipelineConfig) WithMaxFlushLingerTimeout(d time.Duration) PipelineConfig {
+	c.MaxFlushLingerTimeout = d
+	return c
+}
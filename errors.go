@@ -10,4 +10,16 @@ var (
 	ErrChannelIsClosed  = errors.New("channel is closed")
 	ErrContextDrained   = errors.New("context drained")
 	ErrAlreadyRunning   = errors.New("pipeline already running")
+	ErrFlushPanic       = errors.New("flush panic recovered")
+	ErrBufferFull       = errors.New("buffer is full")
+	ErrMemoryLimit      = errors.New("buffered bytes limit exceeded")
+	ErrInvalidConfig    = errors.New("invalid pipeline config")
+	ErrRestartRequired  = errors.New("config change requires restart")
+	ErrUnknownProcessor = errors.New("unknown processor")
+	ErrNotRunning       = errors.New("pipeline is not running")
+	ErrPipelineClosed   = errors.New("pipeline is closed")
+	ErrCommitTimeout    = errors.New("batch commit timed out")
+	// ErrStopPipeline 由 flush 函数返回（可被包装），表示下游已永久不可用，请求管道停止运行；
+	// 与普通（可重试的瞬时）错误不同，它不会进入错误通道与重试队列
+	ErrStopPipeline = errors.New("pipeline stop requested by flush")
 )
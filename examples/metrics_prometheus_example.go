@@ -32,6 +32,7 @@ type PromMetrics struct {
 	finalFlushTO prometheus.Counter
 	drainFlush   prometheus.Counter
 	drainFlushTO prometheus.Counter
+	bufferSat    prometheus.Gauge
 	// Optionally, track error channel saturation via external sampler (see README).
 }
 
@@ -89,7 +90,13 @@ func NewPromMetrics(reg prometheus.Registerer) *PromMetrics {
 		Help:      "Count of drain flushes that timed out",
 	})
 
-	reg.MustRegister(flushLatency, flushSize, flushSuccess, flushFailure, errorCount, errorDropped, finalFlushTO, drainFlush, drainFlushTO)
+	bufferSat := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gopipeline",
+		Name:      "buffer_saturation_ratio",
+		Help:      "Data channel occupancy ratio sampled by the perform loop on each timer tick",
+	})
+
+	reg.MustRegister(flushLatency, flushSize, flushSuccess, flushFailure, errorCount, errorDropped, finalFlushTO, drainFlush, drainFlushTO, bufferSat)
 
 	return &PromMetrics{
 		flushLatency: flushLatency,
@@ -101,6 +108,7 @@ func NewPromMetrics(reg prometheus.Registerer) *PromMetrics {
 		finalFlushTO: finalFlushTO,
 		drainFlush:   drainFlush,
 		drainFlushTO: drainFlushTO,
+		bufferSat:    bufferSat,
 	}
 }
 
@@ -127,6 +135,11 @@ func (m *PromMetrics) ErrorDropped() {
 	m.errorDropped.Inc()
 }
 
+// BufferSaturation implements the optional BufferSaturationHook extension: sampled by the loop on each tick.
+func (m *PromMetrics) BufferSaturation(ratio float64) {
+	m.bufferSat.Set(ratio)
+}
+
 // Below are optional helpers to be called from your flush wrapper when specific conditions occur.
 // The pipeline can also expose hooks in future to call these directly.
 func (m *PromMetrics) FinalFlushTimeout() { m.finalFlushTO.Inc() }
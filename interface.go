@@ -36,6 +36,14 @@ type DataProcessor[T any] interface {
 	isBatchEmpty(batchData any) bool
 }
 
+// batchContextBinder 是 DataProcessor 的可选扩展
+// 实现该接口的处理器可在批次交给 flush 之前，把仅属于该批次的附加信息绑定到 ctx（如去重计数）
+// 在主循环内、批容器被替换之前调用
+type batchContextBinder interface {
+	// bindBatchContext 返回携带批次 batchData 附加信息的 ctx
+	bindBatchContext(ctx context.Context, batchData any) context.Context
+}
+
 // PipelineChannel 定义了管道的通道接口
 type PipelineChannel[T any] interface {
 
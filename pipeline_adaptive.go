@@ -0,0 +1,110 @@
+package gopipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// 自适应间隔的默认参数
+const (
+	defaultAdaptiveTargetFill = 0.7
+	defaultAdaptiveWindow     = 16
+	// adaptiveDeadband 平均填充率与目标的相对偏差在该范围内时不调整，避免来回抖动
+	adaptiveDeadband = 0.1
+	// adaptiveMaxStep 单次调整的最大倍数（放大至多 2 倍、缩小至多一半）
+	adaptiveMaxStep = 2.0
+)
+
+// AdaptiveInterval 自适应 flush 间隔控制器的参数
+// 控制器按最近 Window 次 flush 的平均填充率（批大小 / FlushSize）调整 FlushInterval，使批次平均填充到 TargetFill：
+// 批次偏小（多由定时器触发）时延长间隔以提高批处理效率，批次偏满（多由批满触发）时缩短间隔以降低延迟
+type AdaptiveInterval struct {
+	// TargetFill 目标平均填充率，取值 (0, 1]（<=0 或 >1 时为 0.7）
+	TargetFill float64
+	// MinInterval 间隔下限（<=0 时为 1ms）
+	MinInterval time.Duration
+	// MaxInterval 间隔上限（<=0 或小于 MinInterval 时取 MinInterval）
+	MaxInterval time.Duration
+	// Window 每次调整所依据的 flush 次数（0 时为 16）
+	Window int
+}
+
+// intervalController 自适应间隔的运行时状态（flush 协程并发写）
+type intervalController struct {
+	cfg AdaptiveInterval
+
+	mu    sync.Mutex
+	count int
+	fill  float64 // 窗口内填充率之和
+}
+
+// WithAdaptiveInterval 启用自适应 flush 间隔（可选）
+// 说明:
+//   - 每次 flush 完成后记录批次填充率（超过 FlushSize 的批次按 1 计），每满 Window 次按平均值调用 UpdateFlushInterval：
+//     新间隔 = 当前间隔 × TargetFill / 平均填充率，单次至多放大 2 倍或缩小一半，并限制在 [MinInterval, MaxInterval]
+//   - 平均填充率与目标的相对偏差不超过 10% 时不调整
+//   - 空批次（如心跳 flush）计为填充率 0，会推动间隔变长；运行期间手动调用 UpdateFlushInterval 会在下一个窗口被覆盖
+func (p *PipelineImpl[T]) WithAdaptiveInterval(cfg AdaptiveInterval) *PipelineImpl[T] {
+	if cfg.TargetFill <= 0 || cfg.TargetFill > 1 {
+		cfg.TargetFill = defaultAdaptiveTargetFill
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = time.Millisecond
+	}
+	if cfg.MaxInterval < cfg.MinInterval {
+		cfg.MaxInterval = cfg.MinInterval
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultAdaptiveWindow
+	}
+	p.adaptive = &intervalController{cfg: cfg}
+	return p
+}
+
+// observeFill 记录一次 flush 的批大小，窗口满时按需调整 FlushInterval
+func (p *PipelineImpl[T]) observeFill(items int) {
+	c := p.adaptive
+	fill := float64(items) / float64(p.CurrentFlushSize())
+	if fill > 1 {
+		fill = 1
+	}
+
+	c.mu.Lock()
+	c.count++
+	c.fill += fill
+	if c.count < c.cfg.Window {
+		c.mu.Unlock()
+		return
+	}
+	avg := c.fill / float64(c.count)
+	c.count, c.fill = 0, 0
+	c.mu.Unlock()
+
+	if next, ok := c.nextInterval(p.CurrentFlushInterval(), avg); ok {
+		p.UpdateFlushInterval(next)
+	}
+}
+
+// nextInterval 根据窗口平均填充率计算新的间隔；无需调整时返回 false
+func (c *intervalController) nextInterval(cur time.Duration, avg float64) (time.Duration, bool) {
+	target := c.cfg.TargetFill
+	if diff := avg - target; diff <= target*adaptiveDeadband && diff >= -target*adaptiveDeadband {
+		return 0, false
+	}
+	factor := adaptiveMaxStep
+	if avg > 0 {
+		factor = target / avg
+	}
+	if factor > adaptiveMaxStep {
+		factor = adaptiveMaxStep
+	} else if factor < 1/adaptiveMaxStep {
+		factor = 1 / adaptiveMaxStep
+	}
+	next := time.Duration(float64(cur) * factor)
+	if next < c.cfg.MinInterval {
+		next = c.cfg.MinInterval
+	} else if next > c.cfg.MaxInterval {
+		next = c.cfg.MaxInterval
+	}
+	return next, next != cur
+}
@@ -0,0 +1,121 @@
+package gopipeline
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// AffinityKeyFunc 返回批次的代表键（如首条数据的分区键），相同代表键的批次总是进入同一 flush 通道
+// 参数 batchData 为批处理数据（标准管道为 []T，去重管道为 map[string]T）
+type AffinityKeyFunc func(batchData any) string
+
+// affinityConfig 按键亲和的并行 flush 配置
+type affinityConfig struct {
+	lanes   int
+	keyFunc AffinityKeyFunc
+}
+
+// laneTask 提交给 flush 通道的一次 flush
+type laneTask struct {
+	ctx   context.Context
+	batch any
+	bytes int64
+	done  chan struct{} // 同步 flush 时用于等待完成（异步时为 nil）
+}
+
+// WithFlushAffinity 启用按键亲和的有界并行 flush（可选）
+// 参数:
+//   - lanes: flush 通道数，即最大并行度（<=0 时为 1）
+//   - keyFunc: 返回批次代表键的函数
+//
+// 说明:
+//   - 代表键经哈希映射到固定通道，通道内串行执行，因此相同代表键的批次不会并发、且保持派发顺序；不同通道并行执行
+//   - 每个通道最多排队一个待执行批次，排满时主循环阻塞形成背压；启用后 MaxConcurrentFlushes 不再生效
+//   - 同步 flush（含关闭/收尾路径的最终 flush）同样经过通道并等待完成，确保不会与同键的在途批次并发
+//   - 通道协程随每次运行启动，Perform 返回前会等待已派发的批次全部完成
+func (p *PipelineImpl[T]) WithFlushAffinity(lanes int, keyFunc AffinityKeyFunc) *PipelineImpl[T] {
+	if lanes <= 0 {
+		lanes = 1
+	}
+	p.affinity = &affinityConfig{lanes: lanes, keyFunc: keyFunc}
+	return p
+}
+
+// startLanes 为本次运行启动 flush 通道协程，返回用于关闭通道并等待在途批次完成的函数
+func (p *PipelineImpl[T]) startLanes() func() {
+	if p.affinity == nil {
+		return func() {}
+	}
+	var wg sync.WaitGroup
+	lanes := make([]chan laneTask, p.affinity.lanes)
+	for i := range lanes {
+		ch := make(chan laneTask, 1)
+		lanes[i] = ch
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range ch {
+				p.runLaneTask(t)
+			}
+		}()
+	}
+	p.lanes = lanes
+	return func() {
+		p.lanes = nil
+		for _, ch := range lanes {
+			close(ch)
+		}
+		wg.Wait()
+	}
+}
+
+// submitToLane 将批次派发到其代表键对应的通道；同步模式下等待该批次 flush 完成
+func (p *PipelineImpl[T]) submitToLane(ctx context.Context, async bool, batchData any, bytes int64) {
+	t := laneTask{ctx: ctx, batch: batchData, bytes: bytes}
+	if !async {
+		t.done = make(chan struct{})
+	} else {
+		p.asyncFlushes.Add(1)
+	}
+	p.lanes[p.keyIndex(p.affinity.keyFunc(batchData), len(p.lanes))] <- t
+	if t.done != nil {
+		<-t.done
+	}
+}
+
+// runLaneTask 在通道协程内执行一次 flush
+func (p *PipelineImpl[T]) runLaneTask(t laneTask) {
+	defer func() {
+		p.releaseBytes(t.bytes)
+		if t.done != nil {
+			close(t.done)
+		} else {
+			p.asyncFlushes.Done()
+		}
+	}()
+	p.flushWithErrorChan(t.ctx, t.batch)
+}
+
+// WithKeyHasher 注入键哈希函数（可选），控制按键分区（如 WithFlushAffinity 的通道选择）的分布
+// 默认使用 FNV-1a 64 位哈希；需要与外部分片映射严格对齐时可替换为下游使用的同一哈希
+func (p *PipelineImpl[T]) WithKeyHasher(fn func(string) uint64) *PipelineImpl[T] {
+	p.keyHasher = fn
+	return p
+}
+
+// keyIndex 将键哈希后映射到 [0, n) 的分区下标
+func (p *PipelineImpl[T]) keyIndex(key string, n int) int {
+	hash := defaultKeyHash
+	if p.keyHasher != nil {
+		hash = p.keyHasher
+	}
+	return int(hash(key) % uint64(n))
+}
+
+// defaultKeyHash 默认的键哈希函数（FNV-1a 64 位）
+func defaultKeyHash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
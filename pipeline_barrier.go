@@ -0,0 +1,69 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// barrierRequest Barrier 请求：检查点回调与应答通道
+type barrierRequest struct {
+	checkpoint func() error
+	reply      chan error
+}
+
+// Barrier 建立一致性屏障：暂停接收，flush 屏障之前写入的全部数据，在暂停状态下调用检查点回调，随后恢复接收
+// 参数:
+//   - ctx: 上下文对象，用于限制等待时长（不会传给 flush 函数或 checkpoint）
+//   - checkpoint: 检查点回调（如提交上游偏移量、记录外部系统的快照），在所有屏障前数据落定后、恢复接收前调用
+//
+// 返回值:
+//   - nil: 屏障前的数据均已 flush 成功，且 checkpoint 返回 nil
+//   - flush 返回的错误: 屏障前的批次 flush 失败（同时照常上报错误通道与重试队列），此时不调用 checkpoint
+//   - checkpoint 返回的错误
+//   - ErrNotRunning: 管道未运行（或运行已结束）
+//   - 包装了 ErrContextIsClosed 的错误: ctx 先结束；请求已被主循环接收时屏障仍会照常完成
+//
+// 说明:
+//   - 请求由主循环在两个事件之间处理：先把请求时刻通道中已缓冲的数据并入当前批次（可超出 FlushSize）并同步 flush，
+//     再等待此前派发的异步 flush 全部完成，因此调用前已通过 Add 或 DataChan 写入的数据在 checkpoint 之前一定已经处理完毕
+//   - 整个过程在主循环内执行，期间不接收新数据：通道缓冲满后生产者的 Add/DataChan 写入将阻塞（TryAdd 返回 ErrBufferFull）；
+//     checkpoint 应尽快返回，且不得调用需要主循环应答的方法（FlushSync、Snapshot、Barrier、StopAndCollect），否则会死锁
+//   - PauseFlush 与启动宽限期不阻止屏障的 flush；重试队列中待重放的批次不在等待范围内
+func (p *PipelineImpl[T]) Barrier(ctx context.Context, checkpoint func() error) error {
+	done := p.Done()
+	if done == nil {
+		return ErrNotRunning
+	}
+	req := barrierRequest{checkpoint: checkpoint, reply: make(chan error, 1)}
+	select {
+	case p.barrierReq <- req:
+	case <-done:
+		return ErrNotRunning
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+	select {
+	case err := <-req.reply:
+		return err
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+}
+
+// handleBarrier 在主循环内处理 Barrier 请求：同步 flush 屏障前的数据、等待在飞的异步 flush，再调用检查点回调
+func (p *PipelineImpl[T]) handleBarrier(ctx context.Context, st *batchState, timer *time.Timer, req barrierRequest) {
+	defer p.resetTimer(timer)
+	p.absorbBuffered(st)
+	var err error
+	if !p.processor.isBatchEmpty(st.data) {
+		reply := make(chan error, 1)
+		p.flushBatch(context.WithValue(ctx, forcedFlushKey{}, reply), false, st)
+		err = <-reply
+	}
+	p.asyncFlushes.Wait()
+	if err == nil && req.checkpoint != nil {
+		err = req.checkpoint()
+	}
+	req.reply <- err
+}
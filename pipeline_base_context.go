@@ -0,0 +1,36 @@
+package gopipeline
+
+import "context"
+
+// WithBaseContext 设置管道级的基础 ctx（可选），其取消会结束当前及之后的所有运行
+// 说明:
+//   - 每次运行的 ctx 为 Perform/Start/Run 传入的 ctx 与基础 ctx 的合并：任一取消即按取消语义（DrainOnCancel 等）退出，
+//     ctx 携带的值仍取自传入的 ctx
+//   - 基础 ctx 已取消时，之后的运行会立即按取消语义退出；如需永久关闭管道请使用 Close
+//   - 运行结束后基础 ctx 的取消不再传递给仍在进行的异步 flush（可配合 MaxFlushLingerTimeout 约束其时长）
+//   - 用于将管道生命周期绑定到应用级 ctx，无需在每次调用时传递；传入 nil 表示取消设置
+//   - 应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithBaseContext(ctx context.Context) *PipelineImpl[T] {
+	p.baseCtx = ctx
+	return p
+}
+
+// mergeBaseContext 启用 WithBaseContext 时派生同时受基础 ctx 与运行 ctx 取消的 ctx
+// 返回的 release 须在运行结束时调用：它只停止转发基础 ctx 的取消，不取消派生的 ctx，以免打断仍在进行的异步 flush
+func (p *PipelineImpl[T]) mergeBaseContext(ctx context.Context) (context.Context, func()) {
+	base := p.baseCtx
+	if base == nil {
+		return ctx, func() {}
+	}
+	merged, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-base.Done():
+			cancel()
+		case <-merged.Done():
+		case <-stop:
+		}
+	}()
+	return merged, func() { close(stop) }
+}
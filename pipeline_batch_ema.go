@@ -0,0 +1,62 @@
+package gopipeline
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// batchSizeEMA 已 flush 批次大小的指数移动平均（任意 flush 协程并发写，任意协程可读）
+type batchSizeEMA struct {
+	alpha float64
+	bits  atomic.Uint64 // 当前均值的 float64 位表示；尚无样本时为 NaN
+}
+
+// observe 以 alpha 将一次批大小并入均值；首个样本直接作为初值
+func (e *batchSizeEMA) observe(size int) {
+	x := float64(size)
+	for {
+		old := e.bits.Load()
+		avg := math.Float64frombits(old)
+		next := x
+		if !math.IsNaN(avg) {
+			next = avg + e.alpha*(x-avg)
+		}
+		if e.bits.CompareAndSwap(old, math.Float64bits(next)) {
+			return
+		}
+	}
+}
+
+// WithBatchSizeEMA 启用已 flush 批次大小的指数移动平均（可选，alpha <= 0 表示禁用）
+// 参数:
+//   - alpha: 平滑系数，取值 (0, 1]（>1 时按 1 计）；越大越跟随最近的批次，越小越平滑
+//
+// 说明:
+//   - 主循环派发的每次 flush（含空批次心跳与收尾 flush）更新一次：avg += alpha × (size - avg)，首个批次直接作为初值；重试重放不计入
+//   - 与按窗口调整的 WithAdaptiveInterval 互补，可用于判断是否需要调大 FlushSize 或在看板上展示平滑的批大小
+//   - 开销: 每次 flush 一次乘加与一次 CAS；应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithBatchSizeEMA(alpha float64) *PipelineImpl[T] {
+	if alpha <= 0 {
+		p.sizeEMA = nil
+		return p
+	}
+	if alpha > 1 {
+		alpha = 1
+	}
+	e := &batchSizeEMA{alpha: alpha}
+	e.bits.Store(math.Float64bits(math.NaN()))
+	p.sizeEMA = e
+	return p
+}
+
+// AvgBatchSize 返回已 flush 批次大小的指数移动平均（未启用或尚无 flush 时返回 0）
+func (p *PipelineImpl[T]) AvgBatchSize() float64 {
+	if p.sizeEMA == nil {
+		return 0
+	}
+	avg := math.Float64frombits(p.sizeEMA.bits.Load())
+	if math.IsNaN(avg) {
+		return 0
+	}
+	return avg
+}
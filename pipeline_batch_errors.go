@@ -0,0 +1,51 @@
+package gopipeline
+
+import "fmt"
+
+// FlushError 携带失败批次的类型化错误，由 TypedErrorChan 下发
+type FlushError[T any] struct {
+	// Batch 失败批次的副本（标准管道按入批顺序，去重管道为 map 的值；批次无法表示为 []T 时为 nil）
+	Batch []T
+	// Err flush 返回的错误
+	Err error
+}
+
+func (e FlushError[T]) Error() string {
+	return fmt.Sprintf("flush failed (items=%d): %v", len(e.Batch), e.Err)
+}
+
+func (e FlushError[T]) Unwrap() error {
+	return e.Err
+}
+
+// WithBatchErrors 启用类型化错误通道（可选），每次 flush 失败时连同失败批次写入 TypedErrorChan
+// 参数:
+//   - size: 通道容量（<=0 时与 ErrorChan 的默认容量一致）
+//
+// 说明:
+//   - 与 ErrorChan 并行工作，不改变原有错误通道的行为；重试队列的重放失败同样下发
+//   - 非阻塞写入，通道满时丢弃本条（失败批次仍按重试队列/死信的既有路径处理）
+//   - 批次为副本，读取方可直接持有或修改；每次失败复制一次批次，仅在需要时启用
+func (p *PipelineImpl[T]) WithBatchErrors(size int) *PipelineImpl[T] {
+	if size <= 0 {
+		size = p.defaultErrBufSize()
+	}
+	p.batchErrs = make(chan FlushError[T], size)
+	return p
+}
+
+// TypedErrorChan 返回类型化错误通道；未调用 WithBatchErrors 时返回 nil
+func (p *PipelineImpl[T]) TypedErrorChan() <-chan FlushError[T] {
+	return p.batchErrs
+}
+
+// sendBatchError 非阻塞地将失败批次写入类型化错误通道（未启用时忽略）
+func (p *PipelineImpl[T]) sendBatchError(batchData any, err error) {
+	if p.batchErrs == nil {
+		return
+	}
+	select {
+	case p.batchErrs <- FlushError[T]{Batch: snapshotBatch[T](batchData), Err: err}:
+	default:
+	}
+}
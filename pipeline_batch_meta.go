@@ -0,0 +1,73 @@
+package gopipeline
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// BatchMeta 批次的元信息，用于在日志与下游之间关联同一批次（从组批到 flush）
+type BatchMeta struct {
+	// ID 批次关联 ID：管道内从 1 开始单调递增，跨多次运行连续
+	ID uint64
+	// OpenedAt 首条数据进入该批次的时间（空批次的心跳 flush 为零值）
+	OpenedAt time.Time
+}
+
+// FlushStandardMetaFunc 携带批次元信息的标准刷新函数
+type FlushStandardMetaFunc[T any] func(ctx context.Context, meta BatchMeta, batchData []T) error
+
+// batchMetaKey BatchMeta 在 flush 的 ctx 中的键
+type batchMetaKey struct{}
+
+// WithBatchMeta 为主循环派发的每个批次分配 BatchMeta 并绑定到 flush 的 ctx（可选）
+// 说明:
+//   - flush 函数内可通过 BatchMetaFrom(ctx) 读取；NewStandardPipelineWithMeta 会自动启用并直接传入 meta
+//   - 启用后内部日志（flush panic、调用栈）附带 "batch=<ID>"；注入了 WithLogger 时，flush 失败也会以该 ID 记录一行日志
+//   - 重试队列重放的批次不经过主循环派发，不携带 BatchMeta
+//   - 每个批次一次原子递增与一次 context.WithValue，默认关闭
+func (p *PipelineImpl[T]) WithBatchMeta(enabled bool) *PipelineImpl[T] {
+	p.batchMeta = enabled
+	return p
+}
+
+// BatchMetaFrom 返回 flush 的 ctx 中携带的批次元信息（未启用 WithBatchMeta 或为重放批次时 ok 为 false）
+func BatchMetaFrom(ctx context.Context) (meta BatchMeta, ok bool) {
+	meta, ok = ctx.Value(batchMetaKey{}).(BatchMeta)
+	return meta, ok
+}
+
+// NewStandardPipelineWithMeta 使用自定义配置创建一个标准管道实例，刷新函数额外接收批次元信息
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 携带 BatchMeta 的刷新函数
+//
+// 返回值: 返回一个已启用 WithBatchMeta 的 StandardPipeline 实例
+// 说明: 重试队列重放的批次收到零值 BatchMeta
+func NewStandardPipelineWithMeta[T any](
+	config PipelineConfig,
+	flushFunc FlushStandardMetaFunc[T],
+) *StandardPipeline[T] {
+	p := NewStandardPipeline(config, func(ctx context.Context, batchData []T) error {
+		meta, _ := BatchMetaFrom(ctx)
+		return flushFunc(ctx, meta, batchData)
+	})
+	p.WithBatchMeta(true)
+	return p
+}
+
+// bindBatchMeta 启用 WithBatchMeta 时为即将派发的批次分配 ID 并绑定到 ctx（仅主循环调用）
+func (p *PipelineImpl[T]) bindBatchMeta(ctx context.Context, openedAt time.Time) context.Context {
+	if !p.batchMeta {
+		return ctx
+	}
+	return context.WithValue(ctx, batchMetaKey{}, BatchMeta{ID: p.batchSeq.Add(1), OpenedAt: openedAt})
+}
+
+// batchLogPrintln 输出与批次相关的内部日志：ctx 携带 BatchMeta 时附带 "batch=<ID>"
+func (p *PipelineImpl[T]) batchLogPrintln(ctx context.Context, v ...any) {
+	if meta, ok := BatchMetaFrom(ctx); ok {
+		v = append([]any{"batch=" + strconv.FormatUint(meta.ID, 10)}, v...)
+	}
+	p.logPrintln(v...)
+}
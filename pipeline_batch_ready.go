@@ -0,0 +1,86 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchReadyFunc 批次就绪回调：批满或定时触发时调用，commit 被调用后才真正 flush 该批次
+// commit 阻塞到该次 flush 完成并返回其错误；超时后再调用 commit 返回 ErrCommitTimeout。重复调用返回首次的结果
+type BatchReadyFunc func(commit func() error)
+
+// batchReadyConfig 外部协调 flush 的配置
+type batchReadyConfig struct {
+	fn      BatchReadyFunc
+	timeout time.Duration
+}
+
+// readyCommit 一次批次就绪的提交握手
+type readyCommit struct {
+	req     chan struct{} // 提交请求（无缓冲：仅在主循环等待期间可被接收）
+	reply   chan error    // 该次 flush 的结果（经 forcedFlushKey 由 flushAndReport 通知）
+	expired chan struct{} // 主循环已停止等待（超时或 ctx 结束）
+
+	once sync.Once
+	err  error
+}
+
+// WithBatchReady 启用外部协调的 flush（可选），用于跨多个管道的全局提交（两阶段提交）
+// 参数:
+//   - fn: 批次就绪回调，在独立协程中调用，可在完成跨管道协调后调用 commit
+//   - timeout: 等待 commit 的最长时间（<=0 表示一直等待，直到 ctx 结束）
+//
+// 说明:
+//   - 批满、高水位与定时触发的 flush 先回调 fn，主循环等待 commit 被调用后再 flush，等待期间不接收新数据（背压同 PauseFlush）
+//   - 超时未提交时照常 flush 该批次（避免丢数据与主循环停滞），并向错误通道上报 ErrCommitTimeout
+//   - 等待期间 ctx 结束时停止等待，批次按取消语义（DrainOnCancel）处理
+//   - 关闭/取消收尾、FlushSync 与空批次心跳不经过协调，直接 flush
+func (p *PipelineImpl[T]) WithBatchReady(fn BatchReadyFunc, timeout time.Duration) *PipelineImpl[T] {
+	p.batchReady = &batchReadyConfig{fn: fn, timeout: timeout}
+	return p
+}
+
+// flushWhenCommitted 未启用 WithBatchReady 时直接 flush；否则回调就绪函数并等待提交或超时
+func (p *PipelineImpl[T]) flushWhenCommitted(ctx context.Context, async bool, st *batchState) {
+	if p.batchReady == nil {
+		p.flushBatch(ctx, async, st)
+		return
+	}
+	c := &readyCommit{
+		req:     make(chan struct{}),
+		reply:   make(chan error, 1),
+		expired: make(chan struct{}),
+	}
+	go p.batchReady.fn(c.commit)
+
+	var timeout <-chan time.Time
+	if p.batchReady.timeout > 0 {
+		t := time.NewTimer(p.batchReady.timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-c.req:
+		p.flushBatch(context.WithValue(ctx, forcedFlushKey{}, c.reply), async, st)
+	case <-timeout:
+		close(c.expired)
+		p.safeErrorSend(ErrCommitTimeout)
+		p.flushBatch(ctx, async, st)
+	case <-ctx.Done():
+		close(c.expired)
+	}
+}
+
+// commit 请求主循环 flush 就绪批次，并等待该次 flush 的结果
+func (c *readyCommit) commit() error {
+	c.once.Do(func() {
+		select {
+		case c.req <- struct{}{}:
+			c.err = <-c.reply
+		case <-c.expired:
+			c.err = ErrCommitTimeout
+		}
+	})
+	return c.err
+}
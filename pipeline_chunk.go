@@ -0,0 +1,66 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+)
+
+// flushSliceChunks 按 maxChunk 将切片批次拆分为若干子切片，并依次调用 flushFunc
+// 参数:
+//   - ctx: 上下文对象；ctx 结束后不再发起后续分片的 flush（首个分片总会执行，与不拆分时一致）
+//   - batch: 待刷新的完整批次
+//   - maxChunk: 单次 flush 的最大元素数（0 表示不拆分）
+//   - flushFunc: 用户的刷新函数
+//
+// 返回值: 所有失败分片错误的聚合（errors.Join），全部成功时返回 nil
+func flushSliceChunks[T any](ctx context.Context, batch []T, maxChunk uint32, flushFunc FlushStandardFunc[T]) error {
+	n := int(maxChunk)
+	if n <= 0 || len(batch) <= n {
+		return flushFunc(ctx, batch)
+	}
+	var errs []error
+	for start := 0; start < len(batch); start += n {
+		if err := ctx.Err(); err != nil && start > 0 {
+			errs = append(errs, err)
+			break
+		}
+		end := start + n
+		if end > len(batch) {
+			end = len(batch)
+		}
+		// 使用三索引切片限制容量，避免 flushFunc 的 append 覆盖后续分片
+		if err := flushFunc(ctx, batch[start:end:end]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// joinErrors 聚合多个分片错误；仅一个错误时原样返回，保持与不拆分时相同的错误类型（便于类型断言）
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// chunkMap 按 maxChunk 将 map 批次拆分为若干子 map（0 表示不拆分）
+func chunkMap[K comparable, T any](batch map[K]T, maxChunk uint32) []map[K]T {
+	n := int(maxChunk)
+	if n <= 0 || len(batch) <= n {
+		return []map[K]T{batch}
+	}
+	chunks := make([]map[K]T, 0, (len(batch)+n-1)/n)
+	cur := make(map[K]T, n)
+	for k, v := range batch {
+		cur[k] = v
+		if len(cur) == n {
+			chunks = append(chunks, cur)
+			cur = make(map[K]T, n)
+		}
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
@@ -0,0 +1,73 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+)
+
+// Close 永久关闭管道：关闭数据通道，等待当前运行完成最终 flush 后关闭 Closed() 返回的通道
+// 参数:
+//   - ctx: 限制等待当前运行结束的时长
+//
+// 返回值:
+//   - nil: 管道已关闭（未在运行时立即返回）
+//   - ErrContextIsClosed: 当前运行结束前 ctx 已结束（同时包装 ctx.Err()）；关闭仍会在运行结束后完成
+//
+// 说明:
+//   - 调用前应先停止生产者；之后的 Add 返回 ErrChannelIsClosed，再次 Perform/Start/Run 返回 ErrPipelineClosed
+//   - 数据通道已由写入方关闭时同样适用；重复调用是幂等的，均等待同一个关闭信号
+func (p *PipelineImpl[T]) Close(ctx context.Context) error {
+	p.runMu.Lock()
+	closed := p.closedChanLocked()
+	if !p.closing {
+		p.closing = true
+		p.closeDataChan()
+		if p.runDone == nil {
+			// 未在运行：没有需要等待的最终 flush
+			p.markClosedLocked()
+		}
+	}
+	p.runMu.Unlock()
+
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+}
+
+// Closed 返回管道永久关闭的信号通道，在 Close 后当前运行结束（未在运行时为 Close 调用时）关闭
+// 与 Done() 的区别:
+//   - Done() 只表示“最近一次运行”结束，每次 Perform 都会替换，持有旧通道的代码观察不到之后的运行
+//   - Closed() 在管道的整个生命周期内是同一个通道，只会因显式 Close 而关闭，一旦关闭不再恢复
+func (p *PipelineImpl[T]) Closed() <-chan struct{} {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	return p.closedChanLocked()
+}
+
+// closedChanLocked 返回（必要时创建）关闭信号通道，调用方须持有 runMu
+func (p *PipelineImpl[T]) closedChanLocked() chan struct{} {
+	if p.closed == nil {
+		p.closed = make(chan struct{})
+	}
+	return p.closed
+}
+
+// markClosedLocked 在已请求 Close 时关闭信号通道（仅一次），调用方须持有 runMu
+func (p *PipelineImpl[T]) markClosedLocked() {
+	if !p.closing || p.closedDone {
+		return
+	}
+	close(p.closedChanLocked())
+	p.closedDone = true
+}
+
+// isClosed 判断管道是否已永久关闭
+// 已请求 Close 但仍在等待的运行（如 Start 已创建 done、主循环尚未启动）照常执行，以完成已缓冲数据的最终 flush
+func (p *PipelineImpl[T]) isClosed() bool {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	return p.closedDone
+}
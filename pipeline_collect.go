@@ -0,0 +1,72 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StopAndCollect 停止运行中的管道，并把尚未 flush 的剩余数据（当前批次 + 通道中已缓冲的数据）交还给调用方
+// 返回值:
+//   - 剩余数据：先是当前批次（标准管道按入批顺序；去重管道为 map 的值，顺序不定），后是通道中的缓冲数据（按到达顺序）
+//   - error: 在主循环接收请求前 ctx 结束时返回包装了 ErrContextIsClosed 的错误，此时管道继续运行
+//
+// 说明:
+//   - 交还的数据不会调用 flush 函数；已派发的异步 flush 不受影响，会照常完成
+//   - 主循环应答后立即退出，本次运行的 Perform/Run 返回 nil，done 随之关闭；不会触发 OnClose/OnCancel 回调
+//   - 只取请求时刻已缓冲的数据；调用前应先停止生产者，之后写入 DataChan 的数据留在通道中，由下一次运行处理
+//   - 当前批次无法表示为 []T 时（如 TransformPipeline 已转换的批次），该批次在退出前同步 flush，不会丢失
+//   - 管道未运行时直接取出通道中已缓冲的数据返回
+//   - 用于迁移期间把剩余数据移交给其他系统
+func (p *PipelineImpl[T]) StopAndCollect(ctx context.Context) ([]T, error) {
+	if done := p.Done(); done != nil {
+		reply := make(chan []T, 1)
+		select {
+		case p.collectReq <- reply:
+			return <-reply, nil
+		case <-done:
+			// 运行已结束：按未运行处理
+		case <-ctx.Done():
+			return nil, errors.Join(ErrContextIsClosed, ctx.Err())
+		}
+	}
+	return p.takeBuffered(nil), nil
+}
+
+// handleCollect 在主循环内处理 StopAndCollect 请求：交还剩余数据后退出主循环
+func (p *PipelineImpl[T]) handleCollect(st *batchState, reply chan<- []T) error {
+	var items []T
+	if !p.processor.isBatchEmpty(st.data) {
+		items = snapshotBatch[T](st.data)
+		if items == nil {
+			// 批次元素类型不是 T，无法交还：照常同步 flush
+			p.flushBatch(context.Background(), false, st)
+		}
+	}
+	p.releaseBytes(st.bytes)
+	st.data = p.processor.initBatchData()
+	st.bytes = 0
+	st.stamps = st.stamps[:0]
+	st.openedAt = time.Time{}
+	reply <- p.takeBuffered(items)
+	return nil
+}
+
+// takeBuffered 取出主循环暂存的数据与通道中当前已缓冲的数据（按到达顺序）追加到 items，并释放其内存护栏额度
+func (p *PipelineImpl[T]) takeBuffered(items []T) []T {
+	items = p.takePending(items)
+	n := len(p.dataChan)
+	for i := 0; i < n; i++ {
+		select {
+		case v, ok := <-p.dataChan:
+			if !ok {
+				return items
+			}
+			p.releaseBytes(p.itemBytes(v))
+			items = append(items, v)
+		default:
+			return items
+		}
+	}
+	return items
+}
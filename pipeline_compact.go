@@ -0,0 +1,29 @@
+package gopipeline
+
+// WithCompact 在 flush 前对组装好的批次执行压缩（可选），如合并相邻的重复记录、累加增量
+// 参数:
+//   - fn: 接收当前批次，返回实际交给 flush 函数的批次（可以更短，也可以原地改写后返回子切片）；nil 表示关闭
+//
+// 说明:
+//   - 在主循环协程内、派发 flush 之前逐批调用，无需考虑并发；耗时会直接计入主循环，应保持轻量
+//   - 返回的批次即为被 flush、计入 MetricsHook.Flush 条数、进入重试/死信的批次；组批耗时与年龄等指标仍按压缩前统计
+//   - 空批次（心跳）不调用；压缩结果为空时仍以空批次调用 flush 函数
+//   - 相比按键去重更灵活：可依赖批内顺序，且不要求数据实现 UniqueKeyData
+func (p *StandardPipeline[T]) WithCompact(fn func([]T) []T) *StandardPipeline[T] {
+	if fn == nil {
+		p.compact = nil
+		return p
+	}
+	p.compact = func(batchData any) any {
+		return fn(batchData.([]T))
+	}
+	return p
+}
+
+// compactBatch 启用 WithCompact 时压缩非空批次，否则原样返回
+func (p *PipelineImpl[T]) compactBatch(batchData any) any {
+	if p.compact == nil || p.processor.isBatchEmpty(batchData) {
+		return batchData
+	}
+	return p.compact(batchData)
+}
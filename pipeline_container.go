@@ -0,0 +1,80 @@
+package gopipeline
+
+import "context"
+
+// FlushContainerFunc 处理自定义批容器的刷新函数
+type FlushContainerFunc[C any] func(ctx context.Context, batchData C) error
+
+// ContainerPipeline 使用调用方自定义批容器的管道
+// 批容器的创建、追加、判满、判空与 flush 均由类型化的函数提供，无需实现内部 DataProcessor 接口，也无需 any 断言；
+// 标准管道与去重管道分别相当于以切片和 map 为容器的特例
+type ContainerPipeline[T any, C any] struct {
+	*PipelineImpl[T]
+	newC      func() C
+	add       func(C, T) C
+	isFull    func(C) bool
+	isEmpty   func(C) bool
+	flushFunc FlushContainerFunc[C]
+}
+
+// 确保 ContainerPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*ContainerPipeline[any, any])(nil)
+
+// NewContainerPipeline 使用自定义配置与自定义批容器创建一个管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - newC: 创建空批容器（每次 flush 后都会创建新容器，flush 可安全持有旧容器）
+//   - add: 将一条数据追加到容器并返回更新后的容器
+//   - isFull: 容器是否已满，满时触发 flush
+//   - isEmpty: 容器是否为空，空容器不会在关闭/定时路径上 flush
+//   - flushFunc: 用于处理批容器的刷新函数
+//
+// 返回值: 返回一个新的 ContainerPipeline 实例
+// 说明:
+//   - 批满完全由 isFull 决定，FlushSize 不参与判满（可在 isFull 中读取 CurrentFlushSize 以支持动态调整）
+//   - 容器实现了 Len() int 时，其返回值作为 MetricsHook.Flush 等处的批大小，否则非切片/map 容器按 0 计
+//   - 容器不是 []T 或 map 时 Snapshot 的 batch 为 nil，StopAndCollect 会同步 flush 当前容器而非交还
+//   - 整个容器一次交给 flushFunc，MaxFlushChunk 不生效
+func NewContainerPipeline[T any, C any](
+	config PipelineConfig,
+	newC func() C,
+	add func(C, T) C,
+	isFull func(C) bool,
+	isEmpty func(C) bool,
+	flushFunc FlushContainerFunc[C],
+) *ContainerPipeline[T, C] {
+	p := &ContainerPipeline[T, C]{
+		newC:      newC,
+		add:       add,
+		isFull:    isFull,
+		isEmpty:   isEmpty,
+		flushFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 创建一个新的空批容器
+func (p *ContainerPipeline[T, C]) initBatchData() any {
+	return p.newC()
+}
+
+// addToBatch 将新数据追加到批容器中
+func (p *ContainerPipeline[T, C]) addToBatch(batchData any, data T) any {
+	return p.add(batchData.(C), data)
+}
+
+// flush 使用配置的刷新函数处理批容器
+func (p *ContainerPipeline[T, C]) flush(ctx context.Context, batchData any) error {
+	return p.flushFunc(ctx, batchData.(C))
+}
+
+// isBatchFull 检查批容器是否已满
+func (p *ContainerPipeline[T, C]) isBatchFull(batchData any) bool {
+	return p.isFull(batchData.(C))
+}
+
+// isBatchEmpty 检查批容器是否为空
+func (p *ContainerPipeline[T, C]) isBatchEmpty(batchData any) bool {
+	return p.isEmpty(batchData.(C))
+}
@@ -0,0 +1,104 @@
+package gopipeline
+
+import "context"
+
+// FlushDeduplicationKeyedFunc 处理以 K 为键的去重批次
+type FlushDeduplicationKeyedFunc[T any, K comparable] func(ctx context.Context, batchData map[K]T) error
+
+// KeyedDeduplicationPipeline 以任意可比较类型为去重键的去重管道
+// 语义与 DeduplicationPipeline 一致（窗口内后到的数据覆盖先到的数据），但批次直接使用 map[K]T，
+// 自然键为整数或结构体时无需经 strconv/fmt.Sprintf 转换为字符串，减少高吞吐下的分配
+type KeyedDeduplicationPipeline[T any, K comparable] struct {
+	*PipelineImpl[T]
+	keyFunc   func(T) K
+	flushFunc FlushDeduplicationKeyedFunc[T, K]
+	// added 当前批次的写入条数，含被覆盖的重复数据（仅主循环访问）
+	added int
+}
+
+// 确保 KeyedDeduplicationPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*KeyedDeduplicationPipeline[any, int])(nil)
+
+// 确保 KeyedDeduplicationPipeline 支持 UseMapReuse
+var _ batchReuser = (*KeyedDeduplicationPipeline[any, int])(nil)
+
+// NewDeduplicationPipelineKeyed 使用自定义配置与键函数创建一个以 K 为键的去重管道实例
+// 参数:
+//   - config: 自定义的管道配置（MaxDedupKeys、MaxDedupItems、MaxFlushChunk 同样生效）
+//   - keyFunc: 计算去重键的函数
+//   - flushFunc: 处理 map[K]T 批次的刷新函数
+//
+// 返回值: 返回一个新的 KeyedDeduplicationPipeline 实例
+// 说明: 键为字符串且数据实现了 UniqueKeyData 时，仍可使用 NewDeduplicationPipeline
+func NewDeduplicationPipelineKeyed[T any, K comparable](
+	config PipelineConfig,
+	keyFunc func(T) K,
+	flushFunc FlushDeduplicationKeyedFunc[T, K],
+) *KeyedDeduplicationPipeline[T, K] {
+	p := &KeyedDeduplicationPipeline[T, K]{
+		keyFunc:   keyFunc,
+		flushFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 初始化一个新的批处理 map（按当前 FlushSize 预分配容量）
+func (p *KeyedDeduplicationPipeline[T, K]) initBatchData() any {
+	p.added = 0
+	return make(map[K]T, int(p.CurrentFlushSize()))
+}
+
+// reuseBatchData 清空已 flush 的批处理 map 以供下一批次复用（UseMapReuse）
+func (p *KeyedDeduplicationPipeline[T, K]) reuseBatchData(batchData any) any {
+	bd := batchData.(map[K]T)
+	for k := range bd {
+		delete(bd, k)
+	}
+	p.added = 0
+	return bd
+}
+
+// addToBatch 以 keyFunc 计算的键写入批处理 map，已存在的键被新数据覆盖
+func (p *KeyedDeduplicationPipeline[T, K]) addToBatch(batchData any, data T) any {
+	bd := batchData.(map[K]T)
+	bd[p.keyFunc(data)] = data
+	p.added++
+	return bd
+}
+
+// flush 使用配置的刷新函数处理批处理数据（配置了 MaxFlushChunk 时按键数拆分为子 map 依次刷新）
+func (p *KeyedDeduplicationPipeline[T, K]) flush(ctx context.Context, batchData any) error {
+	bd := batchData.(map[K]T)
+	if h, ok := p.metrics.(DedupKeysHook); ok {
+		h.DedupKeys(len(bd), p.config.MaxDedupKeys > 0 && len(bd) >= int(p.config.MaxDedupKeys))
+	}
+	var errs []error
+	for i, chunk := range chunkMap(bd, p.config.MaxFlushChunk) {
+		if err := ctx.Err(); err != nil && i > 0 {
+			errs = append(errs, err)
+			break
+		}
+		if err := p.flushFunc(ctx, chunk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// isBatchFull 检查不同键数是否达到 FlushSize 或 MaxDedupKeys，或写入条数达到 MaxDedupItems
+func (p *KeyedDeduplicationPipeline[T, K]) isBatchFull(batchData any) bool {
+	n := len(batchData.(map[K]T))
+	if p.config.MaxDedupKeys > 0 && n >= int(p.config.MaxDedupKeys) {
+		return true
+	}
+	if p.dedupItemsReached(p.added) {
+		return true
+	}
+	return n >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理 map 是否为空
+func (p *KeyedDeduplicationPipeline[T, K]) isBatchEmpty(batchData any) bool {
+	return len(batchData.(map[K]T)) < 1
+}
@@ -0,0 +1,103 @@
+package gopipeline
+
+import "context"
+
+// DedupOption 去重管道的行为选项，传给 NewDeduplicationPipelineWith 组合使用
+type DedupOption[T UniqueKeyData] func(p *DeduplicationPipeline[T])
+
+// WithMerge 同一窗口内键冲突时使用 merge(旧值, 新值) 的结果作为该键的数据（默认新值覆盖旧值）
+// 与 WithKeepFirst 互斥，后设置者生效
+func WithMerge[T UniqueKeyData](merge func(old, new T) T) DedupOption[T] {
+	return func(p *DeduplicationPipeline[T]) {
+		p.collide = merge
+	}
+}
+
+// WithKeepFirst 同一窗口内键冲突时保留首次出现的数据，丢弃后到的数据
+// 与 WithMerge 互斥，后设置者生效
+func WithKeepFirst[T UniqueKeyData]() DedupOption[T] {
+	return func(p *DeduplicationPipeline[T]) {
+		p.collide = func(old, _ T) T { return old }
+	}
+}
+
+// WithKeyFunc 使用自定义函数计算去重键，替代 GetKey（如按多个字段组合去重）
+func WithKeyFunc[T UniqueKeyData](keyFunc func(T) string) DedupOption[T] {
+	return func(p *DeduplicationPipeline[T]) {
+		p.keyFunc = keyFunc
+	}
+}
+
+// WithKeyNormalizer 在写入去重 map 前规范化去重键（如去除首尾空白、统一大小写），使仅在格式上不同的键合并为同一键（默认不做规范化）
+// 作用于 GetKey 或 WithKeyFunc 的结果；map 中保存规范化后的键，FlushedKeys、DedupCounts 与 SortedFlush 看到的也是规范化后的键
+func WithKeyNormalizer[T UniqueKeyData](normalize func(string) string) DedupOption[T] {
+	return func(p *DeduplicationPipeline[T]) {
+		p.normalize = normalize
+	}
+}
+
+// WithCountingDedup 统计每个键在窗口内被去重前的出现次数
+// flush 函数内可通过 DedupCounts(ctx) 读取本批次的计数
+// 说明: 计数仅随首次 flush 的 ctx 传递；重试队列重放与失败键回填的数据不携带计数
+func WithCountingDedup[T UniqueKeyData]() DedupOption[T] {
+	return func(p *DeduplicationPipeline[T]) {
+		p.counting = true
+	}
+}
+
+// NewDeduplicationPipelineWith 使用自定义配置与去重选项创建一个新的管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 用于处理批处理数据的刷新函数
+//   - opts: 去重行为选项（WithMerge、WithKeepFirst、WithKeyFunc、WithKeyNormalizer、WithCountingDedup）
+//
+// 返回值: 返回一个新的 DeduplicationPipeline 实例
+func NewDeduplicationPipelineWith[T UniqueKeyData](
+	config PipelineConfig,
+	flushFunc FlushDeduplicationFunc[T],
+	opts ...DedupOption[T],
+) *DeduplicationPipeline[T] {
+	p := NewDeduplicationPipeline(config, flushFunc)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// 确保 DeduplicationPipeline 支持批次级 ctx 附加信息（WithCountingDedup）
+var _ batchContextBinder = (*DeduplicationPipeline[UniqueKeyData])(nil)
+
+// dedupCountsKey DedupCounts 在 ctx 中的键
+type dedupCountsKey struct{}
+
+// DedupCounts 返回本批次各键在去重前的出现次数（仅在启用 WithCountingDedup 的 flush 函数内可用，否则为 nil）
+// 返回的 map 归本批次所有，flush 函数可直接读取；配置了 MaxFlushChunk 时各分片共享整批的计数
+func DedupCounts(ctx context.Context) map[string]int {
+	counts, _ := ctx.Value(dedupCountsKey{}).(map[string]int)
+	return counts
+}
+
+// dedupKey 计算数据的去重键（按需规范化）
+func (p *DeduplicationPipeline[T]) dedupKey(data T) string {
+	var key string
+	if p.keyFunc != nil {
+		key = p.keyFunc(data)
+	} else {
+		key = data.GetKey()
+	}
+	if p.normalize != nil {
+		key = p.normalize(key)
+	}
+	return key
+}
+
+// bindBatchContext 启用计数时将当前批次的计数绑定到 flush 的 ctx；启用失败键回填时登记批次代次
+func (p *DeduplicationPipeline[T]) bindBatchContext(ctx context.Context, batchData any) context.Context {
+	if p.dedupRetry {
+		ctx = context.WithValue(ctx, dedupGenKey{}, p.dispatchGen(batchData.(map[string]T)))
+	}
+	if !p.counting {
+		return ctx
+	}
+	return context.WithValue(ctx, dedupCountsKey{}, p.curCounts)
+}
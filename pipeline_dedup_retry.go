@@ -0,0 +1,188 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FlushDeduplicationPartialFunc 支持部分成功的去重刷新函数
+// 返回值:
+//   - failedKeys: 写入失败的键；err 为 nil 时忽略
+//   - err: 非 nil 表示本批次存在失败；此时 failedKeys 为空视为整批失败
+type FlushDeduplicationPartialFunc[T UniqueKeyData] func(ctx context.Context, batchData map[string]T) (failedKeys []string, err error)
+
+// PartialFlushError 记录去重批次中写入失败的键，可通过 errors.As 获取
+type PartialFlushError struct {
+	FailedKeys []string
+	Err        error
+}
+
+func (e *PartialFlushError) Error() string {
+	return fmt.Sprintf("partial flush failed (%d keys): %v", len(e.FailedKeys), e.Err)
+}
+
+func (e *PartialFlushError) Unwrap() error {
+	return e.Err
+}
+
+// NewPartialDeduplicationPipeline 使用支持部分成功的刷新函数创建去重管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 返回失败键的刷新函数
+//
+// 返回值: 返回一个新的 DeduplicationPipeline 实例
+// 说明: 失败以 *PartialFlushError 上报；配合 WithDedupRetry(true) 可将失败键回填到后续批次
+func NewPartialDeduplicationPipeline[T UniqueKeyData](
+	config PipelineConfig,
+	flushFunc FlushDeduplicationPartialFunc[T],
+) *DeduplicationPipeline[T] {
+	p := &DeduplicationPipeline[T]{
+		partialFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// retryItem 等待回填的失败条目及其所属批次的代次
+type retryItem[T any] struct {
+	value T
+	gen   uint64
+}
+
+// dedupGenKey 批次代次在 flush ctx 中的键
+type dedupGenKey struct{}
+
+// WithDedupRetry 启用失败条目回填（可选）
+// 开启后失败批次中的条目不会被原样重放，而是重新并入当前窗口（下一条数据入批或下一次批次重建时），
+// 与窗口内的新数据一起去重后再 flush，从而为每个键提供至少一次语义且不会写入过期数据。
+// 说明:
+//   - NewPartialDeduplicationPipeline 创建的管道只回填失败键，已成功的键不会重复写入；其他去重管道回填失败分片的全部键
+//   - 并入时若当前窗口已包含同一键，以窗口内较新的数据为准（配置了 WithMerge/WithKeepFirst 时按 merge(回填值, 窗口值) 合并）
+//   - 失败批次在途期间，若同一键已随更新的批次派发，则该键的旧值不再回填
+//   - 错误为 ErrStopPipeline 或被 WithErrorClassifier 判为 Permanent 时不回填
+//
+// 注意:
+//   - 回填条目仅保存在内存中；运行结束后剩余的条目会在下一次运行时并入，进程退出则丢失
+//   - 不建议与 WithRetryQueue 同时使用，否则失败批次还会被整批原样重放
+func (p *DeduplicationPipeline[T]) WithDedupRetry(enabled bool) *DeduplicationPipeline[T] {
+	p.dedupRetry = enabled
+	return p
+}
+
+// PendingDedupRetries 返回等待回填到后续批次的失败条目数
+func (p *DeduplicationPipeline[T]) PendingDedupRetries() int {
+	return int(p.retryCount.Load())
+}
+
+// flushPartial 调用支持部分成功的刷新函数，下发成功键并按需回填失败条目
+func (p *DeduplicationPipeline[T]) flushPartial(ctx context.Context, chunk map[string]T) error {
+	failedKeys, err := p.partialFunc(ctx, chunk)
+	if err == nil {
+		p.emitFlushedKeys(chunk, nil)
+		return nil
+	}
+	if len(failedKeys) == 0 {
+		failedKeys = mapKeys(chunk)
+	}
+	failed := make(map[string]struct{}, len(failedKeys))
+	for _, k := range failedKeys {
+		failed[k] = struct{}{}
+	}
+	p.emitFlushedKeys(chunk, failed)
+	p.requeueFailed(ctx, chunk, failedKeys, err)
+	return &PartialFlushError{FailedKeys: failedKeys, Err: err}
+}
+
+// requeueChunk 整个分片 flush 失败时回填其全部键
+func (p *DeduplicationPipeline[T]) requeueChunk(ctx context.Context, chunk map[string]T, err error) {
+	if p.dedupRetry {
+		p.requeueFailed(ctx, chunk, mapKeys(chunk), err)
+	}
+}
+
+// requeueFailed 将失败条目暂存，等待主循环并入后续批次（flush 可能运行在异步协程中，不能直接写当前批次）
+// 已随更新批次派发的键、或暂存中已有更新代次的键不会被旧值覆盖
+func (p *DeduplicationPipeline[T]) requeueFailed(ctx context.Context, chunk map[string]T, failedKeys []string, err error) {
+	if !p.dedupRetry || errors.Is(err, ErrStopPipeline) || p.classifyError(err) == Permanent {
+		return
+	}
+	gen, _ := ctx.Value(dedupGenKey{}).(uint64)
+
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	if p.retryPending == nil {
+		p.retryPending = make(map[string]retryItem[T], len(failedKeys))
+	}
+	for _, k := range failedKeys {
+		v, ok := chunk[k]
+		if !ok || p.retrySeen[k] > gen {
+			continue
+		}
+		if old, ok := p.retryPending[k]; ok && old.gen > gen {
+			continue
+		}
+		p.retryPending[k] = retryItem[T]{value: v, gen: gen}
+	}
+	p.retryCount.Store(int32(len(p.retryPending)))
+}
+
+// mergeRetryPending 在主循环内将暂存的失败条目并入批次；批次中已有的键以窗口内的数据为准（或按 collide 合并）
+// 无暂存条目时仅一次原子读，不加锁
+func (p *DeduplicationPipeline[T]) mergeRetryPending(bd map[string]T) {
+	if p.retryCount.Load() == 0 {
+		return
+	}
+	p.retryMu.Lock()
+	pending := p.retryPending
+	p.retryPending = nil
+	p.retryCount.Store(0)
+	p.retryMu.Unlock()
+
+	for k, it := range pending {
+		cur, ok := bd[k]
+		if !ok {
+			bd[k] = it.value
+			continue
+		}
+		if p.collide != nil {
+			bd[k] = p.collide(it.value, cur)
+		}
+	}
+}
+
+// dispatchGen 在主循环派发批次时登记其代次；已有批次在途时记录本批次的键，供在途批次失败时识别过期数据
+func (p *DeduplicationPipeline[T]) dispatchGen(bd map[string]T) uint64 {
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	p.retryGen++
+	if p.retryInflight > 0 {
+		if p.retrySeen == nil {
+			p.retrySeen = make(map[string]uint64, len(bd))
+		}
+		for k := range bd {
+			p.retrySeen[k] = p.retryGen
+		}
+	}
+	p.retryInflight++
+	return p.retryGen
+}
+
+// finishGen 批次 flush 结束（回填登记之后）时调用；无在途批次时清空键记录
+func (p *DeduplicationPipeline[T]) finishGen() {
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	p.retryInflight--
+	if p.retryInflight == 0 {
+		p.retrySeen = nil
+	}
+}
+
+// mapKeys 返回 map 的全部键
+func mapKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
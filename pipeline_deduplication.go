@@ -1,6 +1,10 @@
 package gopipeline
 
-import "context"
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
 
 // UniqueKeyData 定义了可用于去重处理的数据接口
 // 实现此接口的类型必须能够提供一个唯一的键值用于去重判断
@@ -12,6 +16,13 @@ type UniqueKeyData interface {
 
 type FlushDeduplicationFunc[T UniqueKeyData] func(ctx context.Context, batchData map[string]T) error
 
+// DedupKeysHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，去重管道在每次 flush 时上报本窗口的不同键数
+type DedupKeysHook interface {
+	// DedupKeys 上报本次 flush 窗口的不同键数；capped 表示该窗口因达到 MaxDedupKeys 而提前 flush
+	DedupKeys(distinct int, capped bool)
+}
+
 // DeduplicationPipeline 实现了基础管道的具体功能
 // 该结构体通过组合 PipelineImpl 来实现通用的管道操作
 // 并添加了特定的刷新函数来处理批处理数据
@@ -19,11 +30,40 @@ type FlushDeduplicationFunc[T UniqueKeyData] func(ctx context.Context, batchData
 type DeduplicationPipeline[T UniqueKeyData] struct {
 	*PipelineImpl[T]
 	flushFunc FlushDeduplicationFunc[T]
+	// partialFunc 可选：支持部分成功的刷新函数（NewPartialDeduplicationPipeline 设置，与 flushFunc 二选一）
+	partialFunc FlushDeduplicationPartialFunc[T]
+
+	// 可选：失败键回填到后续批次（WithDedupRetry）
+	dedupRetry    bool
+	retryMu       sync.Mutex
+	retryPending  map[string]retryItem[T]
+	retryCount    atomic.Int32
+	retryGen      uint64            // 最近派发批次的代次（retryMu 保护）
+	retryInflight int               // 已派发但 flush 尚未结束的批次数（retryMu 保护）
+	retrySeen     map[string]uint64 // 有批次在途期间派发的键及其最新代次，在途清零时重置（retryMu 保护）
+
+	// 可选：去重行为选项（NewDeduplicationPipelineWith 设置）
+	keyFunc   func(T) string
+	normalize func(string) string
+	collide   func(old, new T) T
+	counting  bool
+	curCounts map[string]int // 当前批次各键的出现次数（仅主循环访问）
+	curAdded  int            // 当前批次的写入条数，含被覆盖的重复数据（仅主循环访问）
+
+	// flushedKeys 可选：每次成功 flush 后下发本批次的键集合（FlushedKeys 首次调用时懒初始化）
+	keysOnce    sync.Once
+	flushedKeys atomic.Pointer[chan []string]
 }
 
+// defaultFlushedKeysBufSize FlushedKeys 通道的缓冲容量
+const defaultFlushedKeysBufSize = 16
+
 // 确保 DeduplicationPipeline 实现了 DataProcessor 接口
 var _ DataProcessor[UniqueKeyData] = (*DeduplicationPipeline[UniqueKeyData])(nil)
 
+// 确保 DeduplicationPipeline 支持 UseMapReuse
+var _ batchReuser = (*DeduplicationPipeline[UniqueKeyData])(nil)
+
 // NewDefaultDeduplicationPipeline 使用默认配置创建一个新的管道实例
 // 参数:
 //   - flushFunc: 用于处理批处理数据的刷新函数
@@ -65,7 +105,27 @@ func NewDeduplicationPipeline[T UniqueKeyData](
 // 返回值: 返回一个空的类型T切片
 func (p *DeduplicationPipeline[T]) initBatchData() any {
 	// 预分配容量，减少哈希表扩容/rehash（读取当前可调的 FlushSize）
-	return make(map[string]T, int(p.CurrentFlushSize()))
+	bd := make(map[string]T, int(p.CurrentFlushSize()))
+	p.curAdded = 0
+	if p.counting {
+		p.curCounts = make(map[string]int, int(p.CurrentFlushSize()))
+	}
+	p.mergeRetryPending(bd)
+	return bd
+}
+
+// reuseBatchData 清空已 flush 的批处理 map 以供下一批次复用（UseMapReuse），其余状态的重置同 initBatchData
+func (p *DeduplicationPipeline[T]) reuseBatchData(batchData any) any {
+	bd := batchData.(map[string]T)
+	for k := range bd {
+		delete(bd, k)
+	}
+	p.curAdded = 0
+	if p.counting {
+		p.curCounts = make(map[string]int, int(p.CurrentFlushSize()))
+	}
+	p.mergeRetryPending(bd)
+	return bd
 }
 
 // addToBatch 将新数据添加到批处理容器中
@@ -80,7 +140,18 @@ func (p *DeduplicationPipeline[T]) initBatchData() any {
 //   - 注意：该方法在单消费者事件循环内是安全的；并非可在多协程并发写 map 的线程安全结构
 func (p *DeduplicationPipeline[T]) addToBatch(batchData any, data T) any {
 	bd := batchData.(map[string]T)
-	bd[data.GetKey()] = data
+	p.mergeRetryPending(bd)
+	key := p.dedupKey(data)
+	if p.collide != nil {
+		if old, ok := bd[key]; ok {
+			data = p.collide(old, data)
+		}
+	}
+	bd[key] = data
+	p.curAdded++
+	if p.counting {
+		p.curCounts[key]++
+	}
 	return bd
 }
 
@@ -90,17 +161,87 @@ func (p *DeduplicationPipeline[T]) addToBatch(batchData any, data T) any {
 //   - batchData: 需要刷新的批处理数据
 //
 // 返回值: 如果刷新过程中发生错误则返回error
+// 说明: 配置了 MaxFlushChunk 时按键数拆分为子 map 依次刷新，聚合各分片错误；仅成功写入的键会经 FlushedKeys 下发
 func (p *DeduplicationPipeline[T]) flush(ctx context.Context, batchData any) error {
-	return p.flushFunc(ctx, batchData.(map[string]T))
+	bd := batchData.(map[string]T)
+	if _, ok := ctx.Value(dedupGenKey{}).(uint64); ok {
+		defer p.finishGen()
+	}
+	if h, ok := p.metrics.(DedupKeysHook); ok {
+		h.DedupKeys(len(bd), p.config.MaxDedupKeys > 0 && len(bd) >= int(p.config.MaxDedupKeys))
+	}
+	var errs []error
+	for i, chunk := range chunkMap(bd, p.config.MaxFlushChunk) {
+		if err := ctx.Err(); err != nil && i > 0 {
+			errs = append(errs, err)
+			break
+		}
+		if p.partialFunc == nil {
+			if err := p.flushFunc(ctx, chunk); err != nil {
+				p.requeueChunk(ctx, chunk, err)
+				errs = append(errs, err)
+				continue
+			}
+			p.emitFlushedKeys(chunk, nil)
+			continue
+		}
+		if err := p.flushPartial(ctx, chunk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// FlushedKeys 返回一个只读通道，每次去重批次成功 flush 后下发该批次的键集合
+// 线程安全、幂等：首次调用时懒初始化；未调用时 flush 路径不产生任何额外开销
+// 注意:
+//   - 下发为非阻塞：消费方跟不上导致缓冲满时，本次键集合会被丢弃，避免阻塞 flush
+//   - 失败的 flush 不会下发键集合
+//   - 通道由管道内部持有，不会被关闭
+func (p *DeduplicationPipeline[T]) FlushedKeys() <-chan []string {
+	p.keysOnce.Do(func() {
+		ch := make(chan []string, defaultFlushedKeysBufSize)
+		p.flushedKeys.Store(&ch)
+	})
+	return *p.flushedKeys.Load()
+}
+
+// emitFlushedKeys 在 FlushedKeys 已启用时，非阻塞地下发本批次成功写入的键集合（跳过 failed 中的键）
+func (p *DeduplicationPipeline[T]) emitFlushedKeys(batchData map[string]T, failed map[string]struct{}) {
+	ch := p.flushedKeys.Load()
+	if ch == nil {
+		return
+	}
+	keys := make([]string, 0, len(batchData))
+	for k := range batchData {
+		if _, ok := failed[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	select {
+	case *ch <- keys:
+	default:
+		// 缓冲已满，丢弃本次键集合
+	}
 }
 
 // isBatchFull 检查批处理数据切片是否已达到配置的最大容量
 // 参数:
 //   - batchData: 要检查的批处理数据切片
 //
-// 返回值: 如果数据量达到或超过配置的FlushSize则返回true
+// 返回值: 如果数据量达到或超过配置的FlushSize，或键数达到 MaxDedupKeys，或写入条数达到 MaxDedupItems 则返回true
 func (p *DeduplicationPipeline[T]) isBatchFull(batchData any) bool {
-	return len(batchData.(map[string]T)) >= int(p.CurrentFlushSize())
+	n := len(batchData.(map[string]T))
+	if p.config.MaxDedupKeys > 0 && n >= int(p.config.MaxDedupKeys) {
+		return true
+	}
+	if p.dedupItemsReached(p.curAdded) {
+		return true
+	}
+	return n >= int(p.CurrentFlushSize())
 }
 
 // isBatchEmpty 检查批处理数据切片是否为空
@@ -111,3 +252,8 @@ func (p *DeduplicationPipeline[T]) isBatchFull(batchData any) bool {
 func (p *DeduplicationPipeline[T]) isBatchEmpty(batchData any) bool {
 	return len(batchData.(map[string]T)) < 1
 }
+
+// dedupItemsReached 判断去重窗口的写入条数是否达到 MaxDedupItems（未配置时为 false）
+func (p *PipelineImpl[T]) dedupItemsReached(added int) bool {
+	return p.config.MaxDedupItems > 0 && added >= int(p.config.MaxDedupItems)
+}
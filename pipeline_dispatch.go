@@ -0,0 +1,161 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// DispatchPipeline 将批次投递给外部 worker 的管道
+// 批次的累计与标准管道一致（按 FlushSize/FlushInterval 触发），flush 时不调用刷新函数，
+// 而是把 []T 批次推入有界通道 Batches()，由使用方自建的 worker 池消费，并通过 AckBatch 回报处理结果。
+// 适用于需要完全掌控 worker 模型的场景，是 MaxConcurrentFlushes 等内置并发控制的低层替代
+type DispatchPipeline[T any] struct {
+	*PipelineImpl[T]
+	batches chan []T
+
+	// 已投递但尚未确认的批次数与空闲信号（inflight 归零时关闭 idle）
+	ackMu    sync.Mutex
+	inflight int
+	idle     chan struct{}
+}
+
+// 确保 DispatchPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*DispatchPipeline[any])(nil)
+
+// NewDispatchPipeline 使用自定义配置创建一个投递批次的管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - capacity: 批次通道的容量（<=0 时为无缓冲通道）；通道满时 flush 阻塞，形成对主循环的背压
+//
+// 返回值: 返回一个新的 DispatchPipeline 实例
+func NewDispatchPipeline[T any](config PipelineConfig, capacity int) *DispatchPipeline[T] {
+	if capacity < 0 {
+		capacity = 0
+	}
+	idle := make(chan struct{})
+	close(idle)
+	p := &DispatchPipeline[T]{
+		batches: make(chan []T, capacity),
+		idle:    idle,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// Batches 返回只读的批次通道，供外部 worker 消费
+// 注意: 每个取出的批次都必须调用一次 AckBatch；通道由管道持有，不会被关闭
+func (p *DispatchPipeline[T]) Batches() <-chan []T {
+	return p.batches
+}
+
+// AckBatch 确认一个已取出批次的处理结果
+// 参数:
+//   - err: 批次处理的错误（成功为 nil）；非 nil 时经 ErrorChan/MetricsHook 上报
+//
+// 说明: 确认次数多于投递次数时多余的调用被忽略
+func (p *DispatchPipeline[T]) AckBatch(err error) {
+	p.releaseInflight()
+	if err != nil {
+		p.safeErrorSend(err)
+		if p.metrics != nil {
+			p.metrics.Error(err)
+		}
+	}
+}
+
+// InflightBatches 返回已投递但尚未确认的批次数（含仍在通道缓冲中的批次）
+func (p *DispatchPipeline[T]) InflightBatches() int {
+	p.ackMu.Lock()
+	defer p.ackMu.Unlock()
+	return p.inflight
+}
+
+// WaitAcked 阻塞直到所有已投递的批次都被确认，用于关闭前的收尾
+// 参数:
+//   - ctx: 上下文对象，用于限制等待时长
+//
+// 返回值: 全部确认返回 nil；ctx 先结束时返回 ctx.Err()
+func (p *DispatchPipeline[T]) WaitAcked(ctx context.Context) error {
+	p.ackMu.Lock()
+	idle := p.idle
+	p.ackMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// initBatchData 初始化一个新的批处理数据切片
+// 返回值: 返回一个空的类型T切片
+func (p *DispatchPipeline[T]) initBatchData() any {
+	return make([]T, 0, int(p.CurrentFlushSize()))
+}
+
+// addToBatch 将新数据添加到批处理数据切片中
+// 参数:
+//   - batchData: 当前的批处理数据切片
+//   - data: 需要添加的新数据
+//
+// 返回值: 返回更新后的批处理数据切片
+func (p *DispatchPipeline[T]) addToBatch(batchData any, data T) any {
+	return append(batchData.([]T), data)
+}
+
+// flush 将批次推入批次通道（阻塞直到通道有空位或 ctx 结束）
+// 参数:
+//   - ctx: 上下文对象，用于控制操作的生命周期
+//   - batchData: 需要投递的批处理数据
+//
+// 返回值: ctx 先结束时返回 ctx.Err()，该批次未投递
+// 说明: MetricsHook.Flush 观测的是投递耗时而非 worker 的处理耗时
+func (p *DispatchPipeline[T]) flush(ctx context.Context, batchData any) error {
+	// 先计入在途再投递，避免 worker 的确认先于计数
+	p.ackMu.Lock()
+	if p.inflight == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.inflight++
+	p.ackMu.Unlock()
+
+	select {
+	case p.batches <- batchData.([]T):
+		return nil
+	case <-ctx.Done():
+		p.releaseInflight()
+		return ctx.Err()
+	}
+}
+
+// releaseInflight 在途批次数减一，归零时发出空闲信号
+func (p *DispatchPipeline[T]) releaseInflight() {
+	p.ackMu.Lock()
+	defer p.ackMu.Unlock()
+	if p.inflight == 0 {
+		return
+	}
+	p.inflight--
+	if p.inflight == 0 {
+		close(p.idle)
+	}
+}
+
+// isBatchFull 检查批处理数据切片是否已达到配置的最大容量
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据量达到或超过配置的FlushSize则返回true
+func (p *DispatchPipeline[T]) isBatchFull(batchData any) bool {
+	return len(batchData.([]T)) >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理数据切片是否为空
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据切片长度小于1则返回true
+func (p *DispatchPipeline[T]) isBatchEmpty(batchData any) bool {
+	return len(batchData.([]T)) < 1
+}
@@ -0,0 +1,41 @@
+package gopipeline
+
+import "errors"
+
+// WithCloseErrorChanOnStop 在运行结束后关闭错误通道（可选），使 for err := range errs 的消费循环能够自然结束
+// 说明:
+//   - 关闭时机：Done 已关闭、最终 flush 完成，且在途的异步 flush 全部结束之后；经 Start 启动时，运行返回的错误先写入通道再关闭
+//   - 启用 WithErrorCoalescing 时暂存的错误会在关闭前立即上报；启用 WithUnboundedErrors 时等积压的错误全部投递后再关闭
+//   - 通道只关闭一次：关闭后管道再次运行、重试队列的后台重放等产生的错误都会被丢弃（计入 MetricsHook.ErrorDropped），
+//     因此适用于只运行一次的管道；并发二次启动返回的 ErrAlreadyRunning 不会触发关闭
+//   - 应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithCloseErrorChanOnStop(enabled bool) *PipelineImpl[T] {
+	p.closeErrsOnStop = enabled
+	return p
+}
+
+// closeErrorsOnStop 启用 WithCloseErrorChanOnStop 时，在本次运行结束后关闭错误通道（只关闭一次）
+func (p *PipelineImpl[T]) closeErrorsOnStop(err error) {
+	if !p.closeErrsOnStop || errors.Is(err, ErrAlreadyRunning) {
+		return
+	}
+	// 在途异步 flush 的错误须先于关闭写入通道
+	p.asyncFlushes.Wait()
+	if c := p.coalescer; c != nil {
+		c.mu.Lock()
+		c.gen++ // 使暂存窗口的定时器回调失效
+		p.deliverError(c.takeLocked())
+		c.mu.Unlock()
+	}
+	p.errCloseOnce.Do(func() {
+		_ = p.ErrorChan(0)
+		p.errCloseMu.Lock()
+		defer p.errCloseMu.Unlock()
+		p.errClosed = true
+		if p.errQueue != nil {
+			p.errQueue.closeAfterDrain(p.errorChan)
+			return
+		}
+		close(p.errorChan)
+	})
+}
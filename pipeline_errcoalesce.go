@@ -0,0 +1,99 @@
+package gopipeline
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CoalescedError 合并窗口内连续出现的同类错误，可通过 errors.As 获取；Unwrap 返回窗口内的首个错误
+type CoalescedError struct {
+	Err   error
+	Count int
+}
+
+func (e *CoalescedError) Error() string {
+	return fmt.Sprintf("%v (occurred %d times)", e.Err, e.Count)
+}
+
+func (e *CoalescedError) Unwrap() error {
+	return e.Err
+}
+
+// errorCoalescer 合并连续同类错误的状态（flush 协程并发写）
+type errorCoalescer struct {
+	window time.Duration
+	keyFn  func(error) string
+
+	mu    sync.Mutex
+	key   string
+	first error
+	count int
+	timer *time.Timer
+	gen   uint64 // 窗口代次：已被提前上报的窗口，其定时器回调不得取走新窗口的错误
+}
+
+// WithErrorCoalescing 启用错误合并（可选），避免下游故障期间大量相同错误淹没错误通道的消费方
+// 参数:
+//   - window: 合并窗口；首个错误暂存 window 后再上报（<=0 时不启用）
+//   - keyFn: 判定同类错误的键函数（nil 时使用 err.Error()）
+//
+// 说明:
+//   - 窗口内连续出现的同类错误合并为一条：仅出现一次时原样上报，否则上报 *CoalescedError{Err: 首个错误, Count: 次数}
+//   - 出现不同类的错误时立即上报已暂存的错误并开启新窗口，因此上报顺序与发生顺序一致
+//   - 仅作用于错误通道；MetricsHook.Error 仍按每次错误调用。暂存的错误在窗口结束时上报，可能晚于 done
+func (p *PipelineImpl[T]) WithErrorCoalescing(window time.Duration, keyFn func(error) string) *PipelineImpl[T] {
+	if window <= 0 {
+		p.coalescer = nil
+		return p
+	}
+	if keyFn == nil {
+		keyFn = func(err error) string { return err.Error() }
+	}
+	p.coalescer = &errorCoalescer{window: window, keyFn: keyFn}
+	return p
+}
+
+// coalesceError 将错误并入当前窗口；与暂存错误不同类时先上报暂存的错误
+func (p *PipelineImpl[T]) coalesceError(err error) {
+	c := p.coalescer
+	key := c.keyFn(err)
+
+	c.mu.Lock()
+	if c.count > 0 && c.key == key {
+		c.count++
+		c.mu.Unlock()
+		return
+	}
+	prev := c.takeLocked()
+	c.key, c.first, c.count = key, err, 1
+	c.gen++
+	gen := c.gen
+	c.timer = time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.gen == gen {
+			p.deliverError(c.takeLocked())
+		}
+	})
+	// 持锁上报，保证先于新窗口的错误进入错误通道
+	p.deliverError(prev)
+	c.mu.Unlock()
+}
+
+// takeLocked 取出暂存的错误（调用方须持有 mu）；无暂存时返回 nil
+func (c *errorCoalescer) takeLocked() error {
+	if c.count == 0 {
+		return nil
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	err := c.first
+	if c.count > 1 {
+		err = &CoalescedError{Err: c.first, Count: c.count}
+	}
+	c.key, c.first, c.count = "", nil, 0
+	return err
+}
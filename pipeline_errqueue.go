@@ -0,0 +1,85 @@
+package gopipeline
+
+import "sync"
+
+// errorQueue 无界错误队列：错误通道缓冲满时暂存错误，由按需启动的协程按顺序投递到错误通道
+type errorQueue struct {
+	mu       sync.Mutex
+	pending  []error
+	draining bool // 投递协程是否在运行（同一时刻至多一个，保证投递顺序）
+	// closeCh 非 nil 时表示错误通道待关闭：投递协程清空积压后关闭它（WithCloseErrorChanOnStop）
+	closeCh chan error
+}
+
+// WithUnboundedErrors 启用不丢弃的错误上报（可选），用于审计等不能容忍错误丢失的场景
+// 错误通道缓冲满时，错误暂存到内部无界队列，由后台协程按产生顺序阻塞投递到 ErrorChan 返回的通道，
+// 不再丢弃，也不再调用 MetricsHook.ErrorDropped。
+// 注意:
+//   - 应在首次调用 ErrorChan/Start 之前调用（错误通道的缓冲大小仍由 ErrorChan 决定）
+//   - 消费方停止读取时队列会无限增长，内存随之上涨；可通过 PendingErrors 观测积压
+//   - 投递协程仅在有积压时存在，队列清空后退出；消费方永不读取时它会一直阻塞
+func (p *PipelineImpl[T]) WithUnboundedErrors() *PipelineImpl[T] {
+	p.errQueue = &errorQueue{}
+	return p
+}
+
+// PendingErrors 返回无界错误队列中尚未投递到错误通道的错误数（未启用 WithUnboundedErrors 时恒为 0）
+func (p *PipelineImpl[T]) PendingErrors() int {
+	if p.errQueue == nil {
+		return 0
+	}
+	p.errQueue.mu.Lock()
+	defer p.errQueue.mu.Unlock()
+	return len(p.errQueue.pending)
+}
+
+// enqueueError 将错误写入错误通道；通道已满或已有积压时排入队列并确保投递协程在运行
+func (q *errorQueue) enqueueError(ch chan error, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.draining {
+		select {
+		case ch <- err:
+			return
+		default:
+		}
+	}
+	q.pending = append(q.pending, err)
+	if !q.draining {
+		q.draining = true
+		go q.drain(ch)
+	}
+}
+
+// drain 按顺序阻塞投递积压的错误，队列清空后退出
+func (q *errorQueue) drain(ch chan error) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.draining = false
+			q.pending = nil
+			if q.closeCh != nil {
+				close(q.closeCh)
+			}
+			q.mu.Unlock()
+			return
+		}
+		err := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+		ch <- err
+	}
+}
+
+// closeAfterDrain 关闭错误通道：无积压时立即关闭，否则由投递协程在清空积压后关闭
+// 调用方须保证此后不再有错误入队
+func (q *errorQueue) closeAfterDrain(ch chan error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.draining {
+		q.closeCh = ch
+		return
+	}
+	close(ch)
+}
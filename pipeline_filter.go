@@ -0,0 +1,21 @@
+package gopipeline
+
+// WithFilter 注入入批前的过滤谓词（可选），返回 false 的数据被丢弃，不进入批次
+// 说明:
+//   - 在主循环内对每条收到的数据调用（单消费者，无需并发安全），同样作用于取消收尾、FlushSync 等抽取缓冲的路径
+//   - 被过滤的数据计入 Stats().FilteredTotal，不触发批满判断，占用的内存护栏额度立即释放
+//   - 与 TransformPipeline 的过滤不同，不改变批次元素类型，可用于任意管道
+func (p *PipelineImpl[T]) WithFilter(keep func(T) bool) *PipelineImpl[T] {
+	p.filter = keep
+	return p
+}
+
+// keepItem 按过滤谓词判断数据是否入批；被过滤时计数并释放其额度
+func (p *PipelineImpl[T]) keepItem(data T) bool {
+	if p.filter == nil || p.filter(data) {
+		return true
+	}
+	p.filtered.Add(1)
+	p.releaseBytes(p.itemBytes(data))
+	return false
+}
@@ -0,0 +1,101 @@
+package gopipeline
+
+// MaxConcurrentFlushes 返回当前生效的异步 flush 并发上限（0 表示不限制）
+// 初始值取自 PipelineConfig.MaxConcurrentFlushes，之后反映 SetMaxConcurrentFlushes 的调整
+func (p *PipelineImpl[T]) MaxConcurrentFlushes() int {
+	p.semMu.Lock()
+	defer p.semMu.Unlock()
+	return cap(p.flushSem)
+}
+
+// SetMaxConcurrentFlushes 在运行中调整异步 flush 的并发上限（n <= 0 表示不限制），无需重启管道
+// 说明:
+//   - 以新容量的信号量整体替换旧信号量，并按当前在飞的 flush 数为其预占令牌（至多 n 枚），新上限立即生效：
+//     调小上限后，需等在飞 flush 降到新上限以下才会发起新的 flush
+//   - 主循环若正阻塞在旧信号量上等待空位，会立即改为在新信号量上等待
+//   - 同步 flush 与 WithFlushAffinity 的通道并行不受该上限约束
+//   - Config() 反映调整后的值；Reconfigure 修改 MaxConcurrentFlushes 时经由本方法生效
+//
+// 线程安全，可在运行中调用
+func (p *PipelineImpl[T]) SetMaxConcurrentFlushes(n int) {
+	var sem chan struct{}
+	if n > 0 {
+		sem = make(chan struct{}, n)
+	}
+	p.semMu.Lock()
+	defer p.semMu.Unlock()
+	if cap(p.flushSem) == cap(sem) {
+		return
+	}
+	// 替换后所有在飞 flush 都属于旧代际；新信号量为其预占令牌，随旧代际完成逐个归还
+	p.semOld = p.semInFlight
+	p.semCarried = 0
+	for p.semCarried < p.semOld && p.semCarried < n {
+		sem <- struct{}{}
+		p.semCarried++
+	}
+	p.flushSem = sem
+	if p.semChanged != nil {
+		close(p.semChanged)
+		p.semChanged = nil
+	}
+}
+
+// acquireFlushSlot 为一次异步 flush 取得并发令牌（未限制时不阻塞），返回取得令牌的信号量（未限制时为 nil）
+// 须与 releaseFlushSlot 配对调用；等待期间信号量被替换时改为在新信号量上等待
+func (p *PipelineImpl[T]) acquireFlushSlot() chan struct{} {
+	for {
+		p.semMu.Lock()
+		sem := p.flushSem
+		if sem == nil {
+			p.semInFlight++
+			p.semMu.Unlock()
+			return nil
+		}
+		select {
+		case sem <- struct{}{}:
+			p.semInFlight++
+			p.semMu.Unlock()
+			return sem
+		default:
+		}
+		if p.semChanged == nil {
+			p.semChanged = make(chan struct{})
+		}
+		changed := p.semChanged
+		p.semMu.Unlock()
+
+		select {
+		case sem <- struct{}{}:
+			p.semMu.Lock()
+			if sem == p.flushSem {
+				p.semInFlight++
+				p.semMu.Unlock()
+				return sem
+			}
+			// 取得令牌的同时信号量已被替换：撤回旧信号量上的令牌后在新信号量上重试
+			<-sem
+			p.semMu.Unlock()
+		case <-changed:
+		}
+	}
+}
+
+// releaseFlushSlot 归还 acquireFlushSlot 取得的令牌
+// 令牌取自当前信号量时直接归还；属于旧代际时，仅当旧代际在飞数已低于预占数时归还一枚预占令牌，保证总并发不超过新上限
+func (p *PipelineImpl[T]) releaseFlushSlot(sem chan struct{}) {
+	p.semMu.Lock()
+	defer p.semMu.Unlock()
+	p.semInFlight--
+	if sem == p.flushSem {
+		if sem != nil {
+			<-sem
+		}
+		return
+	}
+	p.semOld--
+	if p.semCarried > p.semOld {
+		p.semCarried--
+		<-p.flushSem
+	}
+}
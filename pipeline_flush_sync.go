@@ -0,0 +1,85 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// forcedFlushKey FlushSync 在 flush 的 ctx 中登记完成通知通道的键
+type forcedFlushKey struct{}
+
+// FlushSync 立即 flush 当前批次（连同调用时已写入通道的数据），并阻塞直到这一次 flush 完成，返回其错误
+// 参数:
+//   - ctx: 上下文对象，用于限制等待时长（不会传给 flush 函数）
+//
+// 返回值:
+//   - nil: 批次已成功 flush，或当前批次与通道均为空（无需 flush）
+//   - flush 返回的错误（同时照常上报错误通道与重试队列）
+//   - 包装了 ErrFlushPanic 的错误: flush 发生 panic（与 PanicPolicy 无关；是否上报错误通道仍由 PanicPolicy 决定）
+//   - ErrNotRunning: 管道未运行（或运行已结束）
+//   - 包装了 ErrContextIsClosed 的错误: ctx 先结束；请求已被主循环接收时该次 flush 仍会照常完成
+//
+// 说明:
+//   - 请求由主循环在两个事件之间处理：先把请求时刻通道中已缓冲的数据并入当前批次（可超出 FlushSize），再整批 flush 一次，
+//     因此调用前已通过 Add 或 DataChan 写入的数据一定包含在该次 flush 中
+//   - PauseFlush 与启动宽限期不阻止该次 flush；异步模式下同样等待该批次的 flush 完成
+//   - 适用于请求级“立即提交并确认”的场景（如 Web 处理函数写入后需确认落库）
+func (p *PipelineImpl[T]) FlushSync(ctx context.Context) error {
+	done := p.Done()
+	if done == nil {
+		return ErrNotRunning
+	}
+	reply := make(chan error, 1)
+	select {
+	case p.flushReq <- reply:
+	case <-done:
+		return ErrNotRunning
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+}
+
+// handleFlushSync 在主循环内处理 FlushSync 请求：将应答通道登记到本次 flush 的 ctx，由 flushAndReport 完成后通知
+func (p *PipelineImpl[T]) handleFlushSync(ctx context.Context, async bool, st *batchState, timer *time.Timer, reply chan error) {
+	p.absorbBuffered(st)
+	if p.processor.isBatchEmpty(st.data) {
+		reply <- nil
+		return
+	}
+	p.flushBatch(context.WithValue(ctx, forcedFlushKey{}, reply), p.resolveAsync(async), st)
+	p.resetTimer(timer)
+}
+
+// absorbBuffered 将主循环暂存的数据与请求时刻通道中已缓冲的数据并入当前批次
+// 只取请求时刻已缓冲的数据，避免在生产者持续写入时无限拉取；通道关闭由主循环的关闭路径处理
+func (p *PipelineImpl[T]) absorbBuffered(st *batchState) {
+	for len(p.pending) > 0 {
+		p.addToBatch(st, p.popPending())
+	}
+	n := len(p.dataChan)
+	for i := 0; i < n; i++ {
+		select {
+		case v, ok := <-p.dataChan:
+			if !ok {
+				return
+			}
+			p.addToBatch(st, v)
+		default:
+			return
+		}
+	}
+}
+
+// notifyForcedFlush 若本次 flush 由 FlushSync 触发，则把最终结果发给请求方
+func notifyForcedFlush(ctx context.Context, err *error) {
+	if reply, ok := ctx.Value(forcedFlushKey{}).(chan error); ok {
+		reply <- *err // 容量为 1 且每个请求只通知一次，不会阻塞
+	}
+}
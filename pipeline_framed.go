@@ -0,0 +1,78 @@
+package gopipeline
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// FrameEncodeError 记录批次中某条数据的编码失败，可通过 errors.As 获取其在批次中的下标
+type FrameEncodeError struct {
+	Index int // 在本批次中的下标（从 0 开始）
+	Err   error
+}
+
+func (e *FrameEncodeError) Error() string {
+	return fmt.Sprintf("frame encode failed (index=%d): %v", e.Index, e.Err)
+}
+
+func (e *FrameEncodeError) Unwrap() error {
+	return e.Err
+}
+
+// frameHeaderSize 长度前缀的字节数（大端 uint32）
+const frameHeaderSize = 4
+
+// NewFramedPipeline 使用自定义配置创建一个标准管道实例，将每个批次以长度前缀帧写入 io.Writer
+// 参数:
+//   - config: 自定义的管道配置
+//   - w: 输出流（如 net.Conn、文件）
+//   - encode: 将单条数据编码为帧内容的函数
+//
+// 返回值: 返回一个新的 StandardPipeline 实例
+// 说明:
+//   - 每条数据写为一帧：4 字节大端长度 + encode 返回的字节；整批帧先在池化缓冲区中拼好，再一次 Write 写出
+//   - 编码失败（或编码结果超过 4GiB）的数据以 *FrameEncodeError 上报到 ErrorChan 并跳过，不影响同批其他数据
+//   - 写入失败（含短写 io.ErrShortWrite）作为本次 flush 的错误上报，按错误通道/重试/死信的既有路径处理
+//   - 多个 flush 对 w 的写入互斥，单批帧不会交错；但异步 flush 之间的先后顺序不确定，需要按输入顺序写出时请使用 SyncPerform
+func NewFramedPipeline[T any](
+	config PipelineConfig,
+	w io.Writer,
+	encode func(T) ([]byte, error),
+) *StandardPipeline[T] {
+	var (
+		p  *StandardPipeline[T]
+		mu sync.Mutex
+	)
+	p = NewScratchPipeline(config, func(ctx context.Context, batchData []T, scratch *Scratch) error {
+		var header [frameHeaderSize]byte
+		for i, v := range batchData {
+			b, err := encode(v)
+			if err == nil && uint64(len(b)) > math.MaxUint32 {
+				err = fmt.Errorf("frame of %d bytes exceeds the 4-byte length prefix", len(b))
+			}
+			if err != nil {
+				p.safeErrorSend(&FrameEncodeError{Index: i, Err: err})
+				continue
+			}
+			binary.BigEndian.PutUint32(header[:], uint32(len(b)))
+			scratch.Write(header[:])
+			scratch.Write(b)
+		}
+		if scratch.Len() == 0 {
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		n, err := w.Write(scratch.Bytes())
+		if err == nil && n < scratch.Len() {
+			err = io.ErrShortWrite
+		}
+		return err
+	})
+	return p
+}
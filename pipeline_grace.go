@@ -0,0 +1,48 @@
+package gopipeline
+
+import "time"
+
+// startGrace 在本次运行开始时设置启动宽限期（PipelineConfig.StartupGracePeriod），并让定时器在宽限期结束时触发
+func (p *PipelineImpl[T]) startGrace(st *batchState, timer *time.Timer) {
+	if d := p.config.StartupGracePeriod; d > 0 {
+		st.graceUntil = time.Now().Add(d)
+		p.capTimerToGrace(st, timer)
+	}
+}
+
+// inGrace 判断是否仍处于启动宽限期；宽限期结束后清除截止时间，后续调用不再读取时钟
+func (st *batchState) inGrace() bool {
+	if st.graceUntil.IsZero() {
+		return false
+	}
+	if time.Now().Before(st.graceUntil) {
+		return true
+	}
+	st.graceUntil = time.Time{}
+	return false
+}
+
+// flushSuppressed 判断当前是否抑制 flush（PauseFlush 暂停或处于启动宽限期）
+func (p *PipelineImpl[T]) flushSuppressed(st *batchState) bool {
+	return p.flushPaused.Load() || st.inGrace()
+}
+
+// capTimerToGrace 宽限期剩余时间短于刷新间隔时，将定时器提前到宽限期结束时刻，
+// 使宽限期内累计的数据在到期时立即 flush，而不必等到下一个完整间隔
+func (p *PipelineImpl[T]) capTimerToGrace(st *batchState, timer *time.Timer) {
+	if st.graceUntil.IsZero() {
+		return
+	}
+	remaining := time.Until(st.graceUntil)
+	if remaining >= p.CurrentFlushInterval() {
+		return
+	}
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(remaining)
+	p.timerResets.Add(1)
+}
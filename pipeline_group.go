@@ -0,0 +1,78 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// pipelineGroup 一组标准管道的公共部分：汇总各成员的错误通道，并合并各成员的完成信号
+// 由 ShardedPipeline 与 WorkerGroup 共用
+type pipelineGroup[T any] struct {
+	members []*StandardPipeline[T]
+	// wrapErr 可选：转发前包装成员的错误（参数为成员下标），nil 时原样转发
+	wrapErr func(i int, err error) error
+
+	errOnce sync.Once
+	errs    chan error
+
+	runMu sync.Mutex
+	done  chan struct{}
+}
+
+// errorChan 返回汇总所有成员错误的只读通道
+// 线程安全、幂等：“首次调用决定缓冲大小”（<=0 时为各成员默认容量之和），后续调用忽略 size
+func (g *pipelineGroup[T]) errorChan(size int) <-chan error {
+	g.errOnce.Do(func() {
+		if size <= 0 {
+			for _, m := range g.members {
+				size += cap(m.ErrorChan(0))
+			}
+		}
+		g.errs = make(chan error, size)
+		for i, m := range g.members {
+			go g.forwardErrors(i, m.ErrorChan(0))
+		}
+	})
+	return g.errs
+}
+
+// forwardErrors 将单个成员的错误转发到汇总通道（成员的错误通道不会关闭，转发协程随管道组常驻）
+func (g *pipelineGroup[T]) forwardErrors(i int, errs <-chan error) {
+	for err := range errs {
+		if g.wrapErr != nil {
+			err = g.wrapErr(i, err)
+		}
+		g.errs <- err
+	}
+}
+
+// start 异步启动所有成员，返回所有成员都退出后关闭的 done 与汇总错误通道
+// 参数 started 可选：每个成员启动后在调用方协程内调用一次
+func (g *pipelineGroup[T]) start(ctx context.Context, started func(m *StandardPipeline[T])) (<-chan struct{}, <-chan error) {
+	errs := g.errorChan(0)
+	dones := make([]<-chan struct{}, len(g.members))
+	for i, m := range g.members {
+		dones[i], _ = m.Start(ctx)
+		if started != nil {
+			started(m)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, d := range dones {
+			<-d
+		}
+	}()
+	g.runMu.Lock()
+	g.done = done
+	g.runMu.Unlock()
+	return done, errs
+}
+
+// doneChan 返回最近一次 start 的完成信号（尚未启动时为 nil）
+func (g *pipelineGroup[T]) doneChan() <-chan struct{} {
+	g.runMu.Lock()
+	defer g.runMu.Unlock()
+	return g.done
+}
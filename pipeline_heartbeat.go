@@ -0,0 +1,33 @@
+package gopipeline
+
+import (
+	"context"
+	"time"
+)
+
+// startHeartbeat 按 HeartbeatInterval 为本次运行启动心跳，返回心跳通道与停止函数（未启用时通道为 nil）
+func (p *PipelineImpl[T]) startHeartbeat() (<-chan time.Time, func()) {
+	if p.config.HeartbeatInterval <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(p.config.HeartbeatInterval)
+	return ticker.C, ticker.Stop
+}
+
+// handleHeartbeat 处理心跳：不论 FlushInterval 定时器是否被批满 flush 推迟，非空批次都立即 flush，空批次按 FlushEmptyOnInterval 发送心跳
+// 暂停 flush 与启动宽限期内跳过；flush 后重置 FlushInterval 定时器，避免紧随其后的定时触发产生过小的批次
+func (p *PipelineImpl[T]) handleHeartbeat(ctx context.Context, async bool, st *batchState, timer *time.Timer) {
+	if p.flushSuppressed(st) {
+		return
+	}
+	async = p.resolveAsync(async)
+	if p.processor.isBatchEmpty(st.data) {
+		if !p.config.FlushEmptyOnInterval {
+			return
+		}
+		p.flushBatch(ctx, async, st)
+	} else {
+		p.flushWhenCommitted(ctx, async, st)
+	}
+	p.resetTimer(timer)
+}
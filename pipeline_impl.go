@@ -3,8 +3,10 @@ package gopipeline
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"reflect"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,6 +24,20 @@ type MetricsHook interface {
 	ErrorDropped()
 }
 
+// BufferSaturationHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，主循环会在每次定时器触发时上报数据通道占用率
+type BufferSaturationHook interface {
+	// BufferSaturation 上报 len(dataChan)/cap(dataChan)，取值范围 [0, 1]
+	BufferSaturation(ratio float64)
+}
+
+// PipelineNameHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，管道会在 WithMetrics/WithName 时把名称传给指标钩子，便于按管道打标签
+type PipelineNameHook interface {
+	// PipelineName 接收管道名称（WithName 设置的标签）
+	PipelineName(name string)
+}
+
 type PipelineImpl[T any] struct {
 	// config 存储管道的配置信息
 	config PipelineConfig
@@ -29,27 +45,164 @@ type PipelineImpl[T any] struct {
 	dataChan chan T
 	// processor 用于处理批量数据的处理器
 	processor DataProcessor[T]
+	// batchCtx 处理器对批次级 ctx 附加信息的可选支持（构造时解析一次）
+	batchCtx batchContextBinder
 	// 错误通道，用于捕获和报告异步执行过程中的错误
 	errorChan chan error
 	// errOnce 确保错误通道只初始化一次（用于 ErrorChan 的懒加载）
 	errOnce sync.Once
+	// errQueue 可选：错误通道满时暂存错误的无界队列（WithUnboundedErrors）
+	errQueue *errorQueue
+	// coalescer 可选：合并连续同类错误后再上报（WithErrorCoalescing）
+	coalescer *errorCoalescer
+	// 可选：运行结束后关闭错误通道（WithCloseErrorChanOnStop）；errClosed 由 errCloseMu 保护，关闭后的错误直接丢弃
+	closeErrsOnStop bool
+	errCloseOnce    sync.Once
+	errCloseMu      sync.Mutex
+	errClosed       bool
+	// batchErrs 可选：携带失败批次的类型化错误通道（WithBatchErrors）
+	batchErrs chan FlushError[T]
 
 	// 运行状态与并发控制
 	running  int32         // 0=未运行, 1=运行中（并发启动保护）
-	flushSem chan struct{} // 异步 flush 并发上限（nil 表示不限制）
+	flushSem chan struct{} // 异步 flush 并发上限（nil 表示不限制），由 semMu 保护，SetMaxConcurrentFlushes 整体替换
+	semMu    sync.Mutex
+	// 以下由 semMu 保护：semChanged 在 flushSem 被替换时关闭，唤醒阻塞在旧信号量上的主循环；
+	// semInFlight 在飞的异步 flush 总数；semOld 其中取自已被替换的信号量（旧代际）的数量；
+	// semCarried 替换时在新信号量中为旧代际预占、尚未归还的令牌数
+	semChanged  chan struct{}
+	semInFlight int
+	semOld      int
+	semCarried  int
 
 	// 动态可调参数（运行时）
 	currFlushSize     atomic.Uint32 // 当前 FlushSize
 	currFlushInterval atomic.Int64  // 当前 FlushInterval（ns）
 	nudge             chan struct{} // 轻推信号：用于立即重置计时器
-
-	// 可选注入：日志与指标
+	tuneMu            sync.Mutex    // 串行化动态参数的写入，使 CurrentTuning 读到一致的组合快照
+	reconfMu          sync.Mutex    // 串行化 Reconfigure 的校验与应用，使 Config 读到同一次 Reconfigure 的完整结果
+	asyncMode         atomic.Int32  // 运行时 flush 模式覆盖（SetAsync），0 表示沿用 Perform 选择的模式
+	flushPaused       atomic.Bool   // PauseFlush 暂停 flush 侧（继续接收数据）
+	timerResets       atomic.Uint64 // 刷新定时器被重置的累计次数（TimerResets）
+	// pending 主循环已从数据通道取出、尚未入批的数据（按到达顺序，先于数据通道消费；仅由主循环访问）
+	// flush 被抑制且批次已满时新数据暂存于此，使主循环始终能感知数据通道关闭
+	pending []T
+
+	// flush 返回 ErrStopPipeline 时请求主循环停止（stopReq 供主循环快速检查，stop 用于唤醒空闲的主循环）
+	stopReq atomic.Bool
+	stop    chan struct{}
+	// snapshotReq Snapshot 请求通道（由主循环在事件之间应答）
+	snapshotReq chan chan<- snapshotResult[T]
+	// collectReq StopAndCollect 请求通道（主循环应答后退出）
+	collectReq chan chan<- []T
+	// flushReq FlushSync 请求通道（flush 完成后经应答通道通知）
+	flushReq chan chan error
+	// barrierReq Barrier 请求通道（主循环内完成 flush 与检查点后应答）
+	barrierReq chan barrierRequest
+	// asyncFlushes 在飞的异步 flush（含 WithFlushAffinity 通道中的异步批次），供 Barrier 等待
+	asyncFlushes sync.WaitGroup
+
+	// 可选注入：名称标签、日志与指标
+	name    string
 	logger  *log.Logger
 	metrics MetricsHook
+	// saturation 为 metrics 的可选扩展（WithMetrics 时解析一次，避免每次 tick 做类型断言）
+	saturation    BufferSaturationHook
+	itemResidence ItemResidenceHook
+	addHook       AddHook
+	// labeledFlush 为 metrics 的可选扩展，配合 labeler 按批次标签上报 flush
+	labeledFlush LabeledFlushHook
+	labeler      MetricsLabeler[T]
+
+	// 生产者侧计数（Add/TryAdd）
+	producer producerCounters
+	// 生产者登记（RegisterProducer）
+	producers producerRegistry
+	// 计数管道（NewCountedPipeline）的持久化计数
+	persist persistCounters
+
+	// 可选：批内驻留时长观测（WithResidenceTracking）
+	residenceTracking bool
+	residences        residenceCounters
+	// fills 组批耗时统计（首条数据入批 → flush 触发）
+	fills fillCounters
+
+	// 可选：批次元信息（WithBatchMeta），batchSeq 为最近分配的批次 ID
+	batchMeta bool
+	batchSeq  atomic.Uint64
+
+	// 可选：最近 N 次 flush 耗时（WithRecentLatencies）
+	latencies *latencyRing
+
+	// 可选：滑动时间窗口内的 flush 成功/失败计数（WithSuccessWindow）
+	outcomes *outcomeWindow
+
+	// 可选：已 flush 批次大小的指数移动平均（WithBatchSizeEMA）
+	sizeEMA *batchSizeEMA
+
+	// 可选：按批次填充率自动调整 FlushInterval（WithAdaptiveInterval）
+	adaptive *intervalController
+
+	// 可选：按估算字节数限制在途数据（MaxBufferedBytes + WithSizeOf）
+	bytes  *byteGuard
+	sizeOf func(T) int
+
+	// 可选：Add/TryAdd 发送前对数据做深拷贝（WithDeepCopy），避免指针负载与异步 flush 共享可变数据
+	deepCopy func(T) T
+	// 可选：缓冲已满时接收溢出数据的 sink（WithOverflowSink）
+	overflow func(T)
+	// 可选：入批前的过滤谓词（WithFilter）及被过滤的累计条数
+	filter   func(T) bool
+	filtered atomic.Uint64
+	// 可选：取消收尾宽限期耗尽时接收剩余数据的溢写函数（WithDrainSpill）
+	spill SpillFunc[T]
+	// 可选：批次就绪后等待外部提交再 flush（WithBatchReady）
+	batchReady *batchReadyConfig
+
+	// 可选：按键亲和的并行 flush（lanes 为本次运行的通道，仅由主循环访问）
+	affinity  *affinityConfig
+	lanes     []chan laneTask
+	keyHasher func(string) uint64 // 可选：自定义键哈希（WithKeyHasher），nil 时使用默认哈希
+
+	// 可选：退出原因回调（数据通道关闭 / ctx 取消）
+	onClose  func()
+	onCancel func()
+
+	// 可选：失败批次的有界重试队列与死信处理
+	retry      *retryQueue
+	deadLetter DeadLetterFunc
+	classify   func(error) ErrorClass // 可选：flush 错误分类（WithErrorClassifier），nil 时均视为 Transient
+
+	// 可选：同步 flush 后复用批容器的重置函数（WithResetFunc）；zeroCopyReset 表示其由 WithZeroCopyFlush 设置
+	resetFunc     ResetFunc
+	zeroCopyReset bool
+
+	// 可选：手动 flush 触发通道（WithManualFlushTrigger），每次接收等价于一次定时触发
+	manualTrigger <-chan struct{}
+
+	// 可选：所有运行共享的基础 ctx（WithBaseContext）
+	baseCtx context.Context
+
+	// 可选：flush 前在主循环内对批次做压缩（WithCompact）
+	compact func(batchData any) any
+
+	// 可选：单次 flush 的截止时间系数（WithFlushDeadlineFactor），0 表示不设置
+	flushDeadlineFactor float64
+
+	// NextFlush 的等待者列表（每次 flush 完成后通知并清空）
+	waitMu      sync.Mutex
+	waiters     []chan error
+	waiterCount atomic.Int32
 
 	// 最近一次运行的完成信号（Done）
 	runMu   sync.Mutex
 	runDone chan struct{}
+	// warm Warmup 预先准备的首轮运行资源（由 runMu 保护）
+	warm *warmState
+	// 永久关闭信号（Close/Closed，由 runMu 保护）：closing 已请求关闭，closedDone 信号通道已关闭
+	closing    bool
+	closedDone bool
+	closed     chan struct{}
 }
 
 // 确保 PipelineImpl 实现了 Performer 接口
@@ -71,20 +224,33 @@ func NewPipelineImpl[T any](
 	// 规范化配置（与 ValidateOrDefault 一致）
 	config = config.ValidateOrDefault()
 	p := &PipelineImpl[T]{
-		config:    config,
-		dataChan:  make(chan T, config.BufferSize),
-		processor: processor,
-		errorChan: nil,
-		nudge:     make(chan struct{}, 1),
+		config:      config,
+		dataChan:    make(chan T, config.BufferSize),
+		processor:   processor,
+		errorChan:   nil,
+		nudge:       make(chan struct{}, 1),
+		stop:        make(chan struct{}, 1),
+		snapshotReq: make(chan chan<- snapshotResult[T]),
+		collectReq:  make(chan chan<- []T),
+		flushReq:    make(chan chan error),
+		barrierReq:  make(chan barrierRequest),
 	}
+	p.batchCtx, _ = processor.(batchContextBinder)
 	// 初始化动态参数
 	p.currFlushSize.Store(config.FlushSize)
 	p.currFlushInterval.Store(int64(config.FlushInterval))
 
-	// 初始化固定容量的并发信号量（0 表示不限制）
+	// 初始化并发信号量（0 表示不限制，运行中可经 SetMaxConcurrentFlushes 调整）
 	if config.MaxConcurrentFlushes > 0 {
 		p.flushSem = make(chan struct{}, int(config.MaxConcurrentFlushes))
 	}
+	if config.MaxBufferedBytes > 0 {
+		p.bytes = &byteGuard{max: config.MaxBufferedBytes}
+	}
+	// 配置校验：非严格模式下仅在构造时告警一次（严格模式在 Perform/Start 时拒绝运行）
+	if err := config.Validate(); err != nil && !config.StrictConfig {
+		p.logPrintln("pipeline config warning: ", err)
+	}
 
 	return p
 }
@@ -101,6 +267,7 @@ func (p *PipelineImpl[T]) DataChan() chan<- T {
 // 返回值: 如果执行过程中发生错误则返回error
 func (p *PipelineImpl[T]) AsyncPerform(ctx context.Context) error {
 	err := p.performLoop(ctx, true)
+	p.closeErrorsOnStop(err)
 	return err
 }
 
@@ -109,9 +276,12 @@ func (p *PipelineImpl[T]) AsyncPerform(ctx context.Context) error {
 //   - ctx: 上下文对象，用于控制操作的生命周期
 //
 // 运行时产生的错误将通过 ErrorChan 下发（需显式调用 ErrorChan 接收）
+// 顺序保证: 同步模式下 flush 在主循环内串行执行，同一次运行内的数据按到达（被主循环接收）的顺序 flush；
+// 跨运行（重启）时各次运行相互独立。可用 pipelinetest.AssertOrdered 验证
 // 返回值: 如果执行过程中发生错误则返回error
 func (p *PipelineImpl[T]) SyncPerform(ctx context.Context) error {
 	err := p.performLoop(ctx, false)
+	p.closeErrorsOnStop(err)
 	return err
 }
 
@@ -152,99 +322,104 @@ func (p *PipelineImpl[T]) performLoop(
 		if p.runDone == myDone {
 			p.runDone = nil
 		}
+		// 已请求 Close：本次运行结束即管道永久关闭
+		p.markClosedLocked()
 		p.runMu.Unlock()
 	}()
 
+	if p.isClosed() {
+		return ErrPipelineClosed
+	}
+	// 启用 WithBaseContext 时，基础 ctx 的取消同样结束本次运行
+	ctx, releaseBase := p.mergeBaseContext(ctx)
+	defer releaseBase()
+
+	// 配置校验：严格模式下拒绝运行（非严格模式已在构造时告警）
+	if p.config.StrictConfig {
+		if err := p.config.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// 使用可重置的 timer，使 FlushInterval 的动态更新在下一次触发时生效
-	timer := time.NewTimer(p.CurrentFlushInterval())
+	// 若已调用 Warmup，则复用预先准备的批容器与定时器
+	var timer *time.Timer
+	var st *batchState
+	if w := p.takeWarmState(); w != nil {
+		timer, st = w.timer, w.st
+		timer.Reset(p.CurrentFlushInterval())
+	} else {
+		timer = time.NewTimer(p.CurrentFlushInterval())
+		st = p.newBatchState()
+	}
 	defer timer.Stop()
-
-	batchData := p.processor.initBatchData()
+	// 启用 StartupGracePeriod 时，宽限期内只累计不 flush
+	p.startGrace(st, timer)
+
+	// 退出时仍留在批次中的数据已被丢弃，释放其内存护栏额度
+	defer func() { p.releaseBytes(st.bytes) }()
+	// 启用按键亲和时启动 flush 通道，退出前等待在途批次完成
+	defer p.startLanes()()
+	// 启用 MaxFlushLingerTimeout 时，退出前限时等待在途异步 flush（先于关闭 flush 通道执行）
+	if d := p.config.MaxFlushLingerTimeout; d > 0 {
+		var cancelFlushes context.CancelFunc
+		ctx, cancelFlushes = context.WithCancel(ctx)
+		defer p.awaitLingeringFlushes(d, cancelFlushes)
+	}
+	// 清除上一次运行遗留的停止请求
+	p.resetStopRequest()
+	// 启用 HeartbeatInterval 时启动不随 flush 重置的心跳（未启用时为 nil 通道，永不就绪）
+	heartbeat, stopHeartbeat := p.startHeartbeat()
+	defer stopHeartbeat()
+	// 手动触发通道（未设置时为 nil，永不就绪；被关闭后本次运行不再监听）
+	trigger := p.manualTrigger
+	if p.useStaticLoop(async) {
+		// 同步 + 静态参数：使用精简循环（无 nudge 分支）
+		return p.staticSyncLoop(ctx, timer, heartbeat, trigger, st)
+	}
 
 	for {
 		select {
 		case newData, ok := <-p.dataChan:
 			if !ok {
 				// 数据通道已关闭：最终刷新未满批次后退出
-				if !p.processor.isBatchEmpty(batchData) {
-					// 使用 FinalFlushOnCloseTimeout 限时最终 flush（0 表示不限时，保持 Background）
-					ctxClose := context.Background()
-					if p.config.FinalFlushOnCloseTimeout > 0 {
-						var cancel context.CancelFunc
-						ctxClose, cancel = context.WithTimeout(context.Background(), p.config.FinalFlushOnCloseTimeout)
-						p.doFlush(ctxClose, false, batchData)
-						cancel()
-					} else {
-						p.doFlush(ctxClose, false, batchData)
-					}
-				}
-				return nil
+				return p.finishOnClose(ctx, st)
 			}
-			batchData = p.processor.addToBatch(batchData, newData)
-			if !p.processor.isBatchFull(batchData) {
-				continue
+			p.receiveData(ctx, async, st, newData, timer)
+			if !p.receiveMore(ctx, async, st, timer) {
+				return p.finishOnClose(ctx, st)
 			}
-			p.doFlush(ctx, async, batchData)
-			batchData = p.processor.initBatchData()
-
-			// 重置 timer，避免过早触发下一次 flush
-			p.resetTimer(timer)
+		case <-p.pendingReady(st):
+			p.handleData(ctx, async, st, p.popPending(), timer)
 		case <-timer.C:
-			// 定时触发：空批则跳过，但仍需重置定时器
-			if !p.processor.isBatchEmpty(batchData) {
-				p.doFlush(ctx, async, batchData)
-				batchData = p.processor.initBatchData()
+			p.handleTick(ctx, async, st, timer)
+		case <-heartbeat:
+			p.handleHeartbeat(ctx, async, st, timer)
+		case _, ok := <-trigger:
+			if !ok {
+				trigger = nil
+				continue
 			}
-			// 重置下一次触发时间，读取当前可调的 FlushInterval
-			p.resetTimer(timer)
+			p.handleTick(ctx, async, st, timer)
 		case <-p.nudge:
-			// 轻推：仅重置计时器到当前 FlushInterval，不触发 flush
+			// 轻推：重置计时器到当前 FlushInterval；仅当恢复 flush 后批次已满时触发 flush
+			p.flushIfFull(ctx, async, st)
 			p.resetTimer(timer)
+		case reply := <-p.snapshotReq:
+			p.handleSnapshot(st, reply)
+		case reply := <-p.collectReq:
+			return p.handleCollect(st, reply)
+		case reply := <-p.flushReq:
+			p.handleFlushSync(ctx, async, st, timer, reply)
+		case req := <-p.barrierReq:
+			p.handleBarrier(ctx, st, timer, req)
+		case <-p.stop:
+			return p.handleStop(st)
 		case <-ctx.Done():
-			// 取消退出语义：
-			// - DrainOnCancel=false：不做最终 flush，返回 ErrContextIsClosed（可用 errors.Is(err, ErrContextIsClosed) 判断）
-			// - DrainOnCancel=true：尽力将当前通道缓冲中的数据吸入批并在独立 drainCtx 下同步 flush；
-			//   返回 errors.Join(ErrContextIsClosed, ErrContextDrained)：
-			//     * errors.Is(err, ErrContextIsClosed) == true → 因取消退出
-			//     * errors.Is(err, ErrContextDrained)  == true → 已执行限时收尾
-			if p.config.DrainOnCancel {
-				// 1) 独立的收尾上下文，避免被原 ctx 立即打断
-				grace := p.config.DrainGracePeriod
-				if grace <= 0 {
-					grace = 100 * time.Millisecond
-				}
-				drainCtx, cancel := context.WithTimeout(context.Background(), grace)
-
-				// 2) 非阻塞地抽干当前通道缓冲中的数据，尽量纳入批（避免阻塞/无限等待）
-				// 注意：仅在取消瞬间把“已缓冲”的项尽力带走；不会主动长期拉取新生产的数据。
-				for {
-					select {
-					case v, ok := <-p.dataChan:
-						if !ok {
-							// 通道已关闭，关闭路径已有最终 flush 保障，这里直接跳出
-							goto DRAIN_DONE
-						}
-						batchData = p.processor.addToBatch(batchData, v)
-						if p.processor.isBatchFull(batchData) {
-							// 批满则立即同步 flush，以免超过 grace 时间
-							p.doFlush(drainCtx, false, batchData)
-							batchData = p.processor.initBatchData()
-						}
-					default:
-						// 通道当前没有更多缓冲项（非阻塞抽干结束）
-						goto DRAIN_DONE
-					}
-				}
-			DRAIN_DONE:
-				// 3) 执行最后一次同步 flush（若批非空）
-				if !p.processor.isBatchEmpty(batchData) {
-					p.doFlush(drainCtx, false, batchData)
-				}
-				cancel()
-				// 4) 返回“取消且已收尾”的组合错误
-				return errors.Join(ErrContextIsClosed, ErrContextDrained)
-			}
-			return ErrContextIsClosed
+			return p.handleCancel(ctx, st)
+		}
+		if p.stopReq.Load() {
+			return p.handleStop(st)
 		}
 	}
 }
@@ -255,6 +430,7 @@ func (p *PipelineImpl[T]) performLoop(
 //   - ctx: 上下文对象，用于控制操作的生命周期
 //   - async: 是否使用异步模式刷新数据
 //   - batchData: 待刷新的数据批次
+//   - bytes: 批次占用的估算字节数，flush 完成后释放（未启用内存护栏时为 0）
 //
 // 注意: 该方法会根据async参数判断是否异步执行刷新操作
 //
@@ -266,19 +442,25 @@ func (p *PipelineImpl[T]) doFlush(
 	ctx context.Context,
 	async bool,
 	batchData any,
+	bytes int64,
 ) {
+	if p.lanes != nil {
+		// 按键亲和：相同代表键的批次串行，不同键并行
+		p.submitToLane(ctx, async, batchData, bytes)
+		return
+	}
 	if async {
 		// 若设置了并发上限，则使用信号量限制在飞 flush goroutine 数
-		if p.flushSem != nil {
-			p.flushSem <- struct{}{}
-			go func() {
-				defer func() { <-p.flushSem }()
-				p.flushWithErrorChan(ctx, batchData)
-			}()
-		} else {
-			go p.flushWithErrorChan(ctx, batchData)
-		}
+		sem := p.acquireFlushSlot()
+		p.asyncFlushes.Add(1)
+		go func() {
+			defer p.releaseFlushSlot(sem)
+			defer p.asyncFlushes.Done()
+			defer p.releaseBytes(bytes)
+			p.flushWithErrorChan(ctx, batchData)
+		}()
 	} else {
+		defer p.releaseBytes(bytes)
 		p.flushWithErrorChan(ctx, batchData)
 	}
 }
@@ -288,33 +470,124 @@ func (p *PipelineImpl[T]) doFlush(
 //   - ctx: 上下文对象，用于控制操作的生命周期
 //   - batchData: 待刷新的数据批次
 func (p *PipelineImpl[T]) flushWithErrorChan(ctx context.Context, batchData any) {
+	if p.sizeEMA != nil {
+		p.sizeEMA.observe(batchLen(batchData))
+	}
+	ctx, cancel := p.flushDeadline(ctx)
+	err, outcome := p.flushAndReport(ctx, batchData)
+	cancel()
+	if p.outcomes != nil {
+		p.outcomes.record(time.Now(), outcome == nil)
+	}
+	if errors.Is(err, ErrStopPipeline) {
+		// 下游已永久不可用：不再重试，直接交给死信处理
+		p.sendDeadLetter(&retryEntry{batch: batchData, lastErr: err})
+		return
+	}
+	if err != nil && p.retry != nil {
+		if p.classifyError(err) == Permanent {
+			// 永久性错误重试无益：直接交给死信处理
+			p.sendDeadLetter(&retryEntry{batch: batchData, attempts: 1, lastErr: err})
+			return
+		}
+		// 启用重试队列时，失败批次进入有界重试队列，由后台协程按退避间隔重放
+		p.enqueueRetry(&retryEntry{batch: batchData, attempts: 1, lastErr: err})
+	}
+}
+
+// WithFlushDeadlineFactor 为每次 flush 的 ctx 设置与批处理节奏相关的截止时间（可选）
+// 设置后每次 flush 开始执行时派生 context.WithTimeout(ctx, f*CurrentFlushInterval())，
+// 避免 flush 的执行时间远超批处理节奏而失去意义（例如 f=2 表示最多允许两个刷新间隔）
+// 说明:
+//   - f <= 0 表示禁用（默认），保持原有行为
+//   - 截止时间从 flush 实际开始执行时计算（不含在信号量/通道中的排队时间），与原 ctx 的取消取较早者
+//   - 仅对主循环派发的 flush 生效；重试队列的重放使用独立的 context.Background()
+//   - flush 函数需要遵守 ctx 才能真正被限时
+func (p *PipelineImpl[T]) WithFlushDeadlineFactor(f float64) *PipelineImpl[T] {
+	p.flushDeadlineFactor = f
+	return p
+}
+
+// flushDeadline 按 WithFlushDeadlineFactor 派生带截止时间的 flush ctx（未启用时原样返回）
+func (p *PipelineImpl[T]) flushDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.flushDeadlineFactor <= 0 {
+		return ctx, func() {}
+	}
+	d := time.Duration(p.flushDeadlineFactor * float64(p.CurrentFlushInterval()))
+	return context.WithTimeout(ctx, d)
+}
+
+// flushAndReport 执行一次 flush（含 panic 恢复、指标与错误上报），并返回本次 flush 的错误
+// 参数:
+//   - ctx: 上下文对象，用于控制操作的生命周期
+//   - batchData: 待刷新的数据批次
+//
+// 返回值:
+//   - err: flush 返回的错误（决定重试与死信）；PanicRecoverAndReport 策略下恢复的 panic 也会作为错误返回
+//   - outcome: 本次 flush 的结果，供 NextFlush/FlushSync 等待者与成功率统计使用；
+//     与 err 相同，但无论 PanicPolicy 如何，发生 panic 时均为包装了 ErrFlushPanic 的错误
+func (p *PipelineImpl[T]) flushAndReport(ctx context.Context, batchData any) (err, outcome error) {
+	// 最先注册、最后执行：确保在 panic 处理确定最终结果之后再通知 NextFlush 等待者
+	defer p.notifyFlushWaiters(&outcome)
+	defer notifyForcedFlush(ctx, &outcome)
 	defer func() {
-		if r := recover(); r != nil {
-			if p.logger != nil {
-				p.logger.Println("panic recovered in pipeline: ", r)
-			} else {
-				log.Println("panic recovered in pipeline: ", r)
-			}
+		r := recover()
+		if r == nil {
+			outcome = err
+			return
+		}
+		outcome = fmt.Errorf("%w: %v", ErrFlushPanic, r)
+		p.batchLogPrintln(ctx, "panic recovered in pipeline: ", r)
+		if p.config.PanicStackTrace {
+			p.batchLogPrintln(ctx, "panic stack trace:\n", string(debug.Stack()))
+		}
+		switch p.config.PanicPolicy {
+		case PanicRethrow:
+			// 快速失败：记录日志后重新抛出
+			panic(r)
+		case PanicRecoverAndReport:
+			err = outcome
+			p.safeErrorSend(err)
+			p.sendBatchError(batchData, err)
 		}
 	}()
 
+	label, labeled := p.batchLabel(batchData)
 	start := time.Now()
-	err := p.processor.flush(ctx, batchData)
+	err = p.processor.flush(ctx, batchData)
 	dur := time.Since(start)
 
+	if p.latencies != nil {
+		p.latencies.record(dur)
+	}
+	if p.adaptive != nil {
+		p.observeFill(batchLen(batchData))
+	}
 	// metrics: flush
-	if p.metrics != nil {
+	if labeled {
+		p.labeledFlush.FlushLabeled(label, batchLen(batchData), dur)
+	} else if p.metrics != nil {
 		p.metrics.Flush(batchLen(batchData), dur)
 	}
 
+	if errors.Is(err, ErrStopPipeline) {
+		// 停止请求不是普通错误：通知主循环停止，而非上报错误通道
+		p.requestStop()
+		return err, err
+	}
 	if err != nil {
+		if p.batchMeta && p.logger != nil {
+			p.batchLogPrintln(ctx, "flush failed:", err)
+		}
 		// 安全地发送错误到错误通道
 		p.safeErrorSend(err)
+		p.sendBatchError(batchData, err)
 		// metrics: error
 		if p.metrics != nil {
 			p.metrics.Error(err)
 		}
 	}
+	return err, err
 }
 
 // resetTimer 安全地将定时器重置为当前的刷新间隔。
@@ -336,10 +609,32 @@ func (p *PipelineImpl[T]) resetTimer(timer *time.Timer) {
 		}
 	}
 	timer.Reset(next)
+	p.timerResets.Add(1)
+}
+
+// TimerResets 返回刷新定时器被重置的累计次数（跨多次运行累计），用于排查动态调参是否导致定时器频繁重置
+// 定时触发、批满 flush、轻推（UpdateFlushInterval/UpdateTuning/ResumeFlush 等）与快照处理后的重置均计入；
+// 例如 UpdateFlushInterval 调用远多于定时触发时，该值会明显高于按 FlushInterval 估算的触发次数
+func (p *PipelineImpl[T]) TimerResets() uint64 {
+	return p.timerResets.Load()
+}
+
+// reportBufferSaturation 在主循环内采样数据通道占用率并上报给可选的 BufferSaturationHook
+func (p *PipelineImpl[T]) reportBufferSaturation() {
+	if p.saturation == nil {
+		return
+	}
+	if c := cap(p.dataChan); c > 0 {
+		p.saturation.BufferSaturation(float64(len(p.dataChan)) / float64(c))
+	}
 }
 
 // 计算默认错误通道缓冲区大小
 func (p *PipelineImpl[T]) defaultErrBufSize() int {
+	// 无缓冲通道（BufferSize == 0）时使用最小容量 1
+	if p.config.BufferSize == 0 {
+		return 1
+	}
 	// Keep original proportional behavior to preserve backward compatibility and tests
 	return int((p.config.FlushSize + p.config.BufferSize - 1) / p.config.BufferSize)
 }
@@ -347,13 +642,39 @@ func (p *PipelineImpl[T]) defaultErrBufSize() int {
 // safeErrorSend 安全地发送错误到错误通道
 // 行为说明：
 // 1) 调用 ErrorChan(0) 确保通道已按“首次调用决定缓冲大小”的规则完成一次性初始化
-// 2) 使用非阻塞发送；当缓冲区已满时丢弃该错误，避免阻塞处理主循环
-// 3) 错误通道的生命周期由管道控制，通常不应在外部关闭
+// 2) 使用非阻塞发送；当缓冲区已满时丢弃该错误，避免阻塞处理主循环；启用 WithUnboundedErrors 时改为排队投递，不再丢弃
+// 3) 启用 WithErrorCoalescing 时先按窗口合并连续同类错误，再交给 deliverError 发送
+// 4) 错误通道的生命周期由管道控制，通常不应在外部关闭
 func (p *PipelineImpl[T]) safeErrorSend(err error) {
+	if err == nil {
+		return
+	}
+	if p.coalescer != nil {
+		p.coalesceError(err)
+		return
+	}
+	p.deliverError(err)
+}
+
+// deliverError 将错误写入错误通道：启用 WithUnboundedErrors 时排队投递，否则非阻塞发送，缓冲满时丢弃
+func (p *PipelineImpl[T]) deliverError(err error) {
 	if err == nil {
 		return
 	}
 	_ = p.ErrorChan(0) // 确保已初始化，并获取同一实例的快照
+	p.errCloseMu.Lock()
+	defer p.errCloseMu.Unlock()
+	if p.errClosed {
+		// 错误通道已随运行结束关闭（WithCloseErrorChanOnStop），丢弃
+		if p.metrics != nil {
+			p.metrics.ErrorDropped()
+		}
+		return
+	}
+	if p.errQueue != nil {
+		p.errQueue.enqueueError(p.errorChan, err)
+		return
+	}
 
 	// 使用非阻塞发送，避免阻塞管道处理
 	select {
@@ -383,15 +704,63 @@ func (p *PipelineImpl[T]) safeErrorSend(err error) {
 //	        log.Println("pipeline error:", err)
 //	    }
 
+// WithName 为管道设置名称标签（可选）
+// 名称会作为 "[name] " 前缀出现在内部日志中，并传给实现了 PipelineNameHook 的指标钩子
+func (p *PipelineImpl[T]) WithName(name string) *PipelineImpl[T] {
+	p.name = name
+	if h, ok := p.metrics.(PipelineNameHook); ok {
+		h.PipelineName(name)
+	}
+	return p
+}
+
+// Name 返回 WithName 设置的管道名称（未设置时为空字符串）
+func (p *PipelineImpl[T]) Name() string {
+	return p.name
+}
+
+// logPrintln 输出内部日志：优先使用注入的日志器，设置了名称时附带 "[name] " 前缀
+func (p *PipelineImpl[T]) logPrintln(v ...any) {
+	if p.name != "" {
+		v = append([]any{"[" + p.name + "]"}, v...)
+	}
+	if p.logger != nil {
+		p.logger.Println(v...)
+	} else {
+		log.Println(v...)
+	}
+}
+
 // WithLogger 注入日志器（可选）
 func (p *PipelineImpl[T]) WithLogger(l *log.Logger) *PipelineImpl[T] {
 	p.logger = l
 	return p
 }
 
+// WithOnClose 注入数据通道关闭时的回调（可选）
+// 在关闭路径的最终 flush 完成后、Perform 返回前于主循环协程内调用，表示生产者已正常结束
+func (p *PipelineImpl[T]) WithOnClose(fn func()) *PipelineImpl[T] {
+	p.onClose = fn
+	return p
+}
+
+// WithOnCancel 注入 ctx 取消时的回调（可选）
+// 在取消处理（含 DrainOnCancel 收尾）完成后、Perform 返回前于主循环协程内调用，表示运行被中止
+func (p *PipelineImpl[T]) WithOnCancel(fn func()) *PipelineImpl[T] {
+	p.onCancel = fn
+	return p
+}
+
 // WithMetrics 注入指标钩子（可选）
 func (p *PipelineImpl[T]) WithMetrics(h MetricsHook) *PipelineImpl[T] {
 	p.metrics = h
+	p.saturation, _ = h.(BufferSaturationHook)
+	p.itemResidence, _ = h.(ItemResidenceHook)
+	p.addHook, _ = h.(AddHook)
+	p.labeledFlush, _ = h.(LabeledFlushHook)
+	if nh, ok := h.(PipelineNameHook); ok && p.name != "" {
+		nh.PipelineName(p.name)
+	}
 	return p
 }
 
@@ -403,11 +772,14 @@ func (p *PipelineImpl[T]) Done() <-chan struct{} {
 	return p.runDone
 }
 
-// 计算批次长度（通过反射支持 slice/map）
+// 计算批次长度（通过反射支持 slice/map；自定义容器可实现 Len() int）
 func batchLen(batch any) int {
 	if batch == nil {
 		return 0
 	}
+	if l, ok := batch.(interface{ Len() int }); ok {
+		return l.Len()
+	}
 	v := reflect.ValueOf(batch)
 	switch v.Kind() {
 	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
@@ -421,8 +793,8 @@ func batchLen(batch any) int {
 //	// 如果不关心自定义容量，可在执行前或读取前调用 p.ErrorChan(0)
 //
 // 异常处理说明:
-//   - 刷新过程中的错误通过 safeErrorSend 非阻塞写入本通道，缓冲满时会丢弃该错误以避免阻塞
-//   - 通道由管道内部创建且仅初始化一次，不建议外部关闭；收尾由 context/WaitGroup 协调
+//   - 刷新过程中的错误通过 safeErrorSend 非阻塞写入本通道，缓冲满时会丢弃该错误以避免阻塞（WithUnboundedErrors 时改为排队投递）
+//   - 通道由管道内部创建且仅初始化一次，不建议外部关闭；收尾由 context/WaitGroup 协调，启用 WithCloseErrorChanOnStop 时由管道在运行结束后关闭
 func (p *PipelineImpl[T]) ErrorChan(size int) <-chan error {
 	p.errOnce.Do(func() {
 		n := size
@@ -450,9 +822,9 @@ func (p *PipelineImpl[T]) Start(ctx context.Context) (<-chan struct{}, <-chan er
 		p.runMu.Unlock()
 		// 触发一次执行，仅用于将 ErrAlreadyRunning 上报告知调用方
 		go func() {
-			if err := p.AsyncPerform(ctx); err != nil {
-				p.safeErrorSend(err)
-			}
+			err := p.performLoop(ctx, true)
+			p.safeErrorSend(err)
+			p.closeErrorsOnStop(err)
 		}()
 		return done, errs
 	}
@@ -464,9 +836,10 @@ func (p *PipelineImpl[T]) Start(ctx context.Context) (<-chan struct{}, <-chan er
 	p.runMu.Unlock()
 
 	go func() {
-		if err := p.AsyncPerform(ctx); err != nil {
-			p.safeErrorSend(err)
-		}
+		// 运行结果先写入错误通道，再按 WithCloseErrorChanOnStop 关闭
+		err := p.performLoop(ctx, true)
+		p.safeErrorSend(err)
+		p.closeErrorsOnStop(err)
 	}()
 	return done, errs
 }
@@ -478,12 +851,6 @@ func (p *PipelineImpl[T]) Run(ctx context.Context, errBuf int) error {
 	return p.SyncPerform(ctx)
 }
 
-// 动态并发限流：获取当前 channel 引用；nil 表示不限流
-func (p *PipelineImpl[T]) acquireFlushSlot() chan struct{} {
-	// 已恢复为固定容量的并发信号量实现，此方法不再使用
-	return p.flushSem
-}
-
 // 动态参数：FlushSize
 func (p *PipelineImpl[T]) CurrentFlushSize() uint32 {
 	return p.currFlushSize.Load()
@@ -493,7 +860,9 @@ func (p *PipelineImpl[T]) UpdateFlushSize(n uint32) {
 	if n == 0 {
 		n = 1
 	}
+	p.tuneMu.Lock()
 	p.currFlushSize.Store(n)
+	p.tuneMu.Unlock()
 }
 
 // 动态参数：FlushInterval
@@ -505,8 +874,73 @@ func (p *PipelineImpl[T]) UpdateFlushInterval(d time.Duration) {
 	if d <= 0 {
 		d = time.Millisecond * 1
 	}
+	p.tuneMu.Lock()
 	p.currFlushInterval.Store(int64(d))
-	// 轻推主循环，确保新的间隔尽快生效
+	p.tuneMu.Unlock()
+	p.nudgeLoop()
+}
+
+// 运行时 flush 模式覆盖的取值
+const (
+	asyncModePerform int32 = iota // 沿用 AsyncPerform/SyncPerform 选择的模式
+	asyncModeOn                   // 强制异步 flush
+	asyncModeOff                  // 强制同步 flush
+)
+
+// SetAsync 在运行时切换批满/定时触发的 flush 是否异步执行，无需重启，下一次 flush 即生效
+// 一经调用即覆盖 AsyncPerform/SyncPerform 选择的模式（对后续运行同样生效）。
+// 注意:
+//   - 主循环在每次 flush 后总是“偷换”批容器（initBatchData），不会复用可能仍被异步 flush 读取的存储，因此切换是安全的
+//   - 由异步切回同步时，切换前已派发的异步 flush 可能仍在执行，严格的批次顺序从它们完成后才重新成立
+//   - 通道关闭与取消收尾路径的最终 flush 始终同步执行，不受该设置影响
+func (p *PipelineImpl[T]) SetAsync(enabled bool) {
+	if enabled {
+		p.asyncMode.Store(asyncModeOn)
+	} else {
+		p.asyncMode.Store(asyncModeOff)
+	}
+}
+
+// resolveAsync 结合 SetAsync 的覆盖值决定本次 flush 是否异步执行
+func (p *PipelineImpl[T]) resolveAsync(async bool) bool {
+	switch p.asyncMode.Load() {
+	case asyncModeOn:
+		return true
+	case asyncModeOff:
+		return false
+	default:
+		return async
+	}
+}
+
+// 动态参数：成组读写 FlushSize 与 FlushInterval
+
+// CurrentTuning 返回 FlushSize 与 FlushInterval 的一致快照（不会读到另一次成组更新的“半个”结果）
+func (p *PipelineImpl[T]) CurrentTuning() (uint32, time.Duration) {
+	p.tuneMu.Lock()
+	defer p.tuneMu.Unlock()
+	return p.currFlushSize.Load(), time.Duration(p.currFlushInterval.Load())
+}
+
+// UpdateTuning 成组更新 FlushSize 与 FlushInterval，并只轻推主循环一次
+// 非法值的规范化与 UpdateFlushSize/UpdateFlushInterval 一致
+// 注意: 主循环按需单独读取各参数，两次读取之间可能跨越一次更新；成组一致性由 CurrentTuning 保证
+func (p *PipelineImpl[T]) UpdateTuning(size uint32, interval time.Duration) {
+	if size == 0 {
+		size = 1
+	}
+	if interval <= 0 {
+		interval = time.Millisecond * 1
+	}
+	p.tuneMu.Lock()
+	p.currFlushSize.Store(size)
+	p.currFlushInterval.Store(int64(interval))
+	p.tuneMu.Unlock()
+	p.nudgeLoop()
+}
+
+// nudgeLoop 轻推主循环，确保新的间隔尽快生效
+func (p *PipelineImpl[T]) nudgeLoop() {
 	select {
 	case p.nudge <- struct{}{}:
 	default:
@@ -0,0 +1,124 @@
+package gopipeline
+
+import (
+	"context"
+	"fmt"
+)
+
+// FlushKeyedFunc 处理同一分组键下的一组数据
+// 参数:
+//   - ctx: 上下文对象
+//   - key: 分组键（如租户 ID）
+//   - batchData: 该分组在本批次中的数据（保持到达顺序）
+type FlushKeyedFunc[T any] func(ctx context.Context, key string, batchData []T) error
+
+// KeyedFlushError 记录某个分组 flush 失败的错误，可通过 errors.As 获取失败的分组键
+type KeyedFlushError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyedFlushError) Error() string {
+	return fmt.Sprintf("keyed flush failed (key=%s): %v", e.Key, e.Err)
+}
+
+func (e *KeyedFlushError) Unwrap() error {
+	return e.Err
+}
+
+// KeyedPipeline 按键分组刷新的管道
+// 批次的累计与标准管道一致（按 FlushSize/FlushInterval 触发），flush 时按 keyFunc 分组，
+// 每个分组调用一次 flushFunc；各分组的失败相互隔离并聚合为一个错误上报
+type KeyedPipeline[T any] struct {
+	*PipelineImpl[T]
+	keyFunc   func(T) string
+	flushFunc FlushKeyedFunc[T]
+}
+
+// 确保 KeyedPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*KeyedPipeline[any])(nil)
+
+// NewKeyedPipeline 使用自定义配置创建一个按键分组刷新的管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - keyFunc: 分组键函数
+//   - flushFunc: 每个分组调用一次的刷新函数
+//
+// 返回值: 返回一个新的 KeyedPipeline 实例
+func NewKeyedPipeline[T any](
+	config PipelineConfig,
+	keyFunc func(T) string,
+	flushFunc FlushKeyedFunc[T],
+) *KeyedPipeline[T] {
+	p := &KeyedPipeline[T]{
+		keyFunc:   keyFunc,
+		flushFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 初始化一个新的批处理数据切片
+// 返回值: 返回一个空的类型T切片
+func (p *KeyedPipeline[T]) initBatchData() any {
+	return make([]T, 0, int(p.CurrentFlushSize()))
+}
+
+// addToBatch 将新数据添加到批处理数据切片中
+// 参数:
+//   - batchData: 当前的批处理数据切片
+//   - data: 需要添加的新数据
+//
+// 返回值: 返回更新后的批处理数据切片
+func (p *KeyedPipeline[T]) addToBatch(batchData any, data T) any {
+	return append(batchData.([]T), data)
+}
+
+// flush 按键分组后依次调用刷新函数
+// 参数:
+//   - ctx: 上下文对象，用于控制操作的生命周期
+//   - batchData: 需要刷新的批处理数据
+//
+// 返回值: 各失败分组的 *KeyedFlushError 聚合；全部成功返回 nil
+// 说明: 分组按键首次出现的顺序处理，组内保持到达顺序；配置了 MaxFlushChunk 时组内再按上限拆分
+func (p *KeyedPipeline[T]) flush(ctx context.Context, batchData any) error {
+	var keys []string
+	groups := make(map[string][]T)
+	for _, item := range batchData.([]T) {
+		k := p.keyFunc(item)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], item)
+	}
+
+	var errs []error
+	for _, k := range keys {
+		key := k
+		err := flushSliceChunks(ctx, groups[key], p.config.MaxFlushChunk, func(ctx context.Context, group []T) error {
+			return p.flushFunc(ctx, key, group)
+		})
+		if err != nil {
+			errs = append(errs, &KeyedFlushError{Key: key, Err: err})
+		}
+	}
+	return joinErrors(errs)
+}
+
+// isBatchFull 检查批处理数据切片是否已达到配置的最大容量
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据量达到或超过配置的FlushSize则返回true
+func (p *KeyedPipeline[T]) isBatchFull(batchData any) bool {
+	return len(batchData.([]T)) >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理数据切片是否为空
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据切片长度小于1则返回true
+func (p *KeyedPipeline[T]) isBatchEmpty(batchData any) bool {
+	return len(batchData.([]T)) < 1
+}
@@ -0,0 +1,37 @@
+package gopipeline
+
+import "time"
+
+// MetricsLabeler 根据批次内容返回该批次的指标标签（如租户 ID）
+// 标签取值由调用方决定，应控制在有限集合内，避免指标基数失控
+type MetricsLabeler[T any] func(batch []T) string
+
+// LabeledFlushHook 可选的指标扩展：按批次标签上报 flush
+// 若注入的 MetricsHook 同时实现了该接口且配置了 WithMetricsLabeler，flush 完成后调用 FlushLabeled 代替 Flush
+type LabeledFlushHook interface {
+	// FlushLabeled 在一次 flush 完成后被调用
+	// label: MetricsLabeler 返回的批次标签；items: 本次批次大小；duration: 执行耗时
+	FlushLabeled(label string, items int, duration time.Duration)
+}
+
+// WithMetricsLabeler 注入批次标签函数（可选），使单个管道可按标签（如租户）上报 flush 指标
+// 说明:
+//   - 仅当 MetricsHook 实现了 LabeledFlushHook 时生效；此时每次 flush 只调用 FlushLabeled，不再调用 Flush，避免重复计数
+//   - 标签函数在 flush 前于 flush 协程内调用一次，不计入 flush 耗时；去重管道传入的是窗口内数据值组成的切片
+//   - 标签函数不应修改或持有批次切片
+//   - 一个批次只有一个标签；批次混有多个租户时，可配合 ShardedPipeline 按租户分片后再打标签
+func (p *PipelineImpl[T]) WithMetricsLabeler(fn MetricsLabeler[T]) *PipelineImpl[T] {
+	p.labeler = fn
+	return p
+}
+
+// batchLabel 计算批次标签；未启用标签上报时返回 false
+func (p *PipelineImpl[T]) batchLabel(batchData any) (string, bool) {
+	if p.labeler == nil || p.labeledFlush == nil {
+		return "", false
+	}
+	if bd, ok := batchData.([]T); ok {
+		return p.labeler(bd), true
+	}
+	return p.labeler(snapshotBatch[T](batchData)), true
+}
@@ -0,0 +1,55 @@
+package gopipeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyRing 最近 N 次 flush 耗时的定长环形缓冲（任意 flush 协程并发写，任意协程可读）
+type latencyRing struct {
+	slots []atomic.Int64
+	next  atomic.Uint64 // 已写入的总次数，同时作为下一个写入位置
+}
+
+// record 写入一次 flush 耗时
+func (r *latencyRing) record(d time.Duration) {
+	i := r.next.Add(1) - 1
+	r.slots[i%uint64(len(r.slots))].Store(int64(d))
+}
+
+// snapshot 按从旧到新的顺序复制当前窗口内的耗时
+func (r *latencyRing) snapshot() []time.Duration {
+	n := r.next.Load()
+	size := uint64(len(r.slots))
+	count := n
+	if count > size {
+		count = size
+	}
+	out := make([]time.Duration, 0, count)
+	for i := n - count; i < n; i++ {
+		out = append(out, time.Duration(r.slots[i%size].Load()))
+	}
+	return out
+}
+
+// WithRecentLatencies 启用最近 n 次 flush 耗时的环形记录（可选，n <= 0 表示禁用）
+// 无需注入 MetricsHook 或配置直方图桶，适合 CLI/调试端点快速查看近期窗口（如估算 p99）
+// 开销: 每次 flush 两次原子操作；内存为 n 个 int64
+// 注意: 应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithRecentLatencies(n int) *PipelineImpl[T] {
+	if n <= 0 {
+		p.latencies = nil
+		return p
+	}
+	p.latencies = &latencyRing{slots: make([]atomic.Int64, n)}
+	return p
+}
+
+// RecentLatencies 返回最近至多 n 次 flush 的耗时（从旧到新；未启用时返回 nil）
+// 说明: 与并发 flush 同时读取时为近似快照，可能包含尚未写入完成的槽位的旧值
+func (p *PipelineImpl[T]) RecentLatencies() []time.Duration {
+	if p.latencies == nil {
+		return nil
+	}
+	return p.latencies.snapshot()
+}
@@ -0,0 +1,215 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// LIFOPipeline 后进先出的批处理管道，适用于积压时优先处理最新数据的时效优先场景
+// 数据先压入有界栈，由转运协程每次弹出栈顶（最新）的一条交给内部标准管道组批，
+// 因此积压时批次由最近写入的数据组成，较旧的数据留在栈底，在追上积压后才会被处理
+type LIFOPipeline[T any] struct {
+	inner  *StandardPipeline[T]
+	limit  int
+	policy OverloadPolicy
+
+	mu     sync.Mutex
+	stack  []T
+	closed bool
+	pushed chan struct{} // 入栈或关闭时关闭并置空，唤醒空闲的转运协程
+	popped chan struct{} // 出栈时关闭并置空，唤醒因栈满阻塞的 Add
+
+	closeOnce sync.Once
+}
+
+// NewLIFOPipeline 使用自定义配置创建一个后进先出的管道实例
+// 参数:
+//   - config: 管道配置；BufferSize 为栈容量（0 时为 1），OverloadPolicy 决定栈满时 Add 的行为，其余字段用于内部标准管道
+//   - flushFunc: 用于处理批处理数据的刷新函数
+//
+// 返回值: 返回一个新的 LIFOPipeline 实例
+// 说明:
+//   - 批次内按出栈顺序排列，即新数据在前；无积压时每个批次即为最近写入的若干条数据的逆序
+//   - 内部标准管道使用无缓冲通道，积压全部留在栈中；转运协程至多持有一条已出栈、等待主循环接收的数据
+//   - 时效优先时建议配合 WithOverloadPolicy(DropOldest)：栈满时丢弃栈底最旧的数据
+func NewLIFOPipeline[T any](config PipelineConfig, flushFunc FlushStandardFunc[T]) *LIFOPipeline[T] {
+	limit := int(config.BufferSize)
+	if limit <= 0 {
+		limit = 1
+	}
+	return &LIFOPipeline[T]{
+		inner:  NewStandardPipeline(config.WithBufferSize(0), flushFunc),
+		limit:  limit,
+		policy: config.OverloadPolicy,
+		stack:  make([]T, 0, limit),
+	}
+}
+
+// Pipeline 返回内部的标准管道，用于注入指标钩子、调参或读取统计
+// 注意: 不要直接写入其 DataChan 或调用其 Add/Close，否则数据会绕过栈
+func (p *LIFOPipeline[T]) Pipeline() *StandardPipeline[T] {
+	return p.inner
+}
+
+// Add 将数据压入栈顶
+// 返回值（可用 errors.Is 区分）:
+//   - nil: 已入栈（DropNewest 下栈满时数据被丢弃同样返回 nil）
+//   - ErrContextIsClosed: 栈满阻塞期间 ctx 结束（同时包装 ctx.Err()）
+//   - ErrChannelIsClosed: 已调用 Close
+func (p *LIFOPipeline[T]) Add(ctx context.Context, data T) error {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return ErrChannelIsClosed
+		}
+		if len(p.stack) < p.limit {
+			p.pushLocked(data)
+			p.mu.Unlock()
+			return nil
+		}
+		switch p.policy {
+		case DropOldest:
+			copy(p.stack, p.stack[1:])
+			p.stack = p.stack[:len(p.stack)-1]
+			p.pushLocked(data)
+			p.mu.Unlock()
+			p.inner.recordDrop(DropOldest)
+			return nil
+		case DropNewest:
+			p.mu.Unlock()
+			p.inner.recordDrop(DropNewest)
+			return nil
+		}
+		wait := signalLocked(&p.popped)
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return errors.Join(ErrContextIsClosed, ctx.Err())
+		}
+	}
+}
+
+// TryAdd 非阻塞地将数据压入栈顶；栈满时返回 ErrBufferFull（不受 OverloadPolicy 影响），已关闭时返回 ErrChannelIsClosed
+func (p *LIFOPipeline[T]) TryAdd(data T) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrChannelIsClosed
+	}
+	if len(p.stack) >= p.limit {
+		return ErrBufferFull
+	}
+	p.pushLocked(data)
+	return nil
+}
+
+// Close 停止接收数据：栈中剩余数据仍按后进先出交给内部管道，取尽后关闭其数据通道，由内部管道执行最终 flush 后退出
+// 说明: 由 LIFOPipeline 代为执行“写入方关闭”；调用后 Add/TryAdd 返回 ErrChannelIsClosed，重复调用无副作用
+func (p *LIFOPipeline[T]) Close() {
+	p.mu.Lock()
+	p.closed = true
+	wakeLocked(&p.pushed)
+	p.mu.Unlock()
+}
+
+// Len 返回栈中等待处理的数据条数
+func (p *LIFOPipeline[T]) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.stack)
+}
+
+// Dropped 返回栈满时按 OverloadPolicy 丢弃的累计条数（同时计入内部管道的 Stats().DroppedTotal 并上报 DropHook）
+func (p *LIFOPipeline[T]) Dropped() uint64 {
+	return p.inner.producer.dropped.Load()
+}
+
+// ErrorChan 返回内部管道的错误通道（语义同 PipelineImpl.ErrorChan）
+func (p *LIFOPipeline[T]) ErrorChan(size int) <-chan error {
+	return p.inner.ErrorChan(size)
+}
+
+// SyncPerform 同步运行：启动转运协程并运行内部管道，直到 Close 后栈被取尽或 ctx 结束
+// ctx 结束时转运协程把手中的数据放回栈顶，栈中数据留给下一次运行
+func (p *LIFOPipeline[T]) SyncPerform(ctx context.Context) error {
+	pumpCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go p.pump(pumpCtx)
+	return p.inner.SyncPerform(ctx)
+}
+
+// Start 异步启动，返回内部管道本次运行的 done 与错误通道（语义同 PipelineImpl.Start）
+func (p *LIFOPipeline[T]) Start(ctx context.Context) (<-chan struct{}, <-chan error) {
+	pumpCtx, cancel := context.WithCancel(ctx)
+	done, errs := p.inner.Start(ctx)
+	go p.pump(pumpCtx)
+	go func() {
+		<-done
+		cancel()
+	}()
+	return done, errs
+}
+
+// pump 转运协程：每次弹出栈顶数据交给内部管道；已关闭且栈为空时关闭内部数据通道后退出
+func (p *LIFOPipeline[T]) pump(ctx context.Context) {
+	in := p.inner.DataChan()
+	for {
+		p.mu.Lock()
+		if n := len(p.stack); n > 0 {
+			data := p.stack[n-1]
+			var zero T
+			p.stack[n-1] = zero
+			p.stack = p.stack[:n-1]
+			wakeLocked(&p.popped)
+			p.mu.Unlock()
+
+			select {
+			case in <- data:
+			case <-ctx.Done():
+				// 放回栈顶：它仍是尚未处理的数据中最新的一条
+				p.mu.Lock()
+				p.stack = append(p.stack, data)
+				p.mu.Unlock()
+				return
+			}
+			continue
+		}
+		if p.closed {
+			p.mu.Unlock()
+			p.closeOnce.Do(func() { close(in) })
+			return
+		}
+		wait := signalLocked(&p.pushed)
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pushLocked 压入栈顶并唤醒转运协程，调用方须持有 mu
+func (p *LIFOPipeline[T]) pushLocked(data T) {
+	p.stack = append(p.stack, data)
+	wakeLocked(&p.pushed)
+}
+
+// signalLocked 返回下一次唤醒时关闭的通道，调用方须持有对应的锁
+func signalLocked(ch *chan struct{}) <-chan struct{} {
+	if *ch == nil {
+		*ch = make(chan struct{})
+	}
+	return *ch
+}
+
+// wakeLocked 关闭并置空信号通道以唤醒全部等待者，调用方须持有对应的锁
+func wakeLocked(ch *chan struct{}) {
+	if *ch != nil {
+		close(*ch)
+		*ch = nil
+	}
+}
@@ -0,0 +1,34 @@
+package gopipeline
+
+import (
+	"context"
+	"time"
+)
+
+// FlushLingerHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每当主循环退出后在途异步 flush 超过 MaxFlushLingerTimeout 仍未完成、其 ctx 被取消时调用
+type FlushLingerHook interface {
+	// FlushLingerTimeout 上报一次等待超时；timeout 为生效的 MaxFlushLingerTimeout
+	FlushLingerTimeout(timeout time.Duration)
+}
+
+// awaitLingeringFlushes 在主循环退出前至多等待 timeout 让在途异步 flush 完成，超时则取消它们的 ctx 并上报
+// 无论是否超时，返回前都会调用 cancel 释放本次运行派生的 ctx
+func (p *PipelineImpl[T]) awaitLingeringFlushes(timeout time.Duration, cancel context.CancelFunc) {
+	defer cancel()
+	finished := make(chan struct{})
+	go func() {
+		p.asyncFlushes.Wait()
+		close(finished)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-finished:
+	case <-t.C:
+		p.logPrintln("async flushes still running after MaxFlushLingerTimeout, canceling:", timeout)
+		if h, ok := p.metrics.(FlushLingerHook); ok {
+			h.FlushLingerTimeout(timeout)
+		}
+	}
+}
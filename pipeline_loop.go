@@ -0,0 +1,349 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// batchState 主循环内当前批次的状态（仅由单消费者主循环访问，无需加锁）
+type batchState struct {
+	// data 当前的批处理数据容器
+	data any
+	// stamps 可选：批内每条数据进入批次的时间（仅在启用 WithResidenceTracking 时记录）
+	stamps []time.Time
+	// openedAt 首条数据进入当前空批次的时间（零值表示批次为空），用于观测组批耗时
+	openedAt time.Time
+	// bytes 当前批次占用的估算字节数（仅在启用内存护栏时累计）
+	bytes int64
+	// graceUntil 启动宽限期的截止时间（零值表示未启用或已结束）
+	graceUntil time.Time
+	// finalDone 本次运行的最终 flush 已执行（关闭/取消收尾至多执行一次）
+	finalDone bool
+}
+
+// newBatchState 为一次运行创建初始批次状态
+func (p *PipelineImpl[T]) newBatchState() *batchState {
+	return &batchState{data: p.processor.initBatchData()}
+}
+
+// addToBatch 按过滤谓词将数据追加到当前批次
+func (p *PipelineImpl[T]) addToBatch(st *batchState, data T) {
+	if p.keepItem(data) {
+		p.appendToBatch(st, data)
+	}
+}
+
+// appendToBatch 将数据追加到当前批次，记录开批时间并按需记录入批时间
+func (p *PipelineImpl[T]) appendToBatch(st *batchState, data T) {
+	if st.openedAt.IsZero() {
+		st.openedAt = time.Now()
+	}
+	st.data = p.processor.addToBatch(st.data, data)
+	st.bytes += p.itemBytes(data)
+	if p.residenceTracking {
+		st.stamps = append(st.stamps, time.Now())
+	}
+}
+
+// flushBatch 将当前批次交给 doFlush 并为后续累计准备容器
+// 异步模式下当前容器会被 flush goroutine 持有，因此总是“偷换容器”；同步模式下可经 WithResetFunc 复用
+func (p *PipelineImpl[T]) flushBatch(ctx context.Context, async bool, st *batchState) {
+	p.observeBatchResidence(st)
+	ctx = p.bindBatchMeta(ctx, st.openedAt)
+	p.observeBatchFill(st)
+	if p.batchCtx != nil {
+		ctx = p.batchCtx.bindBatchContext(ctx, st.data)
+	}
+	p.doFlush(ctx, async, p.compactBatch(st.data), st.bytes)
+	st.data = p.nextBatchData(async, st.data)
+	st.bytes = 0
+}
+
+// handleData 处理从数据通道收到的一条数据：过滤后入批，批满则 flush 并重置定时器
+func (p *PipelineImpl[T]) handleData(ctx context.Context, async bool, st *batchState, data T, timer *time.Timer) {
+	if !p.keepItem(data) {
+		return
+	}
+	async = p.resolveAsync(async)
+	paused := p.flushSuppressed(st)
+	p.appendToBatch(st, data)
+	if paused {
+		return
+	}
+	if p.CurrentFlushSize() == 1 && p.batchReady == nil {
+		// 逐条 flush：每条数据即为满批，跳过判满与高水位检查；仍经 flushBatch 派发，批次级观测照常生效。
+		// 批次在 flush 后总为空，定时器无需重置
+		p.flushBatch(ctx, async, st)
+		return
+	}
+	if !p.processor.isBatchFull(st.data) && !(p.aboveHighWatermark() && p.minFlushSizeReached(st)) {
+		return
+	}
+	p.flushWhenCommitted(ctx, async, st)
+
+	// 重置 timer，避免过早触发下一次 flush
+	p.resetTimer(timer)
+}
+
+// receiveMore 在 ReceiveBatch > 0 时，非阻塞地连续接收至多 ReceiveBatch 条数据并入批，摊薄 select 开销
+// 通道为空或收到停止请求时提前返回；数据通道已关闭时返回 false
+func (p *PipelineImpl[T]) receiveMore(ctx context.Context, async bool, st *batchState, timer *time.Timer) bool {
+	for i := uint32(0); i < p.config.ReceiveBatch && !p.stopReq.Load(); i++ {
+		select {
+		case data, ok := <-p.dataChan:
+			if !ok {
+				return false
+			}
+			p.receiveData(ctx, async, st, data, timer)
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// aboveHighWatermark 判断数据通道缓冲占用率是否达到 BufferHighWatermark（未配置或无缓冲时为 false）
+func (p *PipelineImpl[T]) aboveHighWatermark() bool {
+	wm := p.config.BufferHighWatermark
+	c := cap(p.dataChan)
+	if wm <= 0 || c == 0 {
+		return false
+	}
+	return float64(len(p.dataChan)) >= wm*float64(c)
+}
+
+// handleTick 处理定时器触发：采样缓冲占用率，非空批则 flush，并重置定时器
+func (p *PipelineImpl[T]) handleTick(ctx context.Context, async bool, st *batchState, timer *time.Timer) {
+	async = p.resolveAsync(async)
+	// 定时采样缓冲占用率（仅在钩子支持时）
+	p.reportBufferSaturation()
+	// 定时触发：暂停 flush 或启动宽限期内、空批（未启用 FlushEmptyOnInterval 时）或 SizeThenInterval 下未达最小批大小则跳过，但仍需重置定时器
+	if !p.flushSuppressed(st) {
+		if p.processor.isBatchEmpty(st.data) {
+			if p.config.FlushEmptyOnInterval {
+				// 心跳：以空批次调用 flush 函数
+				p.flushBatch(ctx, async, st)
+			}
+		} else if p.minFlushSizeReached(st) {
+			p.flushWhenCommitted(ctx, async, st)
+		}
+	}
+	// 重置下一次触发时间，读取当前可调的 FlushInterval（启动宽限期内不晚于宽限期结束）
+	p.resetTimer(timer)
+	p.capTimerToGrace(st, timer)
+}
+
+// minFlushSizeReached 判断定时或高水位触发时是否满足 FlushCondition（仅在这两类触发时调用，批长度经反射计算）
+func (p *PipelineImpl[T]) minFlushSizeReached(st *batchState) bool {
+	if p.config.FlushCondition != SizeThenInterval {
+		return true
+	}
+	return batchLen(st.data) >= int(p.config.MinFlushSize)
+}
+
+// handleCancel 处理 ctx 取消
+// 取消退出语义：
+//   - DrainOnCancel=false：不做最终 flush，返回 ErrContextIsClosed（可用 errors.Is(err, ErrContextIsClosed) 判断）
+//   - DrainOnCancel=true：尽力将当前通道缓冲中的数据吸入批并在独立 drainCtx 下同步 flush；
+//     返回 errors.Join(ErrContextIsClosed, ErrContextDrained)，
+//     errors.Is(err, ErrContextIsClosed) 表示因取消退出，errors.Is(err, ErrContextDrained) 表示已执行限时收尾
+//   - 数据通道此时已关闭且无缓冲数据（关闭与取消同时就绪）：按关闭路径处理，与 select 先选中关闭分支的结果一致
+func (p *PipelineImpl[T]) handleCancel(ctx context.Context, st *batchState) error {
+	if p.closedOnCancel(st) {
+		return p.finishOnClose(ctx, st)
+	}
+	if p.onCancel != nil {
+		defer p.onCancel()
+	}
+	if p.config.DrainOnCancel {
+		return p.drainOnCancel(st)
+	}
+	return ErrContextIsClosed
+}
+
+// useStaticLoop 判断本次运行能否使用同步精简循环
+// 运行期调参（UpdateFlushInterval 等）是方法调用而非配置，无法从配置推断，因此需由 StaticTuning 显式声明；
+// 已通过 SetAsync 覆盖 flush 模式、启用重试队列或按键亲和时，flush 结果可能在主循环之外产生，回退到通用循环
+func (p *PipelineImpl[T]) useStaticLoop(async bool) bool {
+	return !async && p.config.StaticTuning &&
+		p.asyncMode.Load() == asyncModePerform && p.retry == nil && p.lanes == nil
+}
+
+// staticSyncLoop 同步模式下的精简主循环（useStaticLoop 为 true 时启用）
+// 与通用循环相比去掉了 nudge 分支（同步模式本就不经过 flushSem），减少每次 select 的分支数；
+// 运行期间调用 UpdateFlushInterval 不会立即重置定时器，新间隔在下一次定时器重置时生效。
+// 运行中调用 SetAsync(true) 后 flush 转为异步，其停止请求经 stop 分支唤醒空闲的循环
+func (p *PipelineImpl[T]) staticSyncLoop(ctx context.Context, timer *time.Timer, heartbeat <-chan time.Time, trigger <-chan struct{}, st *batchState) error {
+	for {
+		select {
+		case newData, ok := <-p.dataChan:
+			if !ok {
+				return p.finishOnClose(ctx, st)
+			}
+			p.receiveData(ctx, false, st, newData, timer)
+			if !p.receiveMore(ctx, false, st, timer) {
+				return p.finishOnClose(ctx, st)
+			}
+		case <-p.pendingReady(st):
+			p.handleData(ctx, false, st, p.popPending(), timer)
+		case <-timer.C:
+			p.handleTick(ctx, false, st, timer)
+		case <-heartbeat:
+			p.handleHeartbeat(ctx, false, st, timer)
+		case _, ok := <-trigger:
+			if !ok {
+				trigger = nil
+				continue
+			}
+			p.handleTick(ctx, false, st, timer)
+		case reply := <-p.snapshotReq:
+			p.handleSnapshot(st, reply)
+		case reply := <-p.collectReq:
+			return p.handleCollect(st, reply)
+		case reply := <-p.flushReq:
+			p.handleFlushSync(ctx, false, st, timer, reply)
+		case req := <-p.barrierReq:
+			p.handleBarrier(ctx, st, timer, req)
+		case <-p.stop:
+			return p.handleStop(st)
+		case <-ctx.Done():
+			return p.handleCancel(ctx, st)
+		}
+		// 同步 flush 在本协程内完成，其停止请求在处理完事件后即可观察到
+		if p.stopReq.Load() {
+			return p.handleStop(st)
+		}
+	}
+}
+
+// finishOnClose 处理数据通道关闭：对未满批次执行最终同步 flush 后退出
+func (p *PipelineImpl[T]) finishOnClose(ctx context.Context, st *batchState) error {
+	if p.onClose != nil {
+		defer p.onClose()
+	}
+	if p.config.DropOnCloseAfterCancel && ctx.Err() != nil {
+		// 取消在先、关闭在后且配置为“放弃一切”：丢弃未满批次与暂存数据，按取消语义返回
+		p.dropPending()
+		return ErrContextIsClosed
+	}
+	if !p.processor.isBatchEmpty(st.data) || len(p.pending) > 0 {
+		// 使用 FinalFlushOnCloseTimeout 限时最终 flush（0 表示不限时，保持 Background）
+		ctxClose := context.Background()
+		if p.config.FinalFlushOnCloseTimeout > 0 {
+			var cancel context.CancelFunc
+			ctxClose, cancel = context.WithTimeout(context.Background(), p.config.FinalFlushOnCloseTimeout)
+			defer cancel()
+		}
+		// 暂存数据（如暂停期间接收的数据）按批满规则同步 flush，不受暂停影响
+		p.flushPending(ctxClose, st)
+		if !p.processor.isBatchEmpty(st.data) {
+			p.finalFlush(ctxClose, st)
+		}
+	}
+	return nil
+}
+
+// closedOnCancel 取消时非阻塞地检查数据通道是否已关闭且已无缓冲数据
+// 通道仍有缓冲数据时不做判断（保持取消语义，缓冲数据留在通道中）；
+// 恰在此刻写入的一条数据会并入当前批次，与取消前已入批的数据同等处理
+func (p *PipelineImpl[T]) closedOnCancel(st *batchState) bool {
+	if len(p.dataChan) > 0 || len(p.pending) > 0 {
+		return false
+	}
+	select {
+	case v, ok := <-p.dataChan:
+		if !ok {
+			return true
+		}
+		p.addToBatch(st, v)
+	default:
+	}
+	return false
+}
+
+// finalFlush 执行本次运行的最终同步 flush；已执行过时直接返回，确保未满批次不会被重复 flush
+func (p *PipelineImpl[T]) finalFlush(ctx context.Context, st *batchState) {
+	if st.finalDone {
+		return
+	}
+	st.finalDone = true
+	p.flushBatch(ctx, false, st)
+}
+
+// drainOnCancel 处理 DrainOnCancel=true 时的取消收尾
+// 在独立的 drainCtx（DrainGracePeriod）下按 DrainMode 抽干缓冲并同步 flush，
+// 返回 errors.Join(ErrContextIsClosed, ErrContextDrained)
+func (p *PipelineImpl[T]) drainOnCancel(st *batchState) error {
+	// 1) 独立的收尾上下文，避免被原 ctx 立即打断
+	grace := p.config.DrainGracePeriod
+	if grace <= 0 {
+		grace = 100 * time.Millisecond
+	}
+	drainCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// UntilDeadline：接收阶段只占用宽限期的前一半，为最终 flush 保留预算
+	var wait <-chan struct{}
+	if p.config.DrainMode == UntilDeadline {
+		receiveCtx, stop := context.WithTimeout(drainCtx, grace/2)
+		defer stop()
+		wait = receiveCtx.Done()
+	}
+
+	// 暂停 flush 期间批次可能已满：先 flush，避免暂存数据并入后超过 FlushSize
+	if p.processor.isBatchFull(st.data) {
+		p.flushBatch(drainCtx, false, st)
+	}
+	// 2) 抽干主循环暂存与通道中的数据，尽量纳入批
+	// Snapshot：非阻塞，仅在取消瞬间把“已缓冲”的项尽力带走；UntilDeadline：阻塞接收直到通道关闭或接收期限到达
+DRAIN:
+	for {
+		// 每轮先检查收尾预算：宽限期已用尽则立即停止抽干，避免后续 flush 超出预算
+		if drainCtx.Err() != nil {
+			break
+		}
+		var (
+			v  T
+			ok bool
+		)
+		if len(p.pending) > 0 {
+			// 先取主循环暂存的数据，保持到达顺序
+			v, ok = p.popPending(), true
+		} else if wait == nil {
+			select {
+			case v, ok = <-p.dataChan:
+			default:
+				// 通道当前没有更多缓冲项（非阻塞抽干结束）
+				break DRAIN
+			}
+		} else {
+			select {
+			case v, ok = <-p.dataChan:
+			case <-wait:
+				// 接收期限到达，停止等待迟到数据
+				break DRAIN
+			}
+		}
+		if !ok {
+			// 通道已关闭，关闭路径已有最终 flush 保障，这里直接跳出
+			break DRAIN
+		}
+		p.addToBatch(st, v)
+		if p.processor.isBatchFull(st.data) {
+			// 批满则立即同步 flush，以免超过 grace 时间
+			p.flushBatch(drainCtx, false, st)
+		}
+	}
+
+	// 3) 执行最后一次同步 flush（若批非空且宽限期未耗尽）
+	if drainCtx.Err() == nil && !p.processor.isBatchEmpty(st.data) {
+		p.finalFlush(drainCtx, st)
+	}
+	// 4) 宽限期已耗尽：剩余批次与缓冲交给可选的溢写函数，避免静默丢失
+	if drainCtx.Err() != nil && p.spill != nil {
+		p.spillRemaining(st)
+	}
+	// 5) 返回“取消且已收尾”的组合错误
+	return errors.Join(ErrContextIsClosed, ErrContextDrained)
+}
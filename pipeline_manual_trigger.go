@@ -0,0 +1,14 @@
+package gopipeline
+
+// WithManualFlushTrigger 注入手动 flush 触发通道（可选，主要用于测试）
+// 主循环每从 ch 收到一次信号，即按一次定时触发处理当前批次：非空批次 flush（遵守 FlushCondition/MinFlushSize），
+// 空批次按 FlushEmptyOnInterval 处理，随后重置 FlushInterval 定时器
+// 说明:
+//   - 与定时器并存；需要完全由测试驱动时，将 FlushInterval 设得足够长（如 time.Hour）即可排除真实定时触发
+//   - 暂停 flush 或启动宽限期内的信号同样被跳过；ch 关闭后本次运行不再监听，传入 nil 表示取消设置
+//   - 发送返回仅表示主循环已取走信号；同步模式下可在下一次发送或 FlushSync 返回后断言 flush 结果
+//   - 应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithManualFlushTrigger(ch <-chan struct{}) *PipelineImpl[T] {
+	p.manualTrigger = ch
+	return p
+}
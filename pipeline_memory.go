@@ -0,0 +1,102 @@
+package gopipeline
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// byteGuard 按估算字节数限制在途数据（通道缓冲 + 当前批次 + 执行中的 flush）
+// 生产者侧 Add/TryAdd 预占额度，主循环在批次 flush 完成（或被丢弃）后释放
+type byteGuard struct {
+	max  int64
+	used atomic.Int64
+
+	mu    sync.Mutex
+	freed chan struct{} // 释放额度时关闭并置空，唤醒阻塞的 Add
+}
+
+// tryReserve 尝试预占 n 字节；已用额度为 0 时总是允许，避免单条超限数据永久阻塞
+func (g *byteGuard) tryReserve(n int64) bool {
+	for {
+		cur := g.used.Load()
+		if cur > 0 && cur+n > g.max {
+			return false
+		}
+		if g.used.CompareAndSwap(cur, cur+n) {
+			return true
+		}
+	}
+}
+
+// waitChan 返回下一次释放额度时关闭的通道（须在 tryReserve 之前获取，避免错过唤醒）
+func (g *byteGuard) waitChan() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.freed == nil {
+		g.freed = make(chan struct{})
+	}
+	return g.freed
+}
+
+// release 释放 n 字节额度（下限为 0）并唤醒等待者
+func (g *byteGuard) release(n int64) {
+	if n <= 0 {
+		return
+	}
+	for {
+		cur := g.used.Load()
+		next := cur - n
+		if next < 0 {
+			next = 0
+		}
+		if g.used.CompareAndSwap(cur, next) {
+			break
+		}
+	}
+	g.mu.Lock()
+	if g.freed != nil {
+		close(g.freed)
+		g.freed = nil
+	}
+	g.mu.Unlock()
+}
+
+// WithSizeOf 注入单条数据的字节数估算函数（可选），与 PipelineConfig.MaxBufferedBytes 配合启用内存护栏
+// 说明:
+//   - 仅 Add/TryAdd 发送的数据会预占额度；直接写 DataChan 的数据不受限制，混用时估算值偏低
+//   - 估算函数在生产者与主循环中各调用一次，须对同一数据返回相同结果且开销低；其中的 panic 直接传播给 Add/TryAdd 的调用方
+func (p *PipelineImpl[T]) WithSizeOf(fn func(T) int) *PipelineImpl[T] {
+	p.sizeOf = fn
+	return p
+}
+
+// BufferedBytes 返回当前估算的在途字节数（未启用内存护栏时恒为 0）
+func (p *PipelineImpl[T]) BufferedBytes() int64 {
+	if p.bytes == nil {
+		return 0
+	}
+	return p.bytes.used.Load()
+}
+
+// memoryGuard 返回生效的内存护栏（需同时配置 MaxBufferedBytes 与 WithSizeOf）
+func (p *PipelineImpl[T]) memoryGuard() *byteGuard {
+	if p.sizeOf == nil {
+		return nil
+	}
+	return p.bytes
+}
+
+// itemBytes 估算单条数据的字节数（未启用内存护栏时为 0）
+func (p *PipelineImpl[T]) itemBytes(data T) int64 {
+	if p.memoryGuard() == nil {
+		return 0
+	}
+	return int64(p.sizeOf(data))
+}
+
+// releaseBytes 释放主循环已 flush 或丢弃的数据所占额度
+func (p *PipelineImpl[T]) releaseBytes(n int64) {
+	if g := p.memoryGuard(); g != nil {
+		g.release(n)
+	}
+}
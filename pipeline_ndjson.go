@@ -0,0 +1,89 @@
+package gopipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NDJSONDecodeError 记录 NDJSON 输入中某一行的解码失败，可通过 errors.As 获取行号
+type NDJSONDecodeError struct {
+	Line int // 从 1 开始的行号
+	Err  error
+}
+
+func (e *NDJSONDecodeError) Error() string {
+	return fmt.Sprintf("ndjson decode failed (line=%d): %v", e.Line, e.Err)
+}
+
+func (e *NDJSONDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeNDJSON 从 io.Reader 读取换行分隔的 JSON（NDJSON），逐行解码为 T 后送入标准管道批量处理
+// 参数:
+//   - ctx: 上下文对象；取消后停止读取，管道按取消语义退出
+//   - r: NDJSON 输入（每行一个 JSON 值）
+//   - config: 管道配置
+//   - flushFunc: 批处理函数
+//
+// 返回值:
+//   - done: 输入读完（EOF 或读取出错）且管道完成最终 flush 后关闭（ctx 取消时在管道退出后关闭）
+//   - errs: 管道的错误通道（容量按 ErrorChan(0) 的默认规则）
+//
+// 说明:
+//   - 批次以同步模式按输入顺序依次 flush；读取与 flush 在不同协程中进行，由缓冲解耦
+//   - 空行（仅含空白字符）被跳过；最后一行可以没有换行符
+//   - 单行长度不受 bufio.Scanner 的 64KB 限制
+//   - 解码失败的行以 *NDJSONDecodeError 上报到 errs 并跳过，不影响后续行；读取错误上报后停止读取
+//   - 与其他错误一样，errs 缓冲满时错误会被丢弃，请及时消费
+func DecodeNDJSON[T any](
+	ctx context.Context,
+	r io.Reader,
+	config PipelineConfig,
+	flushFunc FlushStandardFunc[T],
+) (<-chan struct{}, <-chan error) {
+	p := NewStandardPipeline(config, flushFunc)
+	errs := p.ErrorChan(0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// 同步 flush：批次按输入顺序依次处理，done 关闭时所有 flush 均已完成
+		if err := p.SyncPerform(ctx); err != nil {
+			p.safeErrorSend(err)
+		}
+	}()
+	go func() {
+		p.readNDJSON(ctx, r)
+		// 写入方关闭数据通道：管道对剩余数据执行最终 flush 后退出
+		close(p.dataChan)
+	}()
+	return done, errs
+}
+
+// readNDJSON 逐行读取并解码 NDJSON，直到 EOF、读取出错或 ctx 结束
+func (p *PipelineImpl[T]) readNDJSON(ctx context.Context, r io.Reader) {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, readErr := br.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 {
+			var v T
+			if err := json.Unmarshal(trimmed, &v); err != nil {
+				p.safeErrorSend(&NDJSONDecodeError{Line: line, Err: err})
+			} else if err := p.Add(ctx, v); err != nil {
+				// ctx 结束：管道会以取消语义退出并上报，这里只需停止读取
+				return
+			}
+		}
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				p.safeErrorSend(readErr)
+			}
+			return
+		}
+	}
+}
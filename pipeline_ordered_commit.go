@@ -0,0 +1,137 @@
+package gopipeline
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// PrepareFunc 两阶段 flush 的准备阶段：将批次转换为待提交的结果（如序列化、压缩、预写），可跨批次并行执行
+type PrepareFunc[T any, P any] func(ctx context.Context, batchData []T) (P, error)
+
+// CommitFunc 两阶段 flush 的提交阶段：按批次序号严格递增的顺序串行调用
+// seq 为批次在主循环中的派发序号（从 0 开始，跨多次运行连续递增）
+type CommitFunc[P any] func(ctx context.Context, seq uint64, prepared P) error
+
+// UnsequencedBatch 未经主循环派发的批次（如重试队列重放）在 CommitFunc 中收到的序号
+// 此类批次不参与排序，准备完成后立即提交
+const UnsequencedBatch uint64 = math.MaxUint64
+
+// OrderedCommitPipeline 并行准备、按序提交的两阶段管道
+// 批次按派发顺序编号；准备阶段随异步 flush 并行执行，提交阶段等待所有更早的批次提交（或失败）后再执行
+type OrderedCommitPipeline[T any, P any] struct {
+	*PipelineImpl[T]
+	prepareFunc PrepareFunc[T, P]
+	commitFunc  CommitFunc[P]
+
+	// dispatched 下一个派发序号（仅主循环协程读写）
+	dispatched uint64
+
+	// turnMu/turn 保护下一个轮到提交的序号 next
+	turnMu sync.Mutex
+	turn   *sync.Cond
+	next   uint64
+}
+
+// 确保 OrderedCommitPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*OrderedCommitPipeline[any, any])(nil)
+
+// 确保 OrderedCommitPipeline 在派发时为批次编号
+var _ batchContextBinder = (*OrderedCommitPipeline[any, any])(nil)
+
+// orderedSeqKey 批次序号在 ctx 中的键
+type orderedSeqKey struct{}
+
+// NewOrderedCommitPipeline 使用自定义配置创建一个并行准备、按序提交的管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - prepare: 准备阶段函数，可并行执行
+//   - commit: 提交阶段函数，按批次序号顺序串行执行
+//
+// 返回值: 返回一个新的 OrderedCommitPipeline 实例
+// 说明:
+//   - 使用 AsyncPerform/Start 时准备阶段才会并行（并行度受 MaxConcurrentFlushes 限制）；SyncPerform 下两阶段依次执行
+//   - 某批次准备失败时跳过其提交并上报错误，后续批次照常按序提交；提交失败同样不阻塞后续批次
+//   - 批次在完成准备前不会让出提交顺序，准备阶段耗时过长会阻塞其后所有批次的提交
+//   - 整批一次准备、一次提交，MaxFlushChunk 不生效
+func NewOrderedCommitPipeline[T any, P any](
+	config PipelineConfig,
+	prepare PrepareFunc[T, P],
+	commit CommitFunc[P],
+) *OrderedCommitPipeline[T, P] {
+	p := &OrderedCommitPipeline[T, P]{
+		prepareFunc: prepare,
+		commitFunc:  commit,
+	}
+	p.turn = sync.NewCond(&p.turnMu)
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 初始化一个新的批处理数据切片
+func (p *OrderedCommitPipeline[T, P]) initBatchData() any {
+	return make([]T, 0, int(p.CurrentFlushSize()))
+}
+
+// addToBatch 将新数据添加到批处理数据切片中
+func (p *OrderedCommitPipeline[T, P]) addToBatch(batchData any, data T) any {
+	return append(batchData.([]T), data)
+}
+
+// isBatchFull 检查批处理数据切片是否已达到配置的最大容量
+func (p *OrderedCommitPipeline[T, P]) isBatchFull(batchData any) bool {
+	return len(batchData.([]T)) >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理数据切片是否为空
+func (p *OrderedCommitPipeline[T, P]) isBatchEmpty(batchData any) bool {
+	return len(batchData.([]T)) < 1
+}
+
+// bindBatchContext 在主循环派发批次时分配序号并绑定到 flush 的 ctx
+func (p *OrderedCommitPipeline[T, P]) bindBatchContext(ctx context.Context, _ any) context.Context {
+	seq := p.dispatched
+	p.dispatched++
+	return context.WithValue(ctx, orderedSeqKey{}, seq)
+}
+
+// flush 执行准备阶段，等待轮到本批次后执行提交阶段
+// 无论准备/提交成功、失败或 panic，都会在轮到本批次后推进提交序号，避免后续批次永久等待
+func (p *OrderedCommitPipeline[T, P]) flush(ctx context.Context, batchData any) error {
+	seq, ok := ctx.Value(orderedSeqKey{}).(uint64)
+	if !ok {
+		prepared, err := p.prepareFunc(ctx, batchData.([]T))
+		if err != nil {
+			return err
+		}
+		return p.commitFunc(ctx, UnsequencedBatch, prepared)
+	}
+	defer p.finishTurn(seq)
+
+	prepared, err := p.prepareFunc(ctx, batchData.([]T))
+	p.waitTurn(seq)
+	if err != nil {
+		return err
+	}
+	return p.commitFunc(ctx, seq, prepared)
+}
+
+// waitTurn 阻塞直到轮到序号 seq 提交
+func (p *OrderedCommitPipeline[T, P]) waitTurn(seq uint64) {
+	p.turnMu.Lock()
+	defer p.turnMu.Unlock()
+	for p.next != seq {
+		p.turn.Wait()
+	}
+}
+
+// finishTurn 等待轮到序号 seq 后将提交序号推进到下一个批次，并唤醒等待者
+func (p *OrderedCommitPipeline[T, P]) finishTurn(seq uint64) {
+	p.turnMu.Lock()
+	defer p.turnMu.Unlock()
+	for p.next != seq {
+		p.turn.Wait()
+	}
+	p.next++
+	p.turn.Broadcast()
+}
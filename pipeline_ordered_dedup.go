@@ -0,0 +1,80 @@
+package gopipeline
+
+import "context"
+
+// OrderedDeduplicationPipeline 保留首次插入顺序的去重管道
+// 与 DeduplicationPipeline 一样按 GetKey 在窗口内去重（后到的数据覆盖先到的数据），
+// 但 flush 时以 []T 按各键首次出现的顺序输出：覆盖只替换数据，不改变该键的位置
+// 适用于需要确定性重放去重后数据流的场景
+type OrderedDeduplicationPipeline[T UniqueKeyData] struct {
+	*PipelineImpl[T]
+	flushFunc FlushStandardFunc[T]
+	// index 当前批次中各键在切片中的位置（仅主循环访问，随批次重建而重置）
+	index map[string]int
+	// added 当前批次的写入条数，含被覆盖的重复数据（仅主循环访问，随批次重建而重置）
+	added int
+}
+
+// 确保 OrderedDeduplicationPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[UniqueKeyData] = (*OrderedDeduplicationPipeline[UniqueKeyData])(nil)
+
+// NewOrderedDeduplicationPipeline 使用自定义配置创建一个保留插入顺序的去重管道实例
+// 参数:
+//   - config: 自定义的管道配置（MaxDedupKeys、MaxDedupItems 同样生效）
+//   - flushFunc: 处理按首次插入顺序排列的去重批次的刷新函数
+//
+// 返回值: 返回一个新的 OrderedDeduplicationPipeline 实例
+func NewOrderedDeduplicationPipeline[T UniqueKeyData](
+	config PipelineConfig,
+	flushFunc FlushStandardFunc[T],
+) *OrderedDeduplicationPipeline[T] {
+	p := &OrderedDeduplicationPipeline[T]{
+		flushFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 初始化一个新的批处理数据切片，并重置键位置索引
+func (p *OrderedDeduplicationPipeline[T]) initBatchData() any {
+	size := int(p.CurrentFlushSize())
+	p.index = make(map[string]int, size)
+	p.added = 0
+	return make([]T, 0, size)
+}
+
+// addToBatch 将新数据添加到批处理切片中
+// 说明: 新键追加到末尾；已存在的键原位覆盖，保持其首次插入的位置
+func (p *OrderedDeduplicationPipeline[T]) addToBatch(batchData any, data T) any {
+	bd := batchData.([]T)
+	key := data.GetKey()
+	p.added++
+	if i, ok := p.index[key]; ok {
+		bd[i] = data
+		return bd
+	}
+	p.index[key] = len(bd)
+	return append(bd, data)
+}
+
+// flush 使用配置的刷新函数处理批处理数据（配置了 MaxFlushChunk 时按顺序拆分）
+func (p *OrderedDeduplicationPipeline[T]) flush(ctx context.Context, batchData any) error {
+	return flushSliceChunks(ctx, batchData.([]T), p.config.MaxFlushChunk, p.flushFunc)
+}
+
+// isBatchFull 检查不同键数是否达到 FlushSize 或 MaxDedupKeys，或写入条数达到 MaxDedupItems
+func (p *OrderedDeduplicationPipeline[T]) isBatchFull(batchData any) bool {
+	n := len(batchData.([]T))
+	if p.config.MaxDedupKeys > 0 && n >= int(p.config.MaxDedupKeys) {
+		return true
+	}
+	if p.dedupItemsReached(p.added) {
+		return true
+	}
+	return n >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理数据切片是否为空
+func (p *OrderedDeduplicationPipeline[T]) isBatchEmpty(batchData any) bool {
+	return len(batchData.([]T)) < 1
+}
@@ -0,0 +1,110 @@
+package gopipeline
+
+import (
+	"context"
+	"time"
+)
+
+// PauseFlush 暂停 flush（仅 flush 侧），主循环继续接收数据并累计到当前批次
+// 说明:
+//   - 暂停期间批满、定时触发与缓冲高水位均不会 flush；当前批次达到满批条件后，主循环仍持续接收，
+//     新数据按到达顺序暂存在主循环内，恢复后依次入批，批大小仍遵循 FlushSize
+//   - 持续接收是为了随时感知数据通道关闭，代价是暂停期间不会经通道缓冲对生产者形成背压；
+//     暂存数据随暂停时长增长，需要限制内存时请配合 MaxBufferedBytes + WithSizeOf（超限时 Add 阻塞、TryAdd 返回 ErrMemoryLimit）
+//   - 风险：请仅用于短时的下游维护窗口，并结合写入速率与监控评估可承受的暂停时长
+//   - 数据通道关闭与取消收尾路径不受暂停影响，仍会 flush 当前批次与暂存数据，保证关闭时不丢数据
+//   - 线程安全，可在运行中随时调用；重复调用是幂等的
+func (p *PipelineImpl[T]) PauseFlush() {
+	p.flushPaused.Store(true)
+}
+
+// ResumeFlush 恢复 flush：已满的批次立即 flush，其余批次按正常的批满/定时规则继续
+// 说明: StaticTuning 精简循环没有唤醒分支，恢复后在下一次定时器触发时生效
+func (p *PipelineImpl[T]) ResumeFlush() {
+	if p.flushPaused.CompareAndSwap(true, false) {
+		p.nudgeLoop()
+	}
+}
+
+// FlushPaused 返回 flush 是否处于暂停状态
+func (p *PipelineImpl[T]) FlushPaused() bool {
+	return p.flushPaused.Load()
+}
+
+// closedSignal 已关闭的通道，用作 select 中恒就绪的分支
+var closedSignal = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// holdsBatch 判断当前批次是否暂不接纳新数据：flush 被抑制（暂停或启动宽限期）且批次已满
+func (p *PipelineImpl[T]) holdsBatch(st *batchState) bool {
+	return p.flushSuppressed(st) && p.processor.isBatchFull(st.data)
+}
+
+// receiveData 处理从数据通道收到的一条数据
+// 已有暂存数据或当前批次暂不接纳时追加到 pending（保持到达顺序），否则照常入批
+func (p *PipelineImpl[T]) receiveData(ctx context.Context, async bool, st *batchState, data T, timer *time.Timer) {
+	if len(p.pending) > 0 || p.holdsBatch(st) {
+		p.pending = append(p.pending, data)
+		return
+	}
+	p.handleData(ctx, async, st, data, timer)
+}
+
+// pendingReady 返回本轮 select 的暂存数据分支：有暂存数据且当前批次可接纳时恒就绪，否则为 nil（永不就绪）
+func (p *PipelineImpl[T]) pendingReady(st *batchState) <-chan struct{} {
+	if len(p.pending) == 0 || p.holdsBatch(st) {
+		return nil
+	}
+	return closedSignal
+}
+
+// popPending 取出最早的一条暂存数据；取空后释放底层数组
+func (p *PipelineImpl[T]) popPending() T {
+	var zero T
+	v := p.pending[0]
+	p.pending[0] = zero
+	p.pending = p.pending[1:]
+	if len(p.pending) == 0 {
+		p.pending = nil
+	}
+	return v
+}
+
+// flushPending 将暂存数据依次入批，批满即同步 flush（用于关闭收尾，不受暂停影响）
+func (p *PipelineImpl[T]) flushPending(ctx context.Context, st *batchState) {
+	for len(p.pending) > 0 {
+		if p.processor.isBatchFull(st.data) {
+			p.flushBatch(ctx, false, st)
+		}
+		p.addToBatch(st, p.popPending())
+	}
+}
+
+// takePending 取出全部暂存数据追加到 items，并释放其内存护栏额度
+func (p *PipelineImpl[T]) takePending(items []T) []T {
+	for _, v := range p.pending {
+		p.releaseBytes(p.itemBytes(v))
+		items = append(items, v)
+	}
+	p.pending = nil
+	return items
+}
+
+// dropPending 丢弃全部暂存数据并释放其内存护栏额度
+func (p *PipelineImpl[T]) dropPending() {
+	for _, v := range p.pending {
+		p.releaseBytes(p.itemBytes(v))
+	}
+	p.pending = nil
+}
+
+// flushIfFull 在批次已满时 flush（用于恢复 flush 后处理暂停期间已满的批次）
+func (p *PipelineImpl[T]) flushIfFull(ctx context.Context, async bool, st *batchState) {
+	if p.flushSuppressed(st) || !p.processor.isBatchFull(st.data) {
+		return
+	}
+	p.flushBatch(ctx, p.resolveAsync(async), st)
+}
@@ -0,0 +1,74 @@
+package gopipeline
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// FlushCountedFunc 返回实际持久化条数的刷新函数
+// persisted 为下游实际接受的条数（如部分行被数据库拒绝时小于 len(batchData)）
+type FlushCountedFunc[T any] func(ctx context.Context, batchData []T) (persisted int, err error)
+
+// PersistedHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，计数管道每次调用刷新函数后上报实际持久化条数
+type PersistedHook interface {
+	// Persisted 上报一次刷新函数调用的结果：persisted 为实际持久化条数，items 为交给刷新函数的条数
+	Persisted(persisted, items int)
+}
+
+// ShortFlushError 刷新函数未返回错误但实际持久化条数少于批次大小时上报，可通过 errors.As 获取
+type ShortFlushError struct {
+	Persisted int
+	Items     int
+}
+
+func (e *ShortFlushError) Error() string {
+	return fmt.Sprintf("short flush: persisted %d of %d items", e.Persisted, e.Items)
+}
+
+// persistCounters 计数管道的持久化累计计数（flush 协程并发写）
+type persistCounters struct {
+	persisted   atomic.Uint64
+	unpersisted atomic.Uint64
+}
+
+// NewCountedPipeline 使用返回实际持久化条数的刷新函数创建标准管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 返回实际持久化条数的刷新函数
+//
+// 返回值: 返回一个新的 StandardPipeline 实例
+// 说明:
+//   - 持久化条数计入 Stats().PersistedTotal，差额计入 Stats().UnpersistedTotal，并上报给实现了 PersistedHook 的指标钩子
+//   - persisted < len(batchData) 且刷新函数未返回错误时，按部分失败以 *ShortFlushError 上报错误通道；返回了错误时原样上报
+//   - persisted 超出 [0, len(batchData)] 时按边界截断；配置了 MaxFlushChunk 时按分片分别统计
+func NewCountedPipeline[T any](
+	config PipelineConfig,
+	flushFunc FlushCountedFunc[T],
+) *StandardPipeline[T] {
+	var p *StandardPipeline[T]
+	p = NewStandardPipeline(config, func(ctx context.Context, batchData []T) error {
+		persisted, err := flushFunc(ctx, batchData)
+		return p.recordPersisted(persisted, len(batchData), err)
+	})
+	return p
+}
+
+// recordPersisted 记录一次刷新函数调用的持久化条数，并在未报错的短写时返回 *ShortFlushError
+func (p *PipelineImpl[T]) recordPersisted(persisted, items int, err error) error {
+	if persisted < 0 {
+		persisted = 0
+	} else if persisted > items {
+		persisted = items
+	}
+	p.persist.persisted.Add(uint64(persisted))
+	p.persist.unpersisted.Add(uint64(items - persisted))
+	if h, ok := p.metrics.(PersistedHook); ok {
+		h.Persisted(persisted, items)
+	}
+	if err == nil && persisted < items {
+		return &ShortFlushError{Persisted: persisted, Items: items}
+	}
+	return err
+}
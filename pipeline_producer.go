@@ -0,0 +1,265 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"time"
+)
+
+// Add 将数据发送到管道（阻塞直到被接收进缓冲、ctx 结束或通道已关闭）
+// 启用内存护栏（MaxBufferedBytes + WithSizeOf）时，在途字节数超限也会阻塞，直到有批次 flush 完成释放额度
+// 参数:
+//   - ctx: 上下文对象，用于限制发送等待时长
+//   - data: 需要发送的数据
+//
+// 返回值（可用 errors.Is 区分）:
+//   - nil: 发送成功
+//   - ErrContextIsClosed: ctx 已取消/超时（同时包装 ctx.Err()）
+//   - ErrChannelIsClosed: 数据通道已被关闭（内部仅恢复发送时 send on closed channel 的 panic）
+//
+// 溢出 sink 与 WithSizeOf 估算函数中的 panic 不会被恢复，直接传播给调用方
+//
+// 说明: Add 只是 DataChan() 的便捷封装，不改变“写入方关闭通道”的约定；配置了 WithDeepCopy 时发送的是数据的拷贝；
+// 配置了 WithOverflowSink 时缓冲满不再阻塞，数据转交溢出 sink 并返回 nil；
+// 否则按 PipelineConfig.OverloadPolicy 处理缓冲满：DropOldest 丢弃最旧的一条后写入，DropNewest 丢弃新数据并返回 nil；
+// Block 策略下缓冲已满时的阻塞等待时长计入 Stats().BlockedDuration 并上报给可选的 AddBlockedHook
+func (p *PipelineImpl[T]) Add(ctx context.Context, data T) (err error) {
+	if p.deepCopy != nil {
+		data = p.deepCopy(data)
+	}
+	var reserved int64
+	var overflowed, dropped bool
+	defer func() {
+		if err != nil || overflowed || dropped {
+			p.releaseBytes(reserved)
+		}
+		if overflowed {
+			p.recordOverflow()
+			return
+		}
+		if dropped {
+			p.recordDrop(DropNewest)
+			return
+		}
+		p.recordAdd(err == nil)
+	}()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errors.Join(ErrContextIsClosed, ctxErr)
+	}
+	if g := p.memoryGuard(); g != nil {
+		n := int64(p.sizeOf(data))
+		for {
+			freed := g.waitChan()
+			if g.tryReserve(n) {
+				reserved = n
+				break
+			}
+			select {
+			case <-freed:
+			case <-ctx.Done():
+				return errors.Join(ErrContextIsClosed, ctx.Err())
+			}
+		}
+	}
+	if p.overflow != nil {
+		// 配置了溢出 sink：缓冲满时转交溢出路径而非阻塞
+		sent, err := p.trySend(data)
+		if err != nil || sent {
+			return err
+		}
+		overflowed = true
+		p.overflow(data)
+		return nil
+	}
+	switch p.config.OverloadPolicy {
+	case DropNewest:
+		sent, err := p.trySend(data)
+		dropped = err == nil && !sent
+		return err
+	case DropOldest:
+		if cap(p.dataChan) > 0 {
+			return p.addDropOldest(ctx, data)
+		}
+	}
+	// 快速路径：缓冲有空位时直接写入，不计时
+	if sent, err := p.trySend(data); err != nil || sent {
+		return err
+	}
+	start := time.Now()
+	defer func() { p.recordBlocked(time.Since(start)) }()
+	return p.send(ctx, data)
+}
+
+// trySend 非阻塞地向数据通道写入，返回是否写入成功；数据通道已关闭时返回 ErrChannelIsClosed
+func (p *PipelineImpl[T]) trySend(data T) (sent bool, err error) {
+	defer recoverClosedSend(&err)
+	select {
+	case p.dataChan <- data:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// send 阻塞地向数据通道写入，直到写入成功或 ctx 结束；数据通道已关闭时返回 ErrChannelIsClosed
+func (p *PipelineImpl[T]) send(ctx context.Context, data T) (err error) {
+	defer recoverClosedSend(&err)
+	select {
+	case p.dataChan <- data:
+		return nil
+	case <-ctx.Done():
+		return errors.Join(ErrContextIsClosed, ctx.Err())
+	}
+}
+
+// recoverClosedSend 将向已关闭数据通道发送引发的 panic 转换为 ErrChannelIsClosed，其他 panic 原样抛出
+// 只能直接以 defer 调用，且只包住发送本身，溢出 sink、WithSizeOf 等用户代码中的 panic 不会被吞掉
+func recoverClosedSend(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(runtime.Error); ok && e.Error() == "send on closed channel" {
+		*err = ErrChannelIsClosed
+		return
+	}
+	panic(r)
+}
+
+// addDropOldest 缓冲满时从数据通道取出并丢弃最旧的一条，直到新数据写入成功
+// 与主循环竞争接收是安全的：主循环先取走数据时直接重试写入即可
+func (p *PipelineImpl[T]) addDropOldest(ctx context.Context, data T) error {
+	for {
+		if sent, err := p.trySend(data); err != nil || sent {
+			return err
+		}
+		select {
+		case old, ok := <-p.dataChan:
+			if ok {
+				p.releaseBytes(p.itemBytes(old))
+				p.recordDrop(DropOldest)
+			}
+		case <-ctx.Done():
+			return errors.Join(ErrContextIsClosed, ctx.Err())
+		default:
+		}
+	}
+}
+
+// TryAdd 非阻塞地将数据发送到管道
+// 参数:
+//   - data: 需要发送的数据
+//
+// 返回值（可用 errors.Is 区分）:
+//   - nil: 发送成功
+//   - ErrBufferFull: 缓冲已满（无缓冲通道且主循环未在接收时同样返回该错误）；配置了 WithOverflowSink 时转交溢出 sink 并返回 nil
+//   - ErrMemoryLimit: 启用内存护栏时在途字节数已超限
+//   - ErrChannelIsClosed: 数据通道已被关闭
+//
+// 说明: 配置了 WithDeepCopy 时发送的是数据的拷贝
+func (p *PipelineImpl[T]) TryAdd(data T) (err error) {
+	if p.deepCopy != nil {
+		data = p.deepCopy(data)
+	}
+	var reserved int64
+	var overflowed bool
+	defer func() {
+		if err != nil || overflowed {
+			p.releaseBytes(reserved)
+		}
+		if overflowed {
+			p.recordOverflow()
+			return
+		}
+		p.recordAdd(err == nil)
+	}()
+	if g := p.memoryGuard(); g != nil {
+		n := int64(p.sizeOf(data))
+		if !g.tryReserve(n) {
+			return ErrMemoryLimit
+		}
+		reserved = n
+	}
+	if sent, err := p.trySend(data); err != nil || sent {
+		return err
+	}
+	if p.overflow != nil {
+		overflowed = true
+		p.overflow(data)
+		return nil
+	}
+	return ErrBufferFull
+}
+
+// AddChan 返回绑定 ctx 的发送通道，由后台协程经 Add 转发到数据通道，直到 ctx 结束
+// 生产者向返回的通道发送数据即可，转发时的阻塞与背压由后台协程处理
+// 说明:
+//   - 返回的通道无缓冲；转发经由 Add，WithDeepCopy、内存护栏、溢出 sink 与 OverloadPolicy 照常生效
+//   - ctx 结束、生产者关闭返回的通道或转发失败（如数据通道已关闭）时，后台协程退出；已接收但未能转发的那一条数据被丢弃
+//   - 后台协程的退出时刻对调用方不可见：ctx 结束后生产者必须停止发送（发送时应同时 select ctx.Done()），否则可能永久阻塞
+//   - 关闭返回的通道不会关闭数据通道，“写入方关闭”的约定不变；关闭数据通道前应确认已发送的数据均已转发（如通过 Stats().AddedTotal）
+func (p *PipelineImpl[T]) AddChan(ctx context.Context) chan<- T {
+	in := make(chan T)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data, ok := <-in:
+				if !ok {
+					return
+				}
+				if err := p.Add(ctx, data); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return in
+}
+
+// ConsumeFrom 阻塞地从 src 读取数据并经 Add 写入管道，直到 src 被关闭、ctx 结束或 Add 返回错误
+// 返回值:
+//   - nil: src 已关闭且数据均已写入；此时会关闭数据通道（代为执行“写入方关闭”），管道随后执行最终 flush 并退出
+//   - Add 返回的错误（如 ctx 结束时的 ErrContextIsClosed）：数据通道保持打开，已读取但未写入的那一条数据被丢弃
+//
+// 说明:
+//   - 多个管道可对同一个 src 各自调用 ConsumeFrom 分摊数据（见 NewWorkerGroup），每条数据只会被其中一个管道取得
+//   - 写入经由 Add，WithDeepCopy、内存护栏、溢出 sink 与 OverloadPolicy 照常生效；src 关闭后不应再经其他途径写入本管道
+func (p *PipelineImpl[T]) ConsumeFrom(ctx context.Context, src <-chan T) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Join(ErrContextIsClosed, ctx.Err())
+		case data, ok := <-src:
+			if !ok {
+				p.closeDataChan()
+				return nil
+			}
+			if err := p.Add(ctx, data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WithDeepCopy 注入深拷贝函数（可选），用于安全地传递指针或含共享底层存储的负载
+// Add/TryAdd 在发送前于生产者协程内调用该函数，管道与（异步）flush 只持有拷贝，
+// 生产者在发送后继续修改原对象不会与 flush 产生数据竞争。
+// 注意: 直接写 DataChan() 的数据不会被拷贝（拷贝必须发生在发送之前才能避免竞争）
+func (p *PipelineImpl[T]) WithDeepCopy(fn func(T) T) *PipelineImpl[T] {
+	p.deepCopy = fn
+	return p
+}
+
+// WithOverflowSink 注入溢出 sink（可选）：Add/TryAdd 遇到数据通道缓冲已满时，将数据交给 sink（如落盘）而非阻塞或拒绝
+// 说明:
+//   - sink 在生产者协程内同步调用，须并发安全且尽量快速；其耗时直接计入 Add/TryAdd，其中的 panic 直接传播给调用方
+//   - 溢出的数据不会再进入管道，由 sink 负责后续处理（如稍后重放）；Stats().OverflowTotal 统计溢出条数
+//   - 内存护栏超限（ErrMemoryLimit）、ctx 取消与通道已关闭仍按原语义返回错误，不走溢出路径
+//   - 无缓冲通道（BufferSize == 0）下主循环未在接收即视为已满，绝大多数数据会进入溢出路径
+func (p *PipelineImpl[T]) WithOverflowSink(sink func(T)) *PipelineImpl[T] {
+	p.overflow = sink
+	return p
+}
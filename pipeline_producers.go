@@ -0,0 +1,106 @@
+package gopipeline
+
+import "sync"
+
+// producerRegistry 生产者登记状态（RegisterProducer）
+type producerRegistry struct {
+	mu         sync.Mutex
+	count      int
+	gone       chan struct{} // 在途生产者数降为 0 时关闭
+	goneClosed bool
+	onGone     func()
+	autoClose  bool
+	closed     bool // 已自动关闭数据通道
+}
+
+// RegisterProducer 登记一个生产者，返回其注销函数（可选的生产者生命周期跟踪）
+// 生产者应在开始写入前登记、在最后一次写入完成后调用注销函数；注销函数可重复调用，仅首次生效
+// 说明:
+//   - 在途生产者数从正数降为 0 时关闭 ProducersDone 返回的通道，并调用 WithOnProducersGone 注入的回调
+//   - 启用 WithAutoCloseOnProducersGone 时随之关闭数据通道，管道按关闭路径最终 flush 后退出；
+//     此时所有写入方都必须登记，否则未登记的生产者写入已关闭的通道会 panic（Add/TryAdd 返回 ErrChannelIsClosed）
+//   - 降为 0 后再次登记会开启新一轮跟踪（自动关闭只发生一次）
+func (p *PipelineImpl[T]) RegisterProducer() func() {
+	r := &p.producers
+	r.mu.Lock()
+	if r.count == 0 && r.goneClosed {
+		r.gone, r.goneClosed = nil, false
+	}
+	r.count++
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(p.deregisterProducer)
+	}
+}
+
+// ActiveProducers 返回当前已登记且尚未注销的生产者数
+func (p *PipelineImpl[T]) ActiveProducers() int {
+	p.producers.mu.Lock()
+	defer p.producers.mu.Unlock()
+	return p.producers.count
+}
+
+// ProducersDone 返回在途生产者数降为 0 时关闭的通道
+// 尚无生产者登记时通道保持打开；降为 0 后、再次登记前返回已关闭的通道
+func (p *PipelineImpl[T]) ProducersDone() <-chan struct{} {
+	r := &p.producers
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gone == nil {
+		r.gone = make(chan struct{})
+	}
+	return r.gone
+}
+
+// WithOnProducersGone 注入在途生产者数降为 0 时的回调（可选），在最后一个注销的生产者协程内调用
+func (p *PipelineImpl[T]) WithOnProducersGone(fn func()) *PipelineImpl[T] {
+	p.producers.mu.Lock()
+	p.producers.onGone = fn
+	p.producers.mu.Unlock()
+	return p
+}
+
+// WithAutoCloseOnProducersGone 设置在途生产者数降为 0 时是否自动关闭数据通道（默认 false）
+func (p *PipelineImpl[T]) WithAutoCloseOnProducersGone(enabled bool) *PipelineImpl[T] {
+	p.producers.mu.Lock()
+	p.producers.autoClose = enabled
+	p.producers.mu.Unlock()
+	return p
+}
+
+// deregisterProducer 注销一个生产者；降为 0 时发出信号，并按需关闭数据通道
+func (p *PipelineImpl[T]) deregisterProducer() {
+	r := &p.producers
+	r.mu.Lock()
+	r.count--
+	if r.count > 0 {
+		r.mu.Unlock()
+		return
+	}
+	if r.gone == nil {
+		r.gone = make(chan struct{})
+	}
+	close(r.gone)
+	r.goneClosed = true
+	onGone := r.onGone
+	closeData := r.autoClose && !r.closed
+	if closeData {
+		r.closed = true
+	}
+	r.mu.Unlock()
+
+	if closeData {
+		p.closeDataChan()
+	}
+	if onGone != nil {
+		onGone()
+	}
+}
+
+// closeDataChan 关闭数据通道；通道已被写入方关闭属于正常路径（如 Close 在写入方关闭之后调用），静默忽略
+func (p *PipelineImpl[T]) closeDataChan() {
+	defer func() { _ = recover() }()
+	close(p.dataChan)
+}
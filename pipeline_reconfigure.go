@@ -0,0 +1,73 @@
+package gopipeline
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// hotSwappableFields 可在运行中通过 Reconfigure 即时生效的配置字段
+var hotSwappableFields = map[string]bool{
+	"FlushSize":            true,
+	"FlushInterval":        true,
+	"MaxConcurrentFlushes": true,
+}
+
+// Config 返回管道当前生效的配置：构造时（规范化后）的配置，FlushSize/FlushInterval/MaxConcurrentFlushes 取运行期的当前值
+// 常与 Reconfigure 搭配使用：p.Reconfigure(p.Config().WithFlushSize(200))
+// 与 Reconfigure 互斥，不会读到两次 Reconfigure 各自的一部分；与直接调用 UpdateXxx/SetMaxConcurrentFlushes 之间不保证成组一致
+func (p *PipelineImpl[T]) Config() PipelineConfig {
+	p.reconfMu.Lock()
+	defer p.reconfMu.Unlock()
+	c := p.config
+	c.FlushSize, c.FlushInterval = p.CurrentTuning()
+	c.MaxConcurrentFlushes = uint32(p.MaxConcurrentFlushes())
+	return c
+}
+
+// Reconfigure 以整份配置为入口原子地应用运行期可变的参数
+// 参数:
+//   - config: 期望的完整配置（通常由 Config() 修改得到），按 ValidateOrDefault 规范化后比较
+//
+// 返回值:
+//   - nil: 已应用（FlushSize 与 FlushInterval 成组更新，语义同 UpdateTuning；MaxConcurrentFlushes 经 SetMaxConcurrentFlushes 生效）
+//   - ErrRestartRequired: config 修改了不可热更新的字段（错误信息列出字段名），此时不应用任何修改
+//   - ErrInvalidConfig: StrictConfig 下新配置未通过 Validate
+//
+// 可热更新: FlushSize、FlushInterval、MaxConcurrentFlushes
+// 需重建管道: 其余字段，包括 BufferSize（数据通道容量在构造时确定）、MaxBufferedBytes 以及各类行为开关；
+// 比较基准为构造时的配置，运行中通过 UpdateXxx/SetMaxConcurrentFlushes 修改过的可热更新字段不影响比较
+// 线程安全，可在运行中调用；并发的 Reconfigure 之间串行执行，不会交错出一次的 FlushSize/FlushInterval 与另一次的 MaxConcurrentFlushes
+func (p *PipelineImpl[T]) Reconfigure(config PipelineConfig) error {
+	p.reconfMu.Lock()
+	defer p.reconfMu.Unlock()
+	config = config.ValidateOrDefault()
+	if changed := coldFieldChanges(p.config, config); len(changed) > 0 {
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(changed, ", "))
+	}
+	if err := config.Validate(); err != nil {
+		if p.config.StrictConfig {
+			return err
+		}
+		p.logPrintln("pipeline config warning: ", err)
+	}
+	p.UpdateTuning(config.FlushSize, config.FlushInterval)
+	p.SetMaxConcurrentFlushes(int(config.MaxConcurrentFlushes))
+	return nil
+}
+
+// coldFieldChanges 返回两份配置中取值不同的不可热更新字段名（按字段声明顺序）
+func coldFieldChanges(cur, next PipelineConfig) []string {
+	var changed []string
+	cv, nv := reflect.ValueOf(cur), reflect.ValueOf(next)
+	for i := 0; i < cv.NumField(); i++ {
+		name := cv.Type().Field(i).Name
+		if hotSwappableFields[name] {
+			continue
+		}
+		if cv.Field(i).Interface() != nv.Field(i).Interface() {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,74 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// ReduceFunc 将一个批次并入累计结果，返回新的累计结果
+type ReduceFunc[T any, A any] func(acc A, batchData []T) A
+
+// ReducingPipeline 将每个 flush 的批次归约到累计结果的管道（带批处理的流式 fold）
+type ReducingPipeline[T any, A any] struct {
+	*StandardPipeline[T]
+	reduce ReduceFunc[T, A]
+
+	mu  sync.Mutex
+	acc A
+	// results 容量为 1，仅保留最新的累计结果
+	results chan A
+}
+
+// NewReducingPipeline 使用自定义配置创建一个归约管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - initial: 累计结果的初值
+//   - reduce: 归约函数，每个非空批次调用一次
+//
+// 返回值: 返回一个新的 ReducingPipeline 实例
+// 说明:
+//   - 归约在互斥锁保护下串行执行；异步 flush 时批次的归约顺序不确定，需要按输入顺序归约时请使用 SyncPerform
+//   - 空批次（心跳）不调用 reduce，配置了 MaxFlushChunk 时按分片调用；reduce 不应持有 batchData，累计结果中若引用了批次数据请自行复制
+//   - 每次归约后将新结果写入 Results() 返回的通道：通道只保留最新值，消费方未及时读取时旧值被替换
+func NewReducingPipeline[T any, A any](
+	config PipelineConfig,
+	initial A,
+	reduce ReduceFunc[T, A],
+) *ReducingPipeline[T, A] {
+	p := &ReducingPipeline[T, A]{
+		reduce:  reduce,
+		acc:     initial,
+		results: make(chan A, 1),
+	}
+	p.StandardPipeline = NewStandardPipeline(config, p.flushReduce)
+	return p
+}
+
+// flushReduce 将批次并入累计结果并发布最新值
+func (p *ReducingPipeline[T, A]) flushReduce(ctx context.Context, batchData []T) error {
+	if len(batchData) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.acc = p.reduce(p.acc, batchData)
+	// 持锁替换通道中的旧值，保证通道中始终是最新结果
+	select {
+	case <-p.results:
+	default:
+	}
+	p.results <- p.acc
+	return nil
+}
+
+// Result 返回当前的累计结果
+func (p *ReducingPipeline[T, A]) Result() A {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.acc
+}
+
+// Results 返回发布累计结果的通道：每次归约后写入最新值，未读取的旧值会被替换；通道不会被关闭
+func (p *ReducingPipeline[T, A]) Results() <-chan A {
+	return p.results
+}
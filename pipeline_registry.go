@@ -0,0 +1,61 @@
+package gopipeline
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StandardProcessor 内置的处理器名称：未注册同名工厂时，NewFromRegistry 以 NewStandardPipeline 构造
+const StandardProcessor = "standard"
+
+// ProcessorFactory 按配置与刷新函数构造管道的工厂
+// DataProcessor 的方法不对外导出，自定义处理器通过组合本包的构造函数与 WithXxx 选项实现，
+// 例如 NewStandardPipeline(config, flush).WithRetryQueue(...)
+type ProcessorFactory[T any] func(config PipelineConfig, flush FlushStandardFunc[T]) *PipelineImpl[T]
+
+// processorRegistry 按名称登记的处理器工厂（值为各自数据类型的 ProcessorFactory[T]）
+var processorRegistry = struct {
+	sync.RWMutex
+	factories map[string]any
+}{factories: make(map[string]any)}
+
+// RegisterProcessor 以名称登记处理器工厂，供 NewFromRegistry 按名称（如来自配置文件）组装管道
+// 说明:
+//   - 通常在 init 中调用；线程安全
+//   - 与 database/sql.Register 一致：名称为空、工厂为 nil 或名称重复时 panic
+//   - 登记时绑定数据类型 T，按名称查找时数据类型须一致
+func RegisterProcessor[T any](name string, factory ProcessorFactory[T]) {
+	if name == "" || factory == nil {
+		panic("gopipeline: RegisterProcessor with empty name or nil factory")
+	}
+	processorRegistry.Lock()
+	defer processorRegistry.Unlock()
+	if _, dup := processorRegistry.factories[name]; dup {
+		panic("gopipeline: RegisterProcessor called twice for " + name)
+	}
+	processorRegistry.factories[name] = factory
+}
+
+// NewFromRegistry 按名称查找已登记的处理器工厂并构造管道
+// 参数:
+//   - name: RegisterProcessor 登记的名称；未登记的 StandardProcessor 使用标准管道
+//   - config: 管道配置
+//   - flush: 刷新函数
+//
+// 返回值: 未登记该名称、或登记的数据类型与 T 不一致时返回包装了 ErrUnknownProcessor 的错误
+func NewFromRegistry[T any](name string, config PipelineConfig, flush FlushStandardFunc[T]) (*PipelineImpl[T], error) {
+	processorRegistry.RLock()
+	f, ok := processorRegistry.factories[name]
+	processorRegistry.RUnlock()
+	if !ok {
+		if name == StandardProcessor {
+			return NewStandardPipeline(config, flush).PipelineImpl, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProcessor, name)
+	}
+	factory, ok := f.(ProcessorFactory[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is registered for a different data type", ErrUnknownProcessor, name)
+	}
+	return factory(config, flush), nil
+}
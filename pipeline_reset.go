@@ -0,0 +1,42 @@
+package gopipeline
+
+// ResetFunc 在同步 flush 完成后清空批容器以供下一批次复用，返回可继续累计的空容器
+// 参数 container 为刚 flush 完成的批容器（标准管道为 []T，去重管道为 map[string]T，自定义容器管道为 C）
+type ResetFunc func(container any) any
+
+// batchReuser 可选：支持在同步 flush 后就地清空批容器的处理器（PipelineConfig.UseMapReuse）
+type batchReuser interface {
+	reuseBatchData(batchData any) any
+}
+
+// WithResetFunc 注入批容器重置函数（可选），用复用代替每次 flush 后的重新分配
+// 说明:
+//   - 仅在同步 flush 路径（SyncPerform、关闭/取消收尾的最终 flush 等）上生效：flush 返回后以 fn(旧容器) 作为新批次的容器；
+//     异步 flush 时旧容器仍被 flush 协程持有，总是调用 initBatchData 创建新容器
+//   - 启用重试队列（WithRetryQueue）时不复用：失败批次会被重试队列持有并稍后重放
+//   - flush 函数、DeadLetterFunc 与 MetricsHook 不得在返回后继续持有批容器或其元素所在的底层数组；
+//     WithSkipIdenticalBatches 会保留上一批次作为比较基准，不要与本选项同时使用
+//   - 示例：切片可返回 s[:0]，map 可逐个 delete 后返回原 map；fn 返回 nil 时退回 initBatchData
+//   - 优先于 PipelineConfig.UseMapReuse
+func (p *PipelineImpl[T]) WithResetFunc(fn ResetFunc) *PipelineImpl[T] {
+	p.resetFunc = fn
+	p.zeroCopyReset = false
+	return p
+}
+
+// nextBatchData 返回 flush 之后用于累计下一批次的容器：同步且可安全复用时重置旧容器，否则新建
+func (p *PipelineImpl[T]) nextBatchData(async bool, flushed any) any {
+	if async || p.retry != nil {
+		return p.processor.initBatchData()
+	}
+	if p.resetFunc != nil {
+		if c := p.resetFunc(flushed); c != nil {
+			return c
+		}
+	} else if p.config.UseMapReuse {
+		if r, ok := p.processor.(batchReuser); ok {
+			return r.reuseBatchData(flushed)
+		}
+	}
+	return p.processor.initBatchData()
+}
@@ -0,0 +1,116 @@
+package gopipeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ItemResidenceHook 是 MetricsHook 的可选扩展
+// 启用 WithResidenceTracking 且注入的 MetricsHook 实现了该接口时，每条数据在 flush 触发时上报其在批次中的驻留时长
+type ItemResidenceHook interface {
+	// ItemResidence 上报单条数据从进入批次到 flush 触发的时长
+	ItemResidence(d time.Duration)
+}
+
+// FillDurationHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每次 flush 触发时上报当前批次的组批耗时（首条数据入批 → flush 触发）
+type FillDurationHook interface {
+	// FillDuration 上报一个批次从首条数据入批到 flush 触发的时长
+	FillDuration(d time.Duration)
+}
+
+// ResidenceStats 批内驻留时长（入批 → flush 触发）的累计统计
+type ResidenceStats struct {
+	// Count 观测的数据条数
+	Count uint64
+	// Total 驻留时长总和
+	Total time.Duration
+	// Max 观测到的最大驻留时长
+	Max time.Duration
+}
+
+// Mean 返回平均驻留时长（无观测时为 0）
+func (s ResidenceStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// residenceCounters 驻留时长统计的原子计数器（主循环单写，任意协程可读）
+type residenceCounters struct {
+	count atomic.Uint64
+	total atomic.Int64
+	max   atomic.Int64
+}
+
+// WithResidenceTracking 启用批内驻留时长观测（可选）
+// 开启后主循环为每条数据记录入批时间，在 flush 触发时观测 now - 入批时间，
+// 经 ResidenceStats() 与可选的 ItemResidenceHook 暴露，用于区分“组批等待延迟”与“flush 执行延迟”。
+// 注意:
+//   - 计时起点为主循环从数据通道取出数据的时刻，不包含在通道缓冲中排队的时间；
+//     背压下通道排队往往占主导，此时应结合 Stats().BlockedDuration 与缓冲占用率判断端到端延迟
+//   - 每条数据额外一次 time.Now() 与时间戳存储，默认关闭
+func (p *PipelineImpl[T]) WithResidenceTracking(enabled bool) *PipelineImpl[T] {
+	p.residenceTracking = enabled
+	return p
+}
+
+// ResidenceStats 返回批内驻留时长的累计统计快照（未启用 WithResidenceTracking 时为零值）
+func (p *PipelineImpl[T]) ResidenceStats() ResidenceStats {
+	return ResidenceStats{
+		Count: p.residences.count.Load(),
+		Total: time.Duration(p.residences.total.Load()),
+		Max:   time.Duration(p.residences.max.Load()),
+	}
+}
+
+// observeBatchResidence 在 flush 触发时观测当前批次内每条数据的驻留时长，并清空时间戳
+func (p *PipelineImpl[T]) observeBatchResidence(st *batchState) {
+	if !p.residenceTracking || len(st.stamps) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, ts := range st.stamps {
+		p.observeItemResidence(now.Sub(ts))
+	}
+	st.stamps = st.stamps[:0]
+}
+
+// observeItemResidence 记录单条数据的驻留时长
+func (p *PipelineImpl[T]) observeItemResidence(d time.Duration) {
+	if !p.residenceTracking {
+		return
+	}
+	p.residences.count.Add(1)
+	p.residences.total.Add(int64(d))
+	for {
+		old := p.residences.max.Load()
+		if int64(d) <= old || p.residences.max.CompareAndSwap(old, int64(d)) {
+			break
+		}
+	}
+	if p.itemResidence != nil {
+		p.itemResidence.ItemResidence(d)
+	}
+}
+
+// observeBatchFill 在 flush 触发时观测当前批次的组批耗时并清除开批时间（空批次如心跳 flush 不计）
+// 与 flush 耗时配合可区分“组批慢”（受生产者速率限制）与“flush 慢”（受下游限制）
+func (p *PipelineImpl[T]) observeBatchFill(st *batchState) {
+	if st.openedAt.IsZero() {
+		return
+	}
+	d := time.Since(st.openedAt)
+	st.openedAt = time.Time{}
+	p.recordFill(d)
+}
+
+// recordFill 记录一个批次的组批耗时
+func (p *PipelineImpl[T]) recordFill(d time.Duration) {
+	p.fills.count.Add(1)
+	p.fills.nanos.Add(int64(d))
+	if h, ok := p.metrics.(FillDurationHook); ok {
+		h.FillDuration(d)
+	}
+}
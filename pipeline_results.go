@@ -0,0 +1,54 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// FlushResultsFunc 带结果缓冲区的刷新函数
+// out 是长度为 0、容量不小于 FlushSize 的池化切片，flush 函数向其追加本批次的结果并返回（扩容后的切片同样可以返回）；
+// 返回的切片会被清零并放回池中复用，flush 函数不得在返回后继续持有 out 或其返回值
+type FlushResultsFunc[T any, R any] func(ctx context.Context, batchData []T, out []R) ([]R, error)
+
+// maxPooledResultsFactor 放回池中的结果缓冲区容量上限（FlushSize 的倍数），超过的缓冲区直接丢弃
+const maxPooledResultsFactor = 4
+
+// NewResultsPipeline 使用自定义配置创建一个标准管道实例，每次调用刷新函数时借出一个池化的结果缓冲区
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 带结果缓冲区的刷新函数
+//
+// 返回值: 返回一个新的 StandardPipeline 实例
+// 说明:
+//   - 每次调用 flushFunc（配置了 MaxFlushChunk 时为每个分片）都会取得一个已重置的 out，并发 flush 各自持有独立的缓冲区
+//   - 用于每批次都要构建结果切片的 flush（如转换为写入行），减少每批次的切片分配
+//   - 缓冲区初始容量按创建时的 FlushSize 分配；运行期间调大 FlushSize 后由 append 自然扩容，
+//     容量超过 4 倍 FlushSize 的缓冲区不再放回池中
+func NewResultsPipeline[T any, R any](
+	config PipelineConfig,
+	flushFunc FlushResultsFunc[T, R],
+) *StandardPipeline[T] {
+	size := int(config.ValidateOrDefault().FlushSize)
+	pool := &sync.Pool{New: func() any {
+		out := make([]R, 0, size)
+		return &out
+	}}
+	return NewStandardPipeline(config, func(ctx context.Context, batchData []T) error {
+		buf := pool.Get().(*[]R)
+		out, err := flushFunc(ctx, batchData, (*buf)[:0])
+		if out == nil {
+			// 未返回缓冲区（如出错提前返回）：按原容量整体清零后放回
+			out = (*buf)[:cap(*buf)]
+		}
+		if cap(out) <= maxPooledResultsFactor*size {
+			// 清零已使用的元素，避免池中的缓冲区继续引用上一批次的结果
+			var zero R
+			for i := range out {
+				out[i] = zero
+			}
+			*buf = out[:0]
+			pool.Put(buf)
+		}
+		return err
+	})
+}
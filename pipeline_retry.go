@@ -0,0 +1,197 @@
+package gopipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DeadLetterFunc 处理最终无法成功 flush 的批次（超过最大重试次数或重试队列已满）
+// 参数:
+//   - ctx: 上下文对象
+//   - batchData: 失败的批次（标准管道为 []T，去重管道为 map[string]T）
+//   - err: 最后一次 flush 返回的错误
+type DeadLetterFunc func(ctx context.Context, batchData any, err error)
+
+// ErrorClass flush 错误的分类，决定失败批次是否值得重试
+type ErrorClass uint8
+
+const (
+	// Transient 暂时性错误（如超时、限流）：进入重试队列按退避重放
+	Transient ErrorClass = iota
+	// Permanent 永久性错误（如数据非法）：不重试，直接交给死信处理
+	Permanent
+)
+
+// NewErrorClassifier 返回一个基础的错误分类函数：context.Canceled/DeadlineExceeded 视为 Transient，
+// 其余错误归为 fallback；可作为 WithErrorClassifier 的参数，或在自定义分类函数中兜底调用
+func NewErrorClassifier(fallback ErrorClass) func(error) ErrorClass {
+	return func(err error) ErrorClass {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return Transient
+		}
+		return fallback
+	}
+}
+
+// retryEntry 记录一个待重放的失败批次及其尝试次数
+type retryEntry struct {
+	batch    any
+	attempts int       // 已尝试的 flush 次数（含首次）
+	lastErr  error     // 最近一次失败的错误
+	nextAt   time.Time // 下次允许重放的时间点
+}
+
+// maxRetryBackoffShift 退避指数的上限（interval*2^10）
+const maxRetryBackoffShift = 10
+
+// retryQueue 有界的内存重试队列
+// 由后台协程按 interval 轮询，到期条目按指数退避重放；队列为空时协程自动退出
+type retryQueue struct {
+	depth       int
+	interval    time.Duration
+	maxAttempts int
+
+	mu      sync.Mutex
+	pending []*retryEntry
+	active  bool // 后台重放协程是否在运行
+}
+
+// WithRetryQueue 启用失败批次的有界重试队列（可选）
+// 参数:
+//   - depth: 队列容量；队列已满时新的失败批次直接进入死信处理
+//   - interval: 重放轮询间隔，同时作为退避基数（第 n 次重放前等待 interval*2^(n-1)）
+//   - maxAttempts: 单个批次的最大 flush 次数（含首次）；达到后进入死信处理
+//
+// 说明:
+//   - 重试完全在内存中进行，进程退出时未完成的重试会丢失
+//   - 每次失败的 flush 仍会通过 ErrorChan/MetricsHook 上报
+//   - 重放使用 context.Background()，不受 Perform 的 ctx 取消影响，管道停止后仍会继续直至队列清空
+func (p *PipelineImpl[T]) WithRetryQueue(depth int, interval time.Duration, maxAttempts int) *PipelineImpl[T] {
+	if depth <= 0 {
+		depth = 1
+	}
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	p.retry = &retryQueue{depth: depth, interval: interval, maxAttempts: maxAttempts}
+	return p
+}
+
+// WithDeadLetter 注入死信处理函数（可选），用于接收最终失败的批次
+func (p *PipelineImpl[T]) WithDeadLetter(fn DeadLetterFunc) *PipelineImpl[T] {
+	p.deadLetter = fn
+	return p
+}
+
+// WithErrorClassifier 注入 flush 错误分类函数（可选），集中决定重试策略
+// 启用重试队列时：Transient 错误进入重试队列，Permanent 错误直接交给死信处理（首次失败与重放失败均适用）
+// 未设置时所有错误均视为 Transient（与之前行为一致）；未启用重试队列时分类不生效
+func (p *PipelineImpl[T]) WithErrorClassifier(fn func(error) ErrorClass) *PipelineImpl[T] {
+	p.classify = fn
+	return p
+}
+
+// classifyError 对 flush 错误分类（未设置分类函数时为 Transient）
+func (p *PipelineImpl[T]) classifyError(err error) ErrorClass {
+	if p.classify == nil {
+		return Transient
+	}
+	return p.classify(err)
+}
+
+// PendingRetries 返回当前重试队列中等待重放的批次数（未启用重试队列时恒为 0）
+func (p *PipelineImpl[T]) PendingRetries() int {
+	if p.retry == nil {
+		return 0
+	}
+	p.retry.mu.Lock()
+	defer p.retry.mu.Unlock()
+	return len(p.retry.pending)
+}
+
+// enqueueRetry 将失败批次放入重试队列；超过最大尝试次数或队列已满时转入死信处理
+func (p *PipelineImpl[T]) enqueueRetry(e *retryEntry) {
+	q := p.retry
+	if e.attempts >= q.maxAttempts {
+		p.sendDeadLetter(e)
+		return
+	}
+	// 指数退避：第 n 次重放前等待 interval*2^(n-1)（指数上限 maxRetryBackoffShift，防止溢出）
+	shift := e.attempts - 1
+	if shift > maxRetryBackoffShift {
+		shift = maxRetryBackoffShift
+	}
+	e.nextAt = time.Now().Add(q.interval << shift)
+
+	q.mu.Lock()
+	if len(q.pending) >= q.depth {
+		q.mu.Unlock()
+		p.sendDeadLetter(e)
+		return
+	}
+	q.pending = append(q.pending, e)
+	start := !q.active
+	q.active = true
+	q.mu.Unlock()
+
+	if start {
+		go p.retryLoop()
+	}
+}
+
+// retryLoop 后台重放协程：按 interval 轮询到期条目并串行重放，队列清空后退出
+func (p *PipelineImpl[T]) retryLoop() {
+	q := p.retry
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		q.mu.Lock()
+		var due []*retryEntry
+		kept := q.pending[:0]
+		for _, e := range q.pending {
+			if now.Before(e.nextAt) {
+				kept = append(kept, e)
+			} else {
+				due = append(due, e)
+			}
+		}
+		q.pending = kept
+		q.mu.Unlock()
+
+		for _, e := range due {
+			e.attempts++
+			if err, _ := p.flushAndReport(context.Background(), e.batch); err != nil {
+				e.lastErr = err
+				if errors.Is(err, ErrStopPipeline) || p.classifyError(err) == Permanent {
+					// 下游已永久不可用或错误不可重试：不再重放
+					p.sendDeadLetter(e)
+					continue
+				}
+				p.enqueueRetry(e)
+			}
+		}
+
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.active = false
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+// sendDeadLetter 将最终失败的批次交给死信处理函数；未配置时丢弃
+func (p *PipelineImpl[T]) sendDeadLetter(e *retryEntry) {
+	if p.deadLetter == nil {
+		return
+	}
+	p.deadLetter(context.Background(), e.batch, e.lastErr)
+}
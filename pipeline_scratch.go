@@ -0,0 +1,46 @@
+package gopipeline
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// Scratch flush 函数可复用的临时缓冲区（如序列化批次），由管道从 sync.Pool 取出并在交给 flush 前重置
+// 内嵌 bytes.Buffer，可直接作为 io.Writer 传给 json.NewEncoder 等编码器
+type Scratch struct {
+	bytes.Buffer
+}
+
+// FlushScratchFunc 带临时缓冲区的刷新函数
+// scratch 仅在本次调用期间有效，返回后会被放回池中复用，flush 函数不得在返回后继续持有 scratch 或 scratch.Bytes()
+type FlushScratchFunc[T any] func(ctx context.Context, batchData []T, scratch *Scratch) error
+
+// maxPooledScratch 放回池中的临时缓冲区容量上限，超过的缓冲区直接丢弃，避免偶发的大批次长期占用内存
+const maxPooledScratch = 1 << 20
+
+// NewScratchPipeline 使用自定义配置创建一个标准管道实例，每次调用刷新函数时附带一个池化的临时缓冲区
+// 参数:
+//   - config: 自定义的管道配置
+//   - flushFunc: 带临时缓冲区的刷新函数
+//
+// 返回值: 返回一个新的 StandardPipeline 实例
+// 说明:
+//   - 每次调用 flushFunc（配置了 MaxFlushChunk 时为每个分片）都会取得一个已重置的 Scratch，并发 flush 各自持有独立的缓冲区
+//   - 用于序列化开销大的 flush，减少每批次的缓冲区分配
+func NewScratchPipeline[T any](
+	config PipelineConfig,
+	flushFunc FlushScratchFunc[T],
+) *StandardPipeline[T] {
+	pool := &sync.Pool{New: func() any { return new(Scratch) }}
+	return NewStandardPipeline(config, func(ctx context.Context, batchData []T) error {
+		s := pool.Get().(*Scratch)
+		s.Reset()
+		defer func() {
+			if s.Cap() <= maxPooledScratch {
+				pool.Put(s)
+			}
+		}()
+		return flushFunc(ctx, batchData, s)
+	})
+}
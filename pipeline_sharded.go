@@ -0,0 +1,126 @@
+package gopipeline
+
+import (
+	"context"
+	"fmt"
+)
+
+// FlushShardedFunc 处理某个分片的一个批次
+// 参数:
+//   - ctx: 上下文对象
+//   - shard: 分片下标，取值 [0, n)
+//   - batchData: 该分片本次的批处理数据
+type FlushShardedFunc[T any] func(ctx context.Context, shard int, batchData []T) error
+
+// ShardFlushError 记录某个分片 flush 失败的错误，可通过 errors.As 获取失败的分片下标
+type ShardFlushError struct {
+	Shard int
+	Err   error
+}
+
+func (e *ShardFlushError) Error() string {
+	return fmt.Sprintf("shard flush failed (shard=%d): %v", e.Shard, e.Err)
+}
+
+func (e *ShardFlushError) Unwrap() error {
+	return e.Err
+}
+
+// ShardedPipeline 按分片路由的管道
+// 内部由 n 个独立的标准管道组成，数据按 shardFn 路由到对应分片的批次，
+// 每个分片各自按 FlushSize/FlushInterval 独立 flush，适用于 N 个数据库分片等分片下游
+type ShardedPipeline[T any] struct {
+	group   pipelineGroup[T]
+	shardFn func(T) int
+}
+
+// NewShardedPipeline 使用自定义配置创建一个按分片路由的管道实例
+// 参数:
+//   - n: 分片数（<=0 时为 1）
+//   - config: 每个分片管道的配置（各分片各自拥有 BufferSize 大小的缓冲）
+//   - shardFn: 计算数据所属分片的函数，结果按 n 取模（负数同样映射到 [0, n)）；按字符串键一致性路由可使用 ShardByKey
+//   - flushFunc: 携带分片下标的刷新函数
+//
+// 返回值: 返回一个新的 ShardedPipeline 实例
+func NewShardedPipeline[T any](
+	n int,
+	config PipelineConfig,
+	shardFn func(T) int,
+	flushFunc FlushShardedFunc[T],
+) *ShardedPipeline[T] {
+	if n <= 0 {
+		n = 1
+	}
+	shards := make([]*StandardPipeline[T], n)
+	for i := range shards {
+		shard := i
+		shards[i] = NewStandardPipeline(config, func(ctx context.Context, batchData []T) error {
+			return flushFunc(ctx, shard, batchData)
+		})
+	}
+	return &ShardedPipeline[T]{
+		group: pipelineGroup[T]{
+			members: shards,
+			wrapErr: func(shard int, err error) error { return &ShardFlushError{Shard: shard, Err: err} },
+		},
+		shardFn: shardFn,
+	}
+}
+
+// ShardByKey 返回按字符串键哈希路由的分片函数（与 WithKeyHasher 的默认哈希相同，FNV-1a 64 位），相同键总是进入同一分片
+func ShardByKey[T any](keyFunc func(T) string) func(T) int {
+	return func(data T) int {
+		return int(defaultKeyHash(keyFunc(data)) >> 1)
+	}
+}
+
+// Shards 返回分片数
+func (p *ShardedPipeline[T]) Shards() int {
+	return len(p.group.members)
+}
+
+// Shard 返回第 i 个分片的管道，用于按分片调参或读取统计
+func (p *ShardedPipeline[T]) Shard(i int) *StandardPipeline[T] {
+	return p.group.members[i]
+}
+
+// shardOf 计算数据所属的分片
+func (p *ShardedPipeline[T]) shardOf(data T) *StandardPipeline[T] {
+	n := len(p.group.members)
+	return p.group.members[(p.shardFn(data)%n+n)%n]
+}
+
+// Add 将数据发送到其所属分片（语义同 PipelineImpl.Add）
+func (p *ShardedPipeline[T]) Add(ctx context.Context, data T) error {
+	return p.shardOf(data).Add(ctx, data)
+}
+
+// TryAdd 非阻塞地将数据发送到其所属分片（语义同 PipelineImpl.TryAdd）
+func (p *ShardedPipeline[T]) TryAdd(data T) error {
+	return p.shardOf(data).TryAdd(data)
+}
+
+// Close 关闭所有分片的数据通道，各分片执行最终 flush 后退出
+// 说明: 数据只能经 Add/TryAdd 写入，因此由 ShardedPipeline 代为执行“写入方关闭”；调用后 Add 返回 ErrChannelIsClosed。
+// 重复调用是幂等的
+func (p *ShardedPipeline[T]) Close() {
+	for _, s := range p.group.members {
+		s.closeDataChan()
+	}
+}
+
+// ErrorChan 返回汇总所有分片错误的只读通道，错误以 *ShardFlushError 包装
+// 线程安全、幂等：“首次调用决定缓冲大小”（<=0 时为各分片默认容量之和），后续调用忽略 size
+func (p *ShardedPipeline[T]) ErrorChan(size int) <-chan error {
+	return p.group.errorChan(size)
+}
+
+// Start 异步启动所有分片，返回所有分片都退出后关闭的 done 与汇总错误通道
+func (p *ShardedPipeline[T]) Start(ctx context.Context) (<-chan struct{}, <-chan error) {
+	return p.group.start(ctx, nil)
+}
+
+// Done 返回最近一次 Start 的完成信号（尚未 Start 时为 nil）
+func (p *ShardedPipeline[T]) Done() <-chan struct{} {
+	return p.group.doneChan()
+}
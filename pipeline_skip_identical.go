@@ -0,0 +1,76 @@
+package gopipeline
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// FlushSkippedHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每当批次因与上一次 flush 相同而被跳过时调用（WithSkipIdenticalBatches）
+type FlushSkippedHook interface {
+	// FlushSkipped 上报一次被跳过的 flush；items 为被跳过批次的大小
+	FlushSkipped(items int)
+}
+
+// identicalSkip 相同批次跳过的状态（flush 可能并发执行，prev 由 mu 保护）
+type identicalSkip[T any] struct {
+	equal   func(prev, cur []T) bool
+	mu      sync.Mutex
+	prev    []T
+	hasPrev bool
+	skipped atomic.Uint64
+}
+
+// WithSkipIdenticalBatches 跳过与上一次成功 flush 的批次相同的批次（可选）
+// 参数:
+//   - equal: 比较上一次成功 flush 的批次与当前批次，返回 true 时跳过本次 flush；nil 表示关闭
+//
+// 说明:
+//   - 适用于变化缓慢的遥测数据，减少对下游的重复写入；每次 flush 都要做一次比较，请按比较开销权衡
+//   - 只与最近一次成功的 flush 比较；失败的批次不会成为比较基准，空批次（心跳）总是照常 flush
+//   - 被跳过的批次计入 SkippedFlushes() 并上报给实现了 FlushSkippedHook 的 MetricsHook；MetricsHook.Flush 仍会以近 0 的耗时被调用
+//   - 管道保留上一批次的引用作为比较基准，flush 函数不应修改批次内容
+//   - 异步并发 flush 时“上一次”按完成顺序确定
+func (p *StandardPipeline[T]) WithSkipIdenticalBatches(equal func(prev, cur []T) bool) *StandardPipeline[T] {
+	if equal == nil {
+		p.skip = nil
+		return p
+	}
+	p.skip = &identicalSkip[T]{equal: equal}
+	return p
+}
+
+// SkippedFlushes 返回因与上一次 flush 相同而被跳过的批次数（未启用时恒为 0）
+func (p *StandardPipeline[T]) SkippedFlushes() uint64 {
+	if p.skip == nil {
+		return 0
+	}
+	return p.skip.skipped.Load()
+}
+
+// flushUnlessIdentical 当前批次与上一次成功 flush 相同时跳过，否则 flush 并在成功后记为新的比较基准
+func (p *StandardPipeline[T]) flushUnlessIdentical(ctx context.Context, bd []T) error {
+	s := p.skip
+	if len(bd) > 0 {
+		s.mu.Lock()
+		same := s.hasPrev && s.equal(s.prev, bd)
+		s.mu.Unlock()
+		if same {
+			s.skipped.Add(1)
+			if h, ok := p.metrics.(FlushSkippedHook); ok {
+				h.FlushSkipped(len(bd))
+			}
+			return nil
+		}
+	}
+	if err := flushSliceChunks(ctx, bd, p.config.MaxFlushChunk, p.flushFunc); err != nil {
+		return err
+	}
+	if len(bd) > 0 {
+		s.mu.Lock()
+		s.prev, s.hasPrev = bd, true
+		s.mu.Unlock()
+	}
+	return nil
+}
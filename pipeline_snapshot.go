@@ -0,0 +1,87 @@
+package gopipeline
+
+import "reflect"
+
+// snapshotResult 主循环对 Snapshot 请求的应答
+type snapshotResult[T any] struct {
+	buffered []T
+	batch    []T
+}
+
+// Snapshot 返回运行中管道在途数据的一致视图，用于排查卡住的管道（调试用途）
+// 返回值:
+//   - buffered: 尚未入批的数据（按到达顺序）：主循环暂存的数据（如暂停 flush 期间接收的数据），以及数据通道中尚未被取走的数据
+//   - batch: 当前正在累计的批次数据（标准管道按入批顺序；去重管道为 map 的值，顺序不定）
+//
+// 说明:
+//   - 请求由主循环在两个事件之间处理：复制当前批次，再取出通道中已缓冲的数据暂存到主循环内并复制；
+//     暂存数据随后由主循环先于数据通道按原顺序照常消费（不会丢失或重复），Snapshot 本身不入批、不触发 flush，不改变 flush 时机
+//   - 性能影响：处理期间主循环暂停接收新数据，开销与 BufferSize + 当前批大小成正比，并会分配两份切片。请勿在热路径上频繁调用
+//   - 返回的是数据的浅拷贝；TransformPipeline 的批次元素类型与输入类型不同，batch 恒为 nil
+//   - 管道未运行时（或运行已结束）返回 nil, nil；主循环正在执行同步 flush 时会等待其完成
+func (p *PipelineImpl[T]) Snapshot() (buffered []T, batch []T) {
+	done := p.Done()
+	if done == nil {
+		return nil, nil
+	}
+	reply := make(chan snapshotResult[T], 1)
+	select {
+	case p.snapshotReq <- reply:
+	case <-done:
+		return nil, nil
+	}
+	r := <-reply
+	return r.buffered, r.batch
+}
+
+// handleSnapshot 在主循环内处理 Snapshot 请求
+// 取出的通道数据追加到主循环暂存区 pending，由主循环先于数据通道按原顺序照常消费；处理期间不入批、不触发 flush
+func (p *PipelineImpl[T]) handleSnapshot(st *batchState, reply chan<- snapshotResult[T]) {
+	batch := snapshotBatch[T](st.data)
+
+	// 只取请求时刻已缓冲的数据，避免在生产者持续写入时无限拉取
+	n := len(p.dataChan)
+DRAIN:
+	for i := 0; i < n; i++ {
+		select {
+		case v, ok := <-p.dataChan:
+			if !ok {
+				// 通道已关闭：暂存数据与剩余处理交给主循环的关闭路径
+				break DRAIN
+			}
+			p.pending = append(p.pending, v)
+		default:
+			break DRAIN
+		}
+	}
+	reply <- snapshotResult[T]{buffered: append([]T(nil), p.pending...), batch: batch}
+}
+
+// snapshotBatch 复制当前批次中的数据（批次元素类型不是 T 时返回 nil）
+func snapshotBatch[T any](batchData any) []T {
+	switch bd := batchData.(type) {
+	case []T:
+		return append([]T(nil), bd...)
+	case map[string]T:
+		items := make([]T, 0, len(bd))
+		for _, v := range bd {
+			items = append(items, v)
+		}
+		return items
+	default:
+		// 其他键类型的 map（如 KeyedDeduplicationPipeline 的 map[K]T）
+		v := reflect.ValueOf(batchData)
+		if v.Kind() != reflect.Map {
+			return nil
+		}
+		items := make([]T, 0, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			item, ok := it.Value().Interface().(T)
+			if !ok {
+				return nil
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+}
@@ -0,0 +1,35 @@
+package gopipeline
+
+import (
+	"context"
+	"sort"
+)
+
+// KeyValue 按键排序后的一条批次数据
+type KeyValue[A any] struct {
+	Key   string
+	Value A
+}
+
+// FlushSortedFunc 处理按键升序排列的批次数据
+type FlushSortedFunc[A any] func(ctx context.Context, batchData []KeyValue[A]) error
+
+// SortedFlush 将按键排序的刷新函数适配为以 map[string]A 为批次的刷新函数
+// 每次 flush 先把 map 转换为按键升序排列的 []KeyValue[A] 再调用 flushFunc，使输出顺序稳定、便于比对；
+// 返回值可直接作为 FlushDeduplicationFunc 传入 NewDeduplicationPipeline 等构造函数
+// 说明: 排序带来 O(n log n) 开销与一次切片分配；配置了 MaxFlushChunk 时每个分片各自有序
+func SortedFlush[A any](flushFunc FlushSortedFunc[A]) func(ctx context.Context, batchData map[string]A) error {
+	return func(ctx context.Context, batchData map[string]A) error {
+		return flushFunc(ctx, sortedKeyValues(batchData))
+	}
+}
+
+// sortedKeyValues 将 map 转换为按键升序排列的切片
+func sortedKeyValues[A any](m map[string]A) []KeyValue[A] {
+	kvs := make([]KeyValue[A], 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, KeyValue[A]{Key: k, Value: v})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
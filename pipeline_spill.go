@@ -0,0 +1,33 @@
+package gopipeline
+
+import "time"
+
+// SpillFunc 接收取消收尾时未能在宽限期内 flush 的剩余数据，由调用方另行持久化（如落盘待重放）
+type SpillFunc[T any] func(items []T)
+
+// WithDrainSpill 注入取消收尾的溢写函数（可选），仅在 DrainOnCancel=true 时生效
+// 说明:
+//   - DrainGracePeriod 耗尽时，尚未 flush 的当前批次与通道中仍缓冲的数据一并交给 fn（先批次、后缓冲），不再调用 flush 函数
+//   - 宽限期内完成的收尾不会调用 fn；已交给 flush 函数但因超时失败的批次按错误通道/重试/死信的既有路径处理
+//   - 当前批次无法表示为 []T 时（如 TransformPipeline、自定义批容器），只溢写通道中的缓冲数据
+//   - fn 在主循环协程内同步调用，Perform 在其返回后才返回
+func (p *PipelineImpl[T]) WithDrainSpill(fn SpillFunc[T]) *PipelineImpl[T] {
+	p.spill = fn
+	return p
+}
+
+// spillRemaining 宽限期耗尽后将未 flush 的批次与通道缓冲交给溢写函数，并释放其内存护栏额度
+func (p *PipelineImpl[T]) spillRemaining(st *batchState) {
+	var items []T
+	if !p.processor.isBatchEmpty(st.data) {
+		items = snapshotBatch[T](st.data)
+	}
+	p.releaseBytes(st.bytes)
+	st.data = p.processor.initBatchData()
+	st.bytes = 0
+	st.stamps = st.stamps[:0]
+	st.openedAt = time.Time{}
+	if items = p.takeBuffered(items); len(items) > 0 {
+		p.spill(items)
+	}
+}
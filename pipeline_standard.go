@@ -10,6 +10,8 @@ type FlushStandardFunc[T any] func(ctx context.Context, batchData []T) error
 type StandardPipeline[T any] struct {
 	*PipelineImpl[T]
 	flushFunc FlushStandardFunc[T]
+	// skip 可选：跳过与上一次成功 flush 相同的批次（WithSkipIdenticalBatches）
+	skip *identicalSkip[T]
 }
 
 // 确保 StandardPipeline 实现了 DataProcessor 接口
@@ -76,7 +78,11 @@ func (p *StandardPipeline[T]) addToBatch(batchData any, data T) any {
 //
 // 返回值: 如果刷新过程中发生错误则返回error
 func (p *StandardPipeline[T]) flush(ctx context.Context, batchData any) error {
-	return p.flushFunc(ctx, batchData.([]T))
+	bd := batchData.([]T)
+	if p.skip != nil {
+		return p.flushUnlessIdentical(ctx, bd)
+	}
+	return flushSliceChunks(ctx, bd, p.config.MaxFlushChunk, p.flushFunc)
 }
 
 // isBatchFull 检查批处理数据切片是否已达到配置的最大容量
@@ -0,0 +1,130 @@
+package gopipeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// AddHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每次 Add/TryAdd 返回时上报数据是否被接收
+type AddHook interface {
+	// Add 上报一次发送结果：accepted 为 true 表示数据已进入数据通道，
+	// false 表示未进入（被拒绝：缓冲满、超限、取消或通道已关闭；或转交了溢出 sink、按 DropNewest 丢弃）
+	Add(accepted bool)
+}
+
+// DropHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每当 Add 按 OverloadPolicy 丢弃一条数据时调用
+type DropHook interface {
+	// Dropped 上报一条被丢弃的数据；policy 为触发丢弃的策略（DropOldest 或 DropNewest）
+	Dropped(policy OverloadPolicy)
+}
+
+// AddBlockedHook 是 MetricsHook 的可选扩展
+// 若注入的 MetricsHook 同时实现了该接口，每当 Add 因缓冲已满进入阻塞等待并返回时上报本次阻塞时长
+type AddBlockedHook interface {
+	// AddBlocked 上报一次阻塞发送的等待时长（无论最终写入成功、ctx 结束还是通道关闭）
+	AddBlocked(d time.Duration)
+}
+
+// PipelineStats 管道的累计计数快照（生产者侧发送计数、过滤计数与计数管道的持久化计数）
+type PipelineStats struct {
+	// AddedTotal 经 Add/TryAdd 成功进入数据通道的数据条数
+	AddedTotal uint64
+	// RejectedTotal 经 Add/TryAdd 被拒绝的数据条数（缓冲满、超限、取消或通道已关闭）
+	RejectedTotal uint64
+	// OverflowTotal 因缓冲已满被转交溢出 sink（WithOverflowSink）的数据条数
+	OverflowTotal uint64
+	// DroppedTotal 因缓冲已满按 OverloadPolicy 丢弃的数据条数（DropOldest 丢弃的旧数据或 DropNewest 丢弃的新数据）
+	DroppedTotal uint64
+	// PersistedTotal 刷新函数报告的实际持久化条数（仅 NewCountedPipeline 创建的管道统计）
+	PersistedTotal uint64
+	// UnpersistedTotal 交给刷新函数但未被持久化的条数（仅 NewCountedPipeline 创建的管道统计）
+	UnpersistedTotal uint64
+	// FilteredTotal 被 WithFilter 谓词丢弃、未进入批次的数据条数
+	FilteredTotal uint64
+	// BlockedTotal Add 因缓冲已满进入阻塞等待的次数
+	BlockedTotal uint64
+	// BlockedDuration Add 在缓冲已满时阻塞等待的累计时长，持续增长说明生产者受下游背压
+	BlockedDuration time.Duration
+	// FilledBatches 观测过组批耗时的批次数（flush 触发时非空的批次）
+	FilledBatches uint64
+	// FillDuration 批次从首条数据入批到 flush 触发的累计时长，除以 FilledBatches 即平均组批耗时
+	FillDuration time.Duration
+}
+
+// producerCounters 生产者侧计数器（任意协程并发写）
+type producerCounters struct {
+	added    atomic.Uint64
+	rejected atomic.Uint64
+	overflow atomic.Uint64
+	dropped  atomic.Uint64
+	blocked  atomic.Uint64
+	// blockedNanos 累计阻塞时长（纳秒）
+	blockedNanos atomic.Int64
+}
+
+// fillCounters 组批耗时计数器（主循环单写，任意协程可读）
+type fillCounters struct {
+	count atomic.Uint64
+	nanos atomic.Int64
+}
+
+// Stats 返回累计计数的快照
+// 说明: 发送计数仅统计 Add/TryAdd，直接写 DataChan() 的数据不计入
+func (p *PipelineImpl[T]) Stats() PipelineStats {
+	return PipelineStats{
+		AddedTotal:       p.producer.added.Load(),
+		RejectedTotal:    p.producer.rejected.Load(),
+		OverflowTotal:    p.producer.overflow.Load(),
+		DroppedTotal:     p.producer.dropped.Load(),
+		PersistedTotal:   p.persist.persisted.Load(),
+		UnpersistedTotal: p.persist.unpersisted.Load(),
+		FilteredTotal:    p.filtered.Load(),
+		BlockedTotal:     p.producer.blocked.Load(),
+		BlockedDuration:  time.Duration(p.producer.blockedNanos.Load()),
+		FilledBatches:    p.fills.count.Load(),
+		FillDuration:     time.Duration(p.fills.nanos.Load()),
+	}
+}
+
+// recordAdd 记录一次 Add/TryAdd 的结果并上报给可选的 AddHook
+func (p *PipelineImpl[T]) recordAdd(accepted bool) {
+	if accepted {
+		p.producer.added.Add(1)
+	} else {
+		p.producer.rejected.Add(1)
+	}
+	if p.addHook != nil {
+		p.addHook.Add(accepted)
+	}
+}
+
+// recordOverflow 记录一次转交溢出 sink 的发送（对 AddHook 而言数据未进入数据通道）
+func (p *PipelineImpl[T]) recordOverflow() {
+	p.producer.overflow.Add(1)
+	if p.addHook != nil {
+		p.addHook.Add(false)
+	}
+}
+
+// recordDrop 记录一条按 OverloadPolicy 丢弃的数据并上报给可选的 DropHook
+// DropNewest 丢弃的是本次发送的数据，对 AddHook 而言数据未进入数据通道；DropOldest 的本次发送另行按成功记录
+func (p *PipelineImpl[T]) recordDrop(policy OverloadPolicy) {
+	p.producer.dropped.Add(1)
+	if policy == DropNewest && p.addHook != nil {
+		p.addHook.Add(false)
+	}
+	if h, ok := p.metrics.(DropHook); ok {
+		h.Dropped(policy)
+	}
+}
+
+// recordBlocked 记录一次 Add 阻塞发送的等待时长并上报给可选的 AddBlockedHook
+func (p *PipelineImpl[T]) recordBlocked(d time.Duration) {
+	p.producer.blocked.Add(1)
+	p.producer.blockedNanos.Add(int64(d))
+	if h, ok := p.metrics.(AddBlockedHook); ok {
+		h.AddBlocked(d)
+	}
+}
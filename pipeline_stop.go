@@ -0,0 +1,35 @@
+package gopipeline
+
+import "time"
+
+// requestStop 由 flush 路径调用：标记停止请求并唤醒主循环（可能运行在异步 flush 协程中）
+func (p *PipelineImpl[T]) requestStop() {
+	p.stopReq.Store(true)
+	select {
+	case p.stop <- struct{}{}:
+	default:
+	}
+}
+
+// resetStopRequest 在每次运行开始时清除遗留的停止请求
+func (p *PipelineImpl[T]) resetStopRequest() {
+	p.stopReq.Store(false)
+	select {
+	case <-p.stop:
+	default:
+	}
+}
+
+// handleStop 处理 flush 返回 ErrStopPipeline 后的停止
+// 停止语义：
+//   - 不再发起新的 flush（下游已声明永久不可用），当前未满批次交给死信处理（未配置时丢弃）
+//   - 数据通道中尚未取出的数据保留在缓冲中，不会被消费
+//   - 返回 ErrStopPipeline（可用 errors.Is 判断），不写入错误通道
+func (p *PipelineImpl[T]) handleStop(st *batchState) error {
+	if !p.processor.isBatchEmpty(st.data) {
+		p.sendDeadLetter(&retryEntry{batch: st.data, lastErr: ErrStopPipeline})
+		st.data = p.processor.initBatchData()
+		st.openedAt = time.Time{}
+	}
+	return ErrStopPipeline
+}
@@ -0,0 +1,101 @@
+package gopipeline
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// outcomeBucket 一个时间分桶内的 flush 结果计数；slot 为该桶当前对应的时间片序号
+type outcomeBucket struct {
+	slot   atomic.Int64
+	ok     atomic.Uint64
+	failed atomic.Uint64
+}
+
+// outcomeWindow 按时间轮转的分桶计数器（任意 flush 协程并发写，任意协程可读）
+// 时间片序号 = now / width，第 slot 个时间片写入 buckets[slot % len]；桶的 slot 过期时先清零再复用
+type outcomeWindow struct {
+	width   time.Duration
+	buckets []outcomeBucket
+}
+
+// bucketFor 返回 now 所在时间片的桶，必要时将过期的桶轮转到当前时间片
+func (w *outcomeWindow) bucketFor(now time.Time) *outcomeBucket {
+	slot := now.UnixNano() / int64(w.width)
+	b := &w.buckets[slot%int64(len(w.buckets))]
+	if old := b.slot.Load(); old != slot && b.slot.CompareAndSwap(old, slot) {
+		b.ok.Store(0)
+		b.failed.Store(0)
+	}
+	return b
+}
+
+// record 记录一次 flush 结果
+func (w *outcomeWindow) record(now time.Time, ok bool) {
+	b := w.bucketFor(now)
+	if ok {
+		b.ok.Add(1)
+	} else {
+		b.failed.Add(1)
+	}
+}
+
+// counts 汇总仍在窗口内的各桶计数
+func (w *outcomeWindow) counts(now time.Time) (ok, failed uint64) {
+	cur := now.UnixNano() / int64(w.width)
+	oldest := cur - int64(len(w.buckets)) + 1
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if s := b.slot.Load(); s < oldest || s > cur {
+			continue
+		}
+		ok += b.ok.Load()
+		failed += b.failed.Load()
+	}
+	return ok, failed
+}
+
+// WithSuccessWindow 启用最近 window 时长内 flush 成功率的滑动窗口统计（可选，window <= 0 表示禁用）
+// 参数:
+//   - window: 窗口时长
+//   - buckets: 窗口划分的桶数（<=0 时为 10），桶越多窗口滑动越平滑
+//
+// 说明:
+//   - 统计主循环派发的每次 flush（含空批次心跳与收尾 flush）：flush 函数返回 nil 计为成功，返回错误（含 panic 转换的错误）计为失败；
+//     重试队列的重放不计入
+//   - 窗口按桶轮转，最旧的桶整体过期，实际覆盖 window-window/buckets 到 window 的时长
+//   - 开销: 每次 flush 一次 time.Now 与两三次原子操作；内存为 buckets 个桶
+//   - 应在 Start/Perform 之前调用
+func (p *PipelineImpl[T]) WithSuccessWindow(window time.Duration, buckets int) *PipelineImpl[T] {
+	if window <= 0 {
+		p.outcomes = nil
+		return p
+	}
+	if buckets <= 0 {
+		buckets = 10
+	}
+	width := window / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+	w := &outcomeWindow{width: width, buckets: make([]outcomeBucket, buckets)}
+	for i := range w.buckets {
+		// 标记为从未使用，避免时间片 0 被误计入窗口
+		w.buckets[i].slot.Store(-1)
+	}
+	p.outcomes = w
+	return p
+}
+
+// SuccessRate 返回滑动窗口内 flush 的成功率，取值 [0, 1]
+// 未启用 WithSuccessWindow 或窗口内没有 flush 时返回 1；发生 panic 的 flush 计为失败（与 PanicPolicy 无关）；并发 flush 同时读取时为近似值
+func (p *PipelineImpl[T]) SuccessRate() float64 {
+	if p.outcomes == nil {
+		return 1
+	}
+	ok, failed := p.outcomes.counts(time.Now())
+	if ok+failed == 0 {
+		return 1
+	}
+	return float64(ok) / float64(ok+failed)
+}
@@ -0,0 +1,86 @@
+package gopipeline
+
+import "context"
+
+// TransformFunc 将输入数据映射为批处理元素
+// 返回值: 映射结果；第二个返回值为 false 时丢弃该条数据（过滤）
+type TransformFunc[T any, U any] func(data T) (U, bool)
+
+// TransformPipeline 在批处理前融合了一个 map/filter 阶段
+// 数据通道承载 T，转换在主循环中执行（单消费者，无需额外 goroutine），批次中保存转换后的 U
+type TransformPipeline[T any, U any] struct {
+	*PipelineImpl[T]
+	transform TransformFunc[T, U]
+	flushFunc FlushStandardFunc[U]
+}
+
+// 确保 TransformPipeline 实现了 DataProcessor 接口
+var _ DataProcessor[any] = (*TransformPipeline[any, any])(nil)
+
+// NewTransformPipeline 使用自定义配置创建一个带转换阶段的管道实例
+// 参数:
+//   - config: 自定义的管道配置
+//   - transform: 转换函数，返回 false 时丢弃该条数据
+//   - flushFunc: 用于处理转换后批处理数据的刷新函数
+//
+// 返回值: 返回一个新的 TransformPipeline 实例
+func NewTransformPipeline[T any, U any](
+	config PipelineConfig,
+	transform TransformFunc[T, U],
+	flushFunc FlushStandardFunc[U],
+) *TransformPipeline[T, U] {
+	p := &TransformPipeline[T, U]{
+		transform: transform,
+		flushFunc: flushFunc,
+	}
+	p.PipelineImpl = NewPipelineImpl[T](config, p)
+	return p
+}
+
+// initBatchData 初始化一个新的批处理数据切片
+// 返回值: 返回一个空的类型U切片
+func (p *TransformPipeline[T, U]) initBatchData() any {
+	return make([]U, 0, int(p.CurrentFlushSize()))
+}
+
+// addToBatch 转换新数据并添加到批处理数据切片中
+// 参数:
+//   - batchData: 当前的批处理数据切片
+//   - data: 需要添加的原始数据
+//
+// 返回值: 返回更新后的批处理数据切片；被过滤的数据不会进入批次
+func (p *TransformPipeline[T, U]) addToBatch(batchData any, data T) any {
+	u, ok := p.transform(data)
+	if !ok {
+		return batchData
+	}
+	return append(batchData.([]U), u)
+}
+
+// flush 使用配置的刷新函数处理转换后的批处理数据
+// 参数:
+//   - ctx: 上下文对象，用于控制操作的生命周期
+//   - batchData: 需要刷新的批处理数据
+//
+// 返回值: 如果刷新过程中发生错误则返回error
+func (p *TransformPipeline[T, U]) flush(ctx context.Context, batchData any) error {
+	return flushSliceChunks(ctx, batchData.([]U), p.config.MaxFlushChunk, p.flushFunc)
+}
+
+// isBatchFull 检查批处理数据切片是否已达到配置的最大容量
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据量达到或超过配置的FlushSize则返回true
+func (p *TransformPipeline[T, U]) isBatchFull(batchData any) bool {
+	return len(batchData.([]U)) >= int(p.CurrentFlushSize())
+}
+
+// isBatchEmpty 检查批处理数据切片是否为空
+// 参数:
+//   - batchData: 要检查的批处理数据切片
+//
+// 返回值: 如果数据切片长度小于1则返回true
+func (p *TransformPipeline[T, U]) isBatchEmpty(batchData any) bool {
+	return len(batchData.([]U)) < 1
+}
@@ -0,0 +1,58 @@
+package gopipeline
+
+import "context"
+
+// NextFlush 阻塞直到下一次 flush 完成（无论成功或失败），支持多个并发等待者
+// 参数:
+//   - ctx: 上下文对象，用于限制等待时长
+//
+// 返回值: 下一次 flush 返回的错误（成功为 nil）；flush 发生 panic 时返回包装了 ErrFlushPanic 的错误（与 PanicPolicy 无关）；
+// ctx 先结束时返回 ctx.Err()
+// 说明:
+//   - “下一次”指调用之后完成的第一次 flush（包括重试队列的重放）
+//   - 比 sleep 或轮询计数更精确，适用于测试与检查点场景
+func (p *PipelineImpl[T]) NextFlush(ctx context.Context) error {
+	ch := make(chan error, 1)
+	p.waitMu.Lock()
+	p.waiters = append(p.waiters, ch)
+	p.waiterCount.Add(1)
+	p.waitMu.Unlock()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		p.removeFlushWaiter(ch)
+		return ctx.Err()
+	}
+}
+
+// notifyFlushWaiters 在一次 flush 完成后通知并清空所有等待者
+// 无等待者时仅一次原子读，不加锁
+func (p *PipelineImpl[T]) notifyFlushWaiters(err *error) {
+	if p.waiterCount.Load() == 0 {
+		return
+	}
+	p.waitMu.Lock()
+	waiters := p.waiters
+	p.waiters = nil
+	p.waiterCount.Store(0)
+	p.waitMu.Unlock()
+
+	for _, ch := range waiters {
+		ch <- *err // 容量为 1 且每个等待者只通知一次，不会阻塞
+	}
+}
+
+// removeFlushWaiter 移除超时/取消的等待者，避免列表无限增长
+func (p *PipelineImpl[T]) removeFlushWaiter(ch chan error) {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			p.waiterCount.Add(-1)
+			return
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package gopipeline
+
+import "time"
+
+// warmState Warmup 预先准备好的首轮运行资源，由下一次 Perform 取用一次
+type warmState struct {
+	st    *batchState
+	timer *time.Timer
+}
+
+// Warmup 预热管道，降低首批 flush 的冷启动延迟（可选）
+// 预分配首个批容器（启用 WithResidenceTracking 时连同时间戳切片）、按默认容量初始化错误通道、预创建定时器；
+// 预热资源由下一次 Perform（Sync/Async/Start/Run）取用。
+// 注意:
+//   - 应在 Start/Perform 之前调用，不可与运行中的 Perform 并发调用
+//   - 会按“首次调用决定缓冲大小”的规则初始化错误通道；如需自定义容量，请先调用 ErrorChan(size)
+//   - 重复调用是幂等的；预热后调用 UpdateFlushSize 不会调整已预分配容器的容量
+func (p *PipelineImpl[T]) Warmup() {
+	_ = p.ErrorChan(0)
+
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if p.warm != nil {
+		return
+	}
+	st := p.newBatchState()
+	if p.residenceTracking {
+		st.stamps = make([]time.Time, 0, int(p.CurrentFlushSize()))
+	}
+	timer := time.NewTimer(p.CurrentFlushInterval())
+	timer.Stop()
+	p.warm = &warmState{st: st, timer: timer}
+}
+
+// takeWarmState 取出预热资源（未预热时返回 nil），每份资源只被一次运行使用
+func (p *PipelineImpl[T]) takeWarmState() *warmState {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	w := p.warm
+	p.warm = nil
+	return w
+}
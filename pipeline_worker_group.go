@@ -0,0 +1,60 @@
+package gopipeline
+
+import "context"
+
+// WorkerGroup 共享同一输入通道的一组相同管道
+// 每个工作管道各自经 ConsumeFrom 从共享输入中争抢数据、独立组批与 flush，适用于 flush 开销以 CPU 为主、需要多核并行的场景；
+// 数据按到达时哪个工作管道空闲分配，不保证相同键进入同一管道（需要按键路由时请使用 ShardedPipeline）
+type WorkerGroup[T any] struct {
+	group pipelineGroup[T]
+}
+
+// NewWorkerGroup 创建由 n 个工作管道组成的工作组
+// 参数:
+//   - n: 工作管道数（<=0 时为 1）
+//   - factory: 创建单个工作管道的函数，被调用 n 次，每次应返回一个新的、尚未运行的管道
+//
+// 返回值: 返回一个新的 WorkerGroup 实例
+func NewWorkerGroup[T any](n int, factory func() *StandardPipeline[T]) *WorkerGroup[T] {
+	if n <= 0 {
+		n = 1
+	}
+	workers := make([]*StandardPipeline[T], n)
+	for i := range workers {
+		workers[i] = factory()
+	}
+	return &WorkerGroup[T]{group: pipelineGroup[T]{members: workers}}
+}
+
+// Workers 返回工作管道数
+func (g *WorkerGroup[T]) Workers() int {
+	return len(g.group.members)
+}
+
+// Worker 返回第 i 个工作管道，用于调参或读取统计
+func (g *WorkerGroup[T]) Worker(i int) *StandardPipeline[T] {
+	return g.group.members[i]
+}
+
+// ErrorChan 返回汇总所有工作管道错误的只读通道
+// 线程安全、幂等：“首次调用决定缓冲大小”（<=0 时为各工作管道默认容量之和），后续调用忽略 size
+func (g *WorkerGroup[T]) ErrorChan(size int) <-chan error {
+	return g.group.errorChan(size)
+}
+
+// Start 异步启动所有工作管道，并让每个工作管道经 ConsumeFrom 消费 src
+// 返回所有工作管道都退出后关闭的 done 与汇总错误通道
+// 说明:
+//   - 关闭 src 即为整个工作组的“写入方关闭”：每个工作管道读到关闭后关闭自己的数据通道，执行最终 flush 后退出
+//   - ctx 结束时各工作管道按各自的取消语义（DrainOnCancel 等）退出；src 中尚未被读取的数据保持原样
+//   - 工作管道的数据只应来自 src，不要再对其调用 Add 或写入 DataChan
+func (g *WorkerGroup[T]) Start(ctx context.Context, src <-chan T) (<-chan struct{}, <-chan error) {
+	return g.group.start(ctx, func(w *StandardPipeline[T]) {
+		go func() { _ = w.ConsumeFrom(ctx, src) }()
+	})
+}
+
+// Done 返回最近一次 Start 的完成信号（尚未 Start 时为 nil）
+func (g *WorkerGroup[T]) Done() <-chan struct{} {
+	return g.group.doneChan()
+}
@@ -0,0 +1,33 @@
+package gopipeline
+
+// WithZeroCopyFlush 启用同步模式下的零拷贝批次复用（可选）
+// 说明:
+//   - 累计的切片本身即交给 flush 函数；同步 flush 返回后以 batch[:0] 继续累计下一批次，不再重新分配，
+//     适用于按序同步写入、追求低分配的场景
+//   - 与 WithResetFunc 相同，仅在同步 flush 路径上生效，异步 flush 或启用重试队列时仍重新分配；会覆盖此前设置的 WithResetFunc，
+//     WithZeroCopyFlush(false) 只撤销零拷贝复用，不影响此后经 WithResetFunc 设置的重置函数
+//   - 持有风险：flush 函数（以及 DeadLetterFunc、MetricsHook）返回后不得继续持有 batch 或其子切片，否则其内容会被下一批次覆盖；
+//     需要保留时请自行复制。不要与 WithSkipIdenticalBatches 同时使用。管道不会检测这类持有
+//   - 以 -race 构建（含 go test -race）时，复用前会清零切片元素，使其他协程对被持有切片的并发读取更容易被竞态检测器报告；
+//     这只是调试辅助而非保护：同一协程内稍后的读取只会静默看到零值或下一批次的数据
+func (p *StandardPipeline[T]) WithZeroCopyFlush(enabled bool) *StandardPipeline[T] {
+	if !enabled {
+		if p.zeroCopyReset {
+			p.resetFunc = nil
+			p.zeroCopyReset = false
+		}
+		return p
+	}
+	p.resetFunc = func(container any) any {
+		batch := container.([]T)
+		if raceEnabled {
+			var zero T
+			for i := range batch {
+				batch[i] = zero
+			}
+		}
+		return batch[:0]
+	}
+	p.zeroCopyReset = true
+	return p
+}
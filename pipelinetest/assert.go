@@ -0,0 +1,31 @@
+package pipelinetest
+
+import "testing"
+
+// Ordered 可用 < 比较的键类型
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// AssertOrdered 断言 items 按 key 非递减排列，用于验证同步模式“运行内按到达顺序 flush”的约定
+// 发现逆序时通过 t.Errorf 报告第一处违例（含下标与两侧的键）并返回 false；有序时返回 true
+//
+// 用法示例:
+//
+//	sink := pipelinetest.NewRecordingSink[Event]()
+//	p := gopipeline.NewStandardPipeline(config, sink.Flush)
+//	// ... 按 Seq 递增发送数据并 SyncPerform ...
+//	pipelinetest.AssertOrdered(t, sink.Items(), func(e Event) int64 { return e.Seq })
+func AssertOrdered[T any, K Ordered](t testing.TB, items []T, key func(T) K) bool {
+	t.Helper()
+	for i := 1; i < len(items); i++ {
+		prev, cur := key(items[i-1]), key(items[i])
+		if cur < prev {
+			t.Errorf("items out of order at index %d: key %v follows %v", i, cur, prev)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// Package pipelinetest 提供测试管道时使用的辅助工具
+package pipelinetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RecordingSink 记录所有批次的内存 sink，可直接作为标准管道的刷新函数使用
+// 线程安全：异步 flush 并发调用 Flush 时也能正确记录
+//
+// 用法示例:
+//
+//	sink := pipelinetest.NewRecordingSink[int]()
+//	p := gopipeline.NewStandardPipeline(config, sink.Flush)
+//	// ... 发送数据 ...
+//	if err := sink.WaitFor(100, time.Second); err != nil {
+//	    t.Fatal(err)
+//	}
+type RecordingSink[T any] struct {
+	mu      sync.Mutex
+	batches [][]T
+	items   int
+	err     error
+	changed chan struct{} // 每次记录新批次时关闭并置空，唤醒 WaitFor
+}
+
+// NewRecordingSink 创建一个新的 RecordingSink 实例
+func NewRecordingSink[T any]() *RecordingSink[T] {
+	return &RecordingSink[T]{}
+}
+
+// Flush 记录批次的拷贝，签名与 gopipeline.FlushStandardFunc[T] 一致
+// 通过 SetError 设置了错误时，批次仍会被记录，并返回该错误
+func (s *RecordingSink[T]) Flush(ctx context.Context, batchData []T) error {
+	batch := append([]T(nil), batchData...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, batch)
+	s.items += len(batch)
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+	return s.err
+}
+
+// SetError 设置后续 Flush 的返回值（nil 表示成功），用于模拟下游失败
+func (s *RecordingSink[T]) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Items 按记录顺序返回所有批次中的数据
+func (s *RecordingSink[T]) Items() []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make([]T, 0, s.items)
+	for _, b := range s.batches {
+		items = append(items, b...)
+	}
+	return items
+}
+
+// Batches 返回所有已记录批次的拷贝
+func (s *RecordingSink[T]) Batches() [][]T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batches := make([][]T, len(s.batches))
+	for i, b := range s.batches {
+		batches[i] = append([]T(nil), b...)
+	}
+	return batches
+}
+
+// WaitFor 阻塞直到累计记录的数据条数不少于 n
+// 返回值: 达到 n 条返回 nil；超时返回描述当前进度的错误
+func (s *RecordingSink[T]) WaitFor(n int, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		got := s.items
+		if got >= n {
+			s.mu.Unlock()
+			return nil
+		}
+		if s.changed == nil {
+			s.changed = make(chan struct{})
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return fmt.Errorf("pipelinetest: waited %v for %d items, got %d", timeout, n, got)
+		}
+	}
+}
+
+// Reset 清空已记录的批次与错误设置
+func (s *RecordingSink[T]) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = nil
+	s.items = 0
+	s.err = nil
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package gopipeline
+
+// raceEnabled 是否以 -race 构建（用于仅在竞态检测构建中启用的安全检查）
+const raceEnabled = false
@@ -0,0 +1,484 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+func newAddTestPipeline(buffer uint32) *gopipeline.StandardPipeline[int] {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(buffer).
+		WithFlushSize(10).
+		WithFlushInterval(time.Hour)
+	return gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error { return nil })
+}
+
+// TestAdd_DistinctErrors 验证 Add/TryAdd 针对取消、关闭、缓冲满返回可区分的错误
+func TestAdd_DistinctErrors(t *testing.T) {
+	p := newAddTestPipeline(1)
+
+	if err := p.TryAdd(1); err != nil {
+		t.Fatalf("expected first TryAdd to succeed, got %v", err)
+	}
+	if err := p.TryAdd(2); !errors.Is(err, gopipeline.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got %v", err)
+	}
+
+	// 缓冲已满时 Add 阻塞，直到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := p.Add(ctx, 3)
+	if !errors.Is(err, gopipeline.ErrContextIsClosed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrContextIsClosed wrapping DeadlineExceeded, got %v", err)
+	}
+	if errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("ctx timeout must not be reported as ErrChannelIsClosed: %v", err)
+	}
+
+	close(p.DataChan())
+	if err := p.Add(context.Background(), 4); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed from Add, got %v", err)
+	}
+	if err := p.TryAdd(5); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed from TryAdd, got %v", err)
+	}
+}
+
+// TestAdd_DeliversToFlush 验证通过 Add 发送的数据会被正常 flush
+func TestAdd_DeliversToFlush(t *testing.T) {
+	var total int
+	cfg := gopipeline.NewPipelineConfig().WithBufferSize(4).WithFlushSize(3).WithFlushInterval(time.Hour)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		total += len(batch)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- p.SyncPerform(ctx) }()
+
+	for i := 0; i < 7; i++ {
+		if err := p.Add(ctx, i); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	close(p.DataChan())
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if total != 7 {
+		t.Fatalf("expected 7 items flushed, got %d", total)
+	}
+}
+
+// TestAdd_MemoryLimit 验证内存护栏：超限时 TryAdd 拒绝、Add 阻塞，flush 完成后释放额度
+func TestAdd_MemoryLimit(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour).
+		WithMaxBufferedBytes(10)
+	flushed := make(chan []int, 4)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		flushed <- batch
+		return nil
+	})
+	p.WithSizeOf(func(int) int { return 4 })
+
+	if err := p.TryAdd(1); err != nil {
+		t.Fatalf("expected first TryAdd to succeed, got %v", err)
+	}
+	if err := p.TryAdd(2); err != nil {
+		t.Fatalf("expected second TryAdd to succeed, got %v", err)
+	}
+	if err := p.TryAdd(3); !errors.Is(err, gopipeline.ErrMemoryLimit) {
+		t.Fatalf("expected ErrMemoryLimit, got %v", err)
+	}
+	if got := p.BufferedBytes(); got != 8 {
+		t.Fatalf("expected 8 buffered bytes, got %d", got)
+	}
+
+	// 未运行时超限的 Add 阻塞直到 ctx 超时，且不会占用额度
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Add(ctx, 3); !errors.Is(err, gopipeline.ErrContextIsClosed) {
+		t.Fatalf("expected Add to block until ctx timeout, got %v", err)
+	}
+	if got := p.BufferedBytes(); got != 8 {
+		t.Fatalf("expected failed Add to release its reservation, got %d", got)
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() { _ = p.SyncPerform(runCtx) }()
+
+	// 第一批 flush 完成后释放额度，阻塞的 Add 得以继续
+	addCtx, addCancel := context.WithTimeout(context.Background(), time.Second)
+	defer addCancel()
+	if err := p.Add(addCtx, 3); err != nil {
+		t.Fatalf("expected Add to succeed after flush released bytes, got %v", err)
+	}
+	if batch := <-flushed; len(batch) != 2 {
+		t.Fatalf("expected first batch of 2, got %v", batch)
+	}
+	// 额度在 flush 函数返回后释放，短暂轮询等待
+	deadline := time.Now().Add(time.Second)
+	for p.BufferedBytes() != 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := p.BufferedBytes(); got != 4 {
+		t.Fatalf("expected 4 buffered bytes after first flush, got %d", got)
+	}
+}
+
+// addCountingHook 在 dummyHook 基础上实现了可选的 AddHook 扩展
+type addCountingHook struct {
+	dummyHook
+	accepted, rejected int
+}
+
+func (h *addCountingHook) Add(accepted bool) {
+	if accepted {
+		h.accepted++
+	} else {
+		h.rejected++
+	}
+}
+
+// TestAdd_SizeOfPanicNotChannelClosed 验证 WithSizeOf 估算函数的 panic 不会被报告为 ErrChannelIsClosed，
+// 且数据通道关闭后的发送仍返回 ErrChannelIsClosed
+func TestAdd_SizeOfPanicNotChannelClosed(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(4).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour).
+		WithMaxBufferedBytes(100)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error { return nil })
+	p.WithSizeOf(func(v int) int {
+		if v < 0 {
+			panic("bad size")
+		}
+		return 1
+	})
+
+	for name, add := range map[string]func() error{
+		"Add":    func() error { return p.Add(context.Background(), -1) },
+		"TryAdd": func() error { return p.TryAdd(-1) },
+	} {
+		var (
+			r   any
+			err error
+		)
+		func() {
+			defer func() { r = recover() }()
+			err = add()
+		}()
+		if errors.Is(err, gopipeline.ErrChannelIsClosed) {
+			t.Fatalf("%s: size estimator panic reported as ErrChannelIsClosed", name)
+		}
+		if r != "bad size" {
+			t.Fatalf("%s: expected the size estimator panic to propagate, got %v", name, r)
+		}
+	}
+
+	close(p.DataChan())
+	if err := p.Add(context.Background(), 1); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed from Add, got %v", err)
+	}
+	if err := p.TryAdd(1); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed from TryAdd, got %v", err)
+	}
+}
+
+// TestAdd_Stats 验证 Add/TryAdd 的接收与拒绝计数，以及可选的 AddHook 上报
+func TestAdd_Stats(t *testing.T) {
+	p := newAddTestPipeline(2)
+	hook := &addCountingHook{}
+	p.WithMetrics(hook)
+
+	_ = p.TryAdd(1)
+	_ = p.Add(context.Background(), 2)
+	_ = p.TryAdd(3) // 缓冲满
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = p.Add(ctx, 4) // 已取消
+
+	stats := p.Stats()
+	if stats.AddedTotal != 2 || stats.RejectedTotal != 2 {
+		t.Fatalf("expected 2 added and 2 rejected, got %+v", stats)
+	}
+	if hook.accepted != 2 || hook.rejected != 2 {
+		t.Fatalf("expected hook to observe 2 accepted and 2 rejected, got %d/%d", hook.accepted, hook.rejected)
+	}
+}
+
+// TestAdd_OverflowSink 验证缓冲满时 Add/TryAdd 转交溢出 sink 而非阻塞或拒绝，并计入 OverflowTotal
+func TestAdd_OverflowSink(t *testing.T) {
+	p := newAddTestPipeline(2)
+	var overflowed []int
+	p.WithOverflowSink(func(v int) { overflowed = append(overflowed, v) })
+
+	_ = p.TryAdd(1)
+	_ = p.Add(context.Background(), 2)
+	if err := p.TryAdd(3); err != nil {
+		t.Fatalf("expected TryAdd to overflow without error, got %v", err)
+	}
+	if err := p.Add(context.Background(), 4); err != nil {
+		t.Fatalf("expected Add to overflow without blocking, got %v", err)
+	}
+
+	if len(overflowed) != 2 || overflowed[0] != 3 || overflowed[1] != 4 {
+		t.Fatalf("expected items 3 and 4 to overflow, got %v", overflowed)
+	}
+	stats := p.Stats()
+	if stats.AddedTotal != 2 || stats.OverflowTotal != 2 || stats.RejectedTotal != 0 {
+		t.Fatalf("expected 2 added and 2 overflowed, got %+v", stats)
+	}
+}
+
+// TestAdd_OverflowSinkPanicPropagates 验证溢出 sink 中的 panic 直接传播给调用方，不会被伪装成 ErrChannelIsClosed
+func TestAdd_OverflowSinkPanicPropagates(t *testing.T) {
+	p := newAddTestPipeline(1)
+	p.WithOverflowSink(func(int) { panic("sink failed") })
+	_ = p.TryAdd(1)
+
+	for name, add := range map[string]func() error{
+		"Add":    func() error { return p.Add(context.Background(), 2) },
+		"TryAdd": func() error { return p.TryAdd(2) },
+	} {
+		var r any
+		func() {
+			defer func() { r = recover() }()
+			_ = add()
+		}()
+		if r != "sink failed" {
+			t.Fatalf("%s: expected the sink panic to propagate, got %v", name, r)
+		}
+	}
+}
+
+// dropCountingHook 在 dummyHook 基础上实现了可选的 DropHook 扩展
+type dropCountingHook struct {
+	dummyHook
+	dropped map[gopipeline.OverloadPolicy]int
+}
+
+func (h *dropCountingHook) Dropped(policy gopipeline.OverloadPolicy) { h.dropped[policy]++ }
+
+// TestAdd_OverloadPolicy 验证缓冲满时 DropOldest 丢弃最旧数据、DropNewest 丢弃新数据，Add 均不阻塞并计入 DroppedTotal
+func TestAdd_OverloadPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy gopipeline.OverloadPolicy
+		want   []int
+	}{
+		{"DropOldest", gopipeline.DropOldest, []int{3, 4}},
+		{"DropNewest", gopipeline.DropNewest, []int{1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []int
+			p := gopipeline.NewStandardPipeline[int](
+				gopipeline.NewPipelineConfig().
+					WithBufferSize(2).
+					WithFlushSize(10).
+					WithFlushInterval(time.Hour).
+					WithOverloadPolicy(tc.policy),
+				func(ctx context.Context, batch []int) error {
+					got = append(got, batch...)
+					return nil
+				})
+			hook := &dropCountingHook{dropped: map[gopipeline.OverloadPolicy]int{}}
+			p.WithMetrics(hook)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			for i := 1; i <= 4; i++ {
+				if err := p.Add(ctx, i); err != nil {
+					t.Fatalf("expected Add(%d) not to block or fail, got %v", i, err)
+				}
+			}
+			close(p.DataChan())
+			if err := p.SyncPerform(context.Background()); err != nil {
+				t.Fatalf("SyncPerform returned error: %v", err)
+			}
+
+			if len(got) != 2 || got[0] != tc.want[0] || got[1] != tc.want[1] {
+				t.Fatalf("expected flushed %v, got %v", tc.want, got)
+			}
+			if stats := p.Stats(); stats.DroppedTotal != 2 {
+				t.Fatalf("expected 2 dropped, got %+v", stats)
+			}
+			if hook.dropped[tc.policy] != 2 {
+				t.Fatalf("expected DropHook to observe 2 drops for %s, got %v", tc.name, hook.dropped)
+			}
+		})
+	}
+}
+
+// blockedRecordingHook 在 dummyHook 基础上实现了可选的 AddBlockedHook 扩展
+type blockedRecordingHook struct {
+	dummyHook
+	blocked []time.Duration
+}
+
+func (h *blockedRecordingHook) AddBlocked(d time.Duration) { h.blocked = append(h.blocked, d) }
+
+// TestAdd_BlockedDuration 验证仅缓冲已满时的阻塞发送被计时，累计到 BlockedDuration 并上报 AddBlockedHook
+func TestAdd_BlockedDuration(t *testing.T) {
+	p := newAddTestPipeline(1)
+	hook := &blockedRecordingHook{}
+	p.WithMetrics(hook)
+
+	if err := p.Add(context.Background(), 1); err != nil {
+		t.Fatalf("expected first Add to succeed, got %v", err)
+	}
+	if stats := p.Stats(); stats.BlockedTotal != 0 || stats.BlockedDuration != 0 {
+		t.Fatalf("expected fast-path Add not to be timed, got %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := p.Add(ctx, 2); !errors.Is(err, gopipeline.ErrContextIsClosed) {
+		t.Fatalf("expected blocked Add to end with ErrContextIsClosed, got %v", err)
+	}
+
+	stats := p.Stats()
+	if stats.BlockedTotal != 1 || stats.BlockedDuration < 20*time.Millisecond {
+		t.Fatalf("expected one blocked Add of about 30ms, got %+v", stats)
+	}
+	if len(hook.blocked) != 1 || hook.blocked[0] != stats.BlockedDuration {
+		t.Fatalf("expected hook to observe the blocked duration %v, got %v", stats.BlockedDuration, hook.blocked)
+	}
+}
+
+// TestAdd_AddChan 验证 AddChan 转发数据到管道，并在生产者关闭通道后退出转发协程
+func TestAdd_AddChan(t *testing.T) {
+	var total atomic.Int64
+	cfg := gopipeline.NewPipelineConfig().WithBufferSize(4).WithFlushSize(3).WithFlushInterval(time.Hour)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		total.Add(int64(len(batch)))
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	in := p.AddChan(ctx)
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	close(in)
+
+	// 全部数据写入数据通道后，转发协程不会再发送，此时关闭数据通道是安全的
+	deadline := time.Now().Add(time.Second)
+	for p.Stats().AddedTotal < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := p.Stats().AddedTotal; got != 5 {
+		t.Fatalf("expected 5 items forwarded, got %d", got)
+	}
+	waitAddChanExit(t)
+
+	close(p.DataChan())
+	<-done
+	if got := total.Load(); got != 5 {
+		t.Fatalf("expected 5 items flushed, got %d", got)
+	}
+}
+
+// TestAdd_AddChanExitsOnCancel 验证 ctx 取消后转发协程退出（不关闭数据通道）
+func TestAdd_AddChanExitsOnCancel(t *testing.T) {
+	p := gopipeline.NewStandardPipeline[int](gopipeline.NewPipelineConfig(), func(ctx context.Context, batch []int) error {
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = p.AddChan(ctx)
+	cancel()
+	waitAddChanExit(t)
+}
+
+// waitAddChanExit 等待所有 AddChan 转发协程退出（按协程栈匹配，不受其他协程增减影响）
+func waitAddChanExit(t *testing.T) {
+	t.Helper()
+	buf := make([]byte, 1<<20)
+	deadline := time.Now().Add(time.Second)
+	for {
+		n := runtime.Stack(buf, true)
+		if !strings.Contains(string(buf[:n]), ").AddChan.func") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected AddChan goroutines to exit, still running:\n%s", buf[:n])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestAdd_RegisterProducerAutoClose 验证最后一个生产者注销时发出信号并自动关闭数据通道
+func TestAdd_RegisterProducerAutoClose(t *testing.T) {
+	var total atomic.Int64
+	var gone atomic.Int32
+	cfg := gopipeline.NewPipelineConfig().WithBufferSize(8).WithFlushSize(4).WithFlushInterval(time.Hour)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		total.Add(int64(len(batch)))
+		return nil
+	})
+	p.WithOnProducersGone(func() { gone.Add(1) }).WithAutoCloseOnProducersGone(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	doneProducers := p.ProducersDone()
+	releases := make([]func(), 3)
+	for i := range releases {
+		releases[i] = p.RegisterProducer()
+	}
+	if n := p.ActiveProducers(); n != 3 {
+		t.Fatalf("expected 3 active producers, got %d", n)
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- p.SyncPerform(ctx) }()
+
+	for i, release := range releases {
+		go func(i int, release func()) {
+			defer release()
+			defer release() // 重复注销无副作用
+			for j := 0; j < 5; j++ {
+				_ = p.Add(ctx, i*10+j)
+			}
+		}(i, release)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("expected clean exit via auto close, got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("pipeline did not exit after all producers deregistered")
+	}
+	select {
+	case <-doneProducers:
+	default:
+		t.Fatal("expected ProducersDone to be closed")
+	}
+	if total.Load() != 15 || gone.Load() != 1 || p.ActiveProducers() != 0 {
+		t.Fatalf("expected 15 items flushed and one gone callback, got %d items, %d callbacks, %d active",
+			total.Load(), gone.Load(), p.ActiveProducers())
+	}
+}
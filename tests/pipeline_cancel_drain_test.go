@@ -321,3 +321,167 @@ func TestDedup_CloseChannel_AlwaysFlush(t *testing.T) {
 		}
 	}
 }
+
+// 标准管道：DrainOnCancel=true 且缓冲被灌满时，收尾应在 DrainGracePeriod 附近停止，而不是抽干全部数据
+func TestStandard_Cancel_WithDrain_RespectsGracePeriod(t *testing.T) {
+	var processed int64
+	const grace = 100 * time.Millisecond
+
+	config := gopipeline.NewPipelineConfig().
+		WithBufferSize(1000).
+		WithFlushSize(10).
+		WithFlushInterval(10 * time.Second).
+		WithDrainOnCancel(true).
+		WithDrainGracePeriod(grace)
+
+	// 慢 flush 且不理会 ctx：全部抽干约需 100 批 * 20ms = 2s，远超宽限期
+	p := gopipeline.NewStandardPipeline[int](config, func(ctx context.Context, batch []int) error {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt64(&processed, int64(len(batch)))
+		return nil
+	})
+
+	// 启动前灌满缓冲
+	ch := p.DataChan()
+	for i := 0; i < 1000; i++ {
+		ch <- i
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := p.SyncPerform(ctx)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, gopipeline.ErrContextIsClosed) || !errors.Is(err, gopipeline.ErrContextDrained) {
+		t.Fatalf("expected ErrContextIsClosed and ErrContextDrained, got %v", err)
+	}
+	if elapsed > grace+200*time.Millisecond {
+		t.Fatalf("drain exceeded grace period: elapsed=%v grace=%v", elapsed, grace)
+	}
+	if got := atomic.LoadInt64(&processed); got >= 1000 {
+		t.Fatalf("expected drain to stop before flushing everything, processed=%d", got)
+	}
+}
+
+// 标准管道：宽限期耗尽时，未 flush 的批次与缓冲数据按顺序交给 WithDrainSpill，不会静默丢失
+func TestStandard_Cancel_WithDrain_SpillsRemainder(t *testing.T) {
+	var processed int64
+	config := gopipeline.NewPipelineConfig().
+		WithBufferSize(1000).
+		WithFlushSize(10).
+		WithFlushInterval(10 * time.Second).
+		WithDrainOnCancel(true).
+		WithDrainGracePeriod(50 * time.Millisecond)
+
+	p := gopipeline.NewStandardPipeline[int](config, func(ctx context.Context, batch []int) error {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt64(&processed, int64(len(batch)))
+		return nil
+	})
+	var spilled []int
+	p.WithDrainSpill(func(items []int) { spilled = append(spilled, items...) })
+
+	ch := p.DataChan()
+	for i := 0; i < 1000; i++ {
+		ch <- i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := p.SyncPerform(ctx); !errors.Is(err, gopipeline.ErrContextDrained) {
+		t.Fatalf("expected ErrContextDrained, got %v", err)
+	}
+	got := int(atomic.LoadInt64(&processed))
+	if got+len(spilled) != 1000 {
+		t.Fatalf("expected flushed and spilled items to cover all 1000, got %d flushed and %d spilled", got, len(spilled))
+	}
+	for i, v := range spilled {
+		if v != got+i {
+			t.Fatalf("expected spilled items to be the unflushed tail in order, got %d at %d", v, i)
+		}
+	}
+}
+
+// 标准管道：DropOnCloseAfterCancel=true 时，取消后再关闭通道不会 flush 未满批次
+func TestStandard_CloseAfterCancel_DropOnClose(t *testing.T) {
+	var processed int64
+	config := gopipeline.NewPipelineConfig().
+		WithBufferSize(100).
+		WithFlushSize(50).
+		WithFlushInterval(10 * time.Second).
+		WithDropOnCloseAfterCancel(true)
+
+	p := gopipeline.NewStandardPipeline[int](config, func(ctx context.Context, batch []int) error {
+		atomic.AddInt64(&processed, int64(len(batch)))
+		return nil
+	})
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	close(ch)
+
+	// 无论主循环先观察到关闭还是取消，都不应 flush
+	err := p.SyncPerform(ctx)
+	if !errors.Is(err, gopipeline.ErrContextIsClosed) {
+		t.Fatalf("expected ErrContextIsClosed, got %v", err)
+	}
+	if got := atomic.LoadInt64(&processed); got != 0 {
+		t.Fatalf("expected partial batch to be dropped, processed=%d", got)
+	}
+}
+
+// 标准管道：DrainMode=UntilDeadline 时取消后继续接收迟到数据，通道关闭即结束收尾
+func TestStandard_Cancel_DrainUntilDeadline_CapturesLateArrivals(t *testing.T) {
+	var processed int64
+	config := gopipeline.NewPipelineConfig().
+		WithBufferSize(100).
+		WithFlushSize(50).
+		WithFlushInterval(10 * time.Second).
+		WithDrainOnCancel(true).
+		WithDrainGracePeriod(time.Second).
+		WithDrainMode(gopipeline.UntilDeadline)
+
+	p := gopipeline.NewStandardPipeline[int](config, func(ctx context.Context, batch []int) error {
+		atomic.AddInt64(&processed, int64(len(batch)))
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(ctx) }()
+
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	cancel()
+
+	// 取消后仍在写入的生产者
+	time.Sleep(30 * time.Millisecond)
+	for i := 5; i < 10; i++ {
+		ch <- i
+	}
+	start := time.Now()
+	close(ch)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, gopipeline.ErrContextIsClosed) || !errors.Is(err, gopipeline.ErrContextDrained) {
+			t.Fatalf("expected drained cancel error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for SyncPerform to exit")
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("expected drain to end once the channel closed, took %v", elapsed)
+	}
+	if got := atomic.LoadInt64(&processed); got != 10 {
+		t.Fatalf("expected late arrivals to be flushed, processed=%d", got)
+	}
+}
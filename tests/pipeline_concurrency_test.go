@@ -3,6 +3,7 @@ package gopipeline_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -134,3 +135,289 @@ func TestErrAlreadyRunning(t *testing.T) {
 
 // 附加：在测试文件内使用互斥或其他辅助，避免竞态（如上无共享写入无需）。
 // 现有项目的其他测试已验证核心行为，这里仅覆盖新增能力。
+
+// TestSetAsync_SwitchesModeAtRuntime 验证 SetAsync 在同一次运行中切换 flush 的同步/异步模式
+func TestSetAsync_SwitchesModeAtRuntime(t *testing.T) {
+	var inflight, maxInflight, flushed int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			n := atomic.AddInt32(&inflight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInflight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInflight, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inflight, -1)
+			atomic.AddInt32(&flushed, 1)
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.SyncPerform(ctx)
+	}()
+
+	waitFlushed := func(n int32) {
+		deadline := time.Now().Add(2 * time.Second)
+		for atomic.LoadInt32(&flushed) < n && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// 同步模式：逐个 flush
+	for i := 0; i < 3; i++ {
+		p.DataChan() <- i
+	}
+	waitFlushed(3)
+	if m := atomic.LoadInt32(&maxInflight); m != 1 {
+		t.Fatalf("expected serial flushes in sync mode, max inflight %d", m)
+	}
+
+	// 切换为异步：flush 并发执行
+	p.SetAsync(true)
+	for i := 0; i < 4; i++ {
+		p.DataChan() <- i
+	}
+	waitFlushed(7)
+	if m := atomic.LoadInt32(&maxInflight); m < 2 {
+		t.Fatalf("expected concurrent flushes after SetAsync(true), max inflight %d", m)
+	}
+
+	cancel()
+	<-done
+}
+
+// TestFlushAffinity_SameKeySerialDifferentKeysParallel 验证按键亲和：同键批次串行且保序，不同键批次并行
+func TestFlushAffinity_SameKeySerialDifferentKeysParallel(t *testing.T) {
+	type event struct {
+		Key string
+		Seq int
+	}
+	var mu sync.Mutex
+	perKeyInflight := map[string]int{}
+	perKeySeqs := map[string][]int{}
+	var total, maxTotal int
+
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []event) error {
+			key := batch[0].Key
+			mu.Lock()
+			perKeyInflight[key]++
+			if perKeyInflight[key] > 1 {
+				t.Errorf("batches for key %s flushed concurrently", key)
+			}
+			total++
+			if total > maxTotal {
+				maxTotal = total
+			}
+			for _, e := range batch {
+				perKeySeqs[key] = append(perKeySeqs[key], e.Seq)
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			perKeyInflight[key]--
+			total--
+			mu.Unlock()
+			return nil
+		})
+	p.WithFlushAffinity(8, func(batch any) string { return batch.([]event)[0].Key })
+
+	ch := p.DataChan()
+	go func() {
+		defer close(ch)
+		// 每个批次只含同一键的数据：按键成对发送
+		for seq := 0; seq < 6; seq++ {
+			for _, k := range []string{"a", "b", "c", "d"} {
+				ch <- event{Key: k, Seq: seq * 2}
+				ch <- event{Key: k, Seq: seq*2 + 1}
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.AsyncPerform(ctx); err != nil {
+		t.Fatalf("AsyncPerform returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, k := range []string{"a", "b", "c", "d"} {
+		seqs := perKeySeqs[k]
+		if len(seqs) != 12 {
+			t.Fatalf("expected 12 items for key %s after Perform returned, got %d", k, len(seqs))
+		}
+		for i, s := range seqs {
+			if s != i {
+				t.Fatalf("expected in-order flushes for key %s, got %v", k, seqs)
+			}
+		}
+	}
+	if maxTotal < 2 {
+		t.Fatalf("expected different keys to flush in parallel, max concurrent %d", maxTotal)
+	}
+}
+
+// TestKeyHasher_ControlsLaneSelection 验证 WithKeyHasher 决定按键亲和的通道分布
+func TestKeyHasher_ControlsLaneSelection(t *testing.T) {
+	var mu sync.Mutex
+	var inflight, maxInflight int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []string) error {
+			mu.Lock()
+			inflight++
+			if inflight > maxInflight {
+				maxInflight = inflight
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+			return nil
+		})
+	var hashed int32
+	// 所有键哈希到同一通道：不同键也必须串行
+	p.WithFlushAffinity(4, func(batch any) string { return batch.([]string)[0] }).
+		WithKeyHasher(func(string) uint64 {
+			atomic.AddInt32(&hashed, 1)
+			return 7
+		})
+
+	ch := p.DataChan()
+	for _, k := range []string{"a", "b", "c", "d", "e", "f"} {
+		ch <- k
+	}
+	close(ch)
+	if err := p.AsyncPerform(context.Background()); err != nil {
+		t.Fatalf("AsyncPerform returned error: %v", err)
+	}
+
+	if atomic.LoadInt32(&hashed) != 6 {
+		t.Fatalf("expected custom hasher to be used for every batch, got %d calls", hashed)
+	}
+	if maxInflight != 1 {
+		t.Fatalf("expected all batches on one lane to run serially, max inflight %d", maxInflight)
+	}
+}
+
+// TestPauseResumeFlush 验证暂停期间继续接收数据但不 flush，恢复后按 FlushSize 依序 flush 暂存数据
+func TestPauseResumeFlush(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(2).
+			WithFlushSize(3).
+			WithFlushInterval(5*time.Millisecond),
+		func(ctx context.Context, batch []int) error {
+			mu.Lock()
+			batches = append(batches, append([]int(nil), batch...))
+			mu.Unlock()
+			return nil
+		})
+	flushCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches)
+	}
+
+	p.PauseFlush()
+	done := make(chan error, 1)
+	go func() { done <- p.SyncPerform(context.Background()) }()
+
+	// 批满后主循环仍持续接收，写入量可超过批次 + 缓冲
+	for i := 1; i <= 7; i++ {
+		if err := p.Add(context.Background(), i); err != nil {
+			t.Fatalf("Add(%d) returned error: %v", i, err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond) // 多次定时触发也不会 flush
+	if n := flushCount(); n != 0 || !p.FlushPaused() {
+		t.Fatalf("expected no flush while paused, got %d", n)
+	}
+
+	p.ResumeFlush()
+	deadline := time.Now().Add(time.Second)
+	for flushCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(p.DataChan())
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var all []int
+	for _, b := range batches {
+		all = append(all, b...)
+	}
+	if len(batches) < 2 || len(batches[0]) != 3 || len(batches[1]) != 3 {
+		t.Fatalf("expected full batches on resume, got %v", batches)
+	}
+	for i, v := range all {
+		if v != i+1 {
+			t.Fatalf("expected items in arrival order without loss, got %v", batches)
+		}
+	}
+	if len(all) != 7 {
+		t.Fatalf("expected no data loss, got %v", batches)
+	}
+}
+
+// TestPauseFlushCloseWhilePaused 验证暂停期间关闭数据通道仍能结束运行，并 flush 当前批次与暂存数据
+func TestPauseFlushCloseWhilePaused(t *testing.T) {
+	var batches [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(4).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, append([]int(nil), batch...))
+			return nil
+		})
+
+	p.PauseFlush()
+	done, errs := p.Start(context.Background())
+	go func() {
+		for range errs {
+		}
+	}()
+	for i := 1; i <= 3; i++ {
+		if err := p.Add(context.Background(), i); err != nil {
+			t.Fatalf("Add(%d) returned error: %v", i, err)
+		}
+	}
+	close(p.DataChan())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected closing the data channel to end the run while flush is paused")
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0] != 3 {
+		t.Fatalf("expected remaining data flushed in FlushSize batches on close, got %v", batches)
+	}
+}
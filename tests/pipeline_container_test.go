@@ -0,0 +1,119 @@
+package gopipeline_test
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// intSet 测试用的自定义批容器：去重的整数集合
+type intSet struct {
+	m map[int]struct{}
+}
+
+func (s *intSet) Len() int { return len(s.m) }
+
+// containerHook 统计 MetricsHook.Flush 上报的批大小
+type containerHook struct {
+	dummyHook
+	items atomic.Int32
+}
+
+func (h *containerHook) Flush(items int, duration time.Duration) { h.items.Add(int32(items)) }
+
+// TestContainerPipeline 验证自定义批容器按 isFull/isEmpty 触发 flush，且 Len() 作为指标批大小
+func TestContainerPipeline(t *testing.T) {
+	var batches [][]int
+	p := gopipeline.NewContainerPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func() *intSet { return &intSet{m: map[int]struct{}{}} },
+		func(s *intSet, v int) *intSet {
+			s.m[v] = struct{}{}
+			return s
+		},
+		func(s *intSet) bool { return len(s.m) >= 3 },
+		func(s *intSet) bool { return len(s.m) == 0 },
+		func(ctx context.Context, s *intSet) error {
+			batch := make([]int, 0, len(s.m))
+			for v := range s.m {
+				batch = append(batch, v)
+			}
+			sort.Ints(batch)
+			batches = append(batches, batch)
+			return nil
+		})
+	h := &containerHook{}
+	p.WithMetrics(h)
+
+	ch := p.DataChan()
+	for _, v := range []int{1, 1, 2, 2, 3, 4, 4} {
+		ch <- v
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 3 || batches[0][2] != 3 || len(batches[1]) != 1 || batches[1][0] != 4 {
+		t.Fatalf("expected batches [[1 2 3] [4]], got %v", batches)
+	}
+	if n := h.items.Load(); n != 4 {
+		t.Fatalf("expected metrics to report 4 items via Len(), got %d", n)
+	}
+}
+
+// TestContainerPipeline_ResetFunc 验证同步 flush 后通过 WithResetFunc 复用同一个批容器，而不是重新创建
+func TestContainerPipeline_ResetFunc(t *testing.T) {
+	var created, flushed int
+	var first *intSet
+	p := gopipeline.NewContainerPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func() *intSet {
+			created++
+			return &intSet{m: map[int]struct{}{}}
+		},
+		func(s *intSet, v int) *intSet {
+			s.m[v] = struct{}{}
+			return s
+		},
+		func(s *intSet) bool { return len(s.m) >= 2 },
+		func(s *intSet) bool { return len(s.m) == 0 },
+		func(ctx context.Context, s *intSet) error {
+			if first == nil {
+				first = s
+			} else if s != first {
+				t.Errorf("expected the container to be reused across flushes")
+			}
+			flushed += len(s.m)
+			return nil
+		})
+	p.WithResetFunc(func(c any) any {
+		s := c.(*intSet)
+		for k := range s.m {
+			delete(s.m, k)
+		}
+		return s
+	})
+
+	ch := p.DataChan()
+	for v := 0; v < 5; v++ {
+		ch <- v
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if created != 1 || flushed != 5 {
+		t.Fatalf("expected a single container and 5 flushed items, got %d containers, %d items", created, flushed)
+	}
+}
@@ -0,0 +1,195 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestDeduplicationPipeline_PartialFlushRetry 验证部分失败时仅失败键被回填到后续批次，成功键不重复写入
+func TestDeduplicationPipeline_PartialFlushRetry(t *testing.T) {
+	errWrite := errors.New("write b failed")
+	writes := map[string]int{}
+	failedOnce := false
+
+	p := gopipeline.NewPartialDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch map[string]DedupTestData) ([]string, error) {
+			if _, ok := batch["b"]; ok && !failedOnce {
+				failedOnce = true
+				for k := range batch {
+					if k != "b" {
+						writes[k]++
+					}
+				}
+				return []string{"b"}, errWrite
+			}
+			for k := range batch {
+				writes[k]++
+			}
+			return nil, nil
+		}).WithDedupRetry(true)
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		ch <- DedupTestData{ID: id}
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if writes[id] != 1 {
+			t.Fatalf("expected key %s written exactly once, got %v", id, writes)
+		}
+	}
+	if n := p.PendingDedupRetries(); n != 0 {
+		t.Fatalf("expected no pending retries, got %d", n)
+	}
+
+	select {
+	case err := <-errs:
+		var perr *gopipeline.PartialFlushError
+		if !errors.As(err, &perr) || len(perr.FailedKeys) != 1 || perr.FailedKeys[0] != "b" || !errors.Is(err, errWrite) {
+			t.Fatalf("expected PartialFlushError for key b, got %v", err)
+		}
+	default:
+		t.Fatal("expected partial failure to be reported")
+	}
+}
+
+// TestDeduplicationPipeline_PartialFlushWithoutRetry 验证未启用 WithDedupRetry 时失败键不会被回填
+func TestDeduplicationPipeline_PartialFlushWithoutRetry(t *testing.T) {
+	var flushes int
+	p := gopipeline.NewPartialDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch map[string]DedupTestData) ([]string, error) {
+			flushes++
+			return nil, errors.New("all failed")
+		})
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "a"}
+	ch <- DedupTestData{ID: "b"}
+	close(ch)
+
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if flushes != 1 || p.PendingDedupRetries() != 0 {
+		t.Fatalf("expected a single flush and no pending retries, got %d flushes, %d pending", flushes, p.PendingDedupRetries())
+	}
+}
+
+// TestDeduplicationPipeline_RetryRemergesIntoWindow 验证整批失败的条目并入当前窗口，与新数据合并后再写入，不会写入过期值
+func TestDeduplicationPipeline_RetryRemergesIntoWindow(t *testing.T) {
+	var flushes []map[string]DedupTestData
+	p := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch map[string]DedupTestData) error {
+			if len(flushes) == 0 {
+				flushes = append(flushes, nil)
+				return errors.New("first flush failed")
+			}
+			flushes = append(flushes, batch)
+			return nil
+		}).WithDedupRetry(true)
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "a", Name: "stale"}
+	ch <- DedupTestData{ID: "b", Name: "v1"}
+	ch <- DedupTestData{ID: "a", Name: "fresh"}
+	close(ch)
+
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(flushes) != 2 {
+		t.Fatalf("expected the failed batch to be re-merged into one retry flush, got %d flushes", len(flushes))
+	}
+	got := flushes[1]
+	if len(got) != 2 || got["a"].Name != "fresh" || got["b"].Name != "v1" {
+		t.Fatalf("expected retry to carry the latest value for a and the failed value for b, got %v", got)
+	}
+}
+
+// TestDeduplicationPipeline_RetrySkipsSupersededKeys 验证失败批次在途期间同一键已随更新批次写入时，旧值不再回填
+func TestDeduplicationPipeline_RetrySkipsSupersededKeys(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var mu sync.Mutex
+	written := map[string]string{}
+
+	p := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch map[string]DedupTestData) error {
+			if batch["a"].Name == "stale" {
+				close(started)
+				<-release
+				return errors.New("slow flush failed")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range batch {
+				written[k] = v.Name
+			}
+			return nil
+		}).WithDedupRetry(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "a", Name: "stale"}
+	<-started
+	ch <- DedupTestData{ID: "a", Name: "fresh"}
+	// 等待较新的批次写入完成后再让旧批次失败
+	for {
+		mu.Lock()
+		ok := written["a"] == "fresh"
+		mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	close(ch)
+	<-done
+
+	if n := p.PendingDedupRetries(); n != 0 {
+		t.Fatalf("expected superseded key not to be requeued, got %d pending", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if written["a"] != "fresh" {
+		t.Fatalf("expected latest value to stay written, got %q", written["a"])
+	}
+}
@@ -3,7 +3,10 @@ package gopipeline_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -443,3 +446,385 @@ func TestDeduplicationPipelineCompleteDeduplication(t *testing.T) {
 
 	<-doneChan
 }
+
+// TestDeduplicationPipelineFlushedKeys 验证成功 flush 后下发键集合，失败的 flush 不下发
+func TestDeduplicationPipelineFlushedKeys(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	errFlush := errors.New("flush failed")
+	pipeline := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			if _, ok := batchData["x"]; ok {
+				return errFlush
+			}
+			return nil
+		})
+	keysChan := pipeline.FlushedKeys()
+
+	dataChan := pipeline.DataChan()
+	for _, id := range []string{"a", "b", "a", "c", "x"} {
+		dataChan <- DedupTestData{ID: id}
+	}
+	close(dataChan)
+
+	if err := pipeline.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	select {
+	case keys := <-keysChan:
+		sort.Strings(keys)
+		if strings.Join(keys, ",") != "a,b,c" {
+			t.Fatalf("expected flushed keys a,b,c, got %v", keys)
+		}
+	default:
+		t.Fatal("expected flushed keys for the successful batch")
+	}
+	select {
+	case keys := <-keysChan:
+		t.Fatalf("failed flush should not emit keys, got %v", keys)
+	default:
+	}
+}
+
+// dedupKeysHook 在 dummyHook 基础上实现了可选的 DedupKeysHook 扩展
+type dedupKeysHook struct {
+	dummyHook
+	windows []int
+	capped  int
+}
+
+func (h *dedupKeysHook) DedupKeys(distinct int, capped bool) {
+	h.windows = append(h.windows, distinct)
+	if capped {
+		h.capped++
+	}
+}
+
+// TestDeduplicationPipeline_MaxDedupKeys 验证高基数输入下去重 map 在 flush 前不超过 MaxDedupKeys
+func TestDeduplicationPipeline_MaxDedupKeys(t *testing.T) {
+	const maxKeys = 64
+	var total, maxSeen int
+	pipeline := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(128).
+			WithFlushSize(100000).
+			WithFlushInterval(time.Hour).
+			WithMaxDedupKeys(maxKeys),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			total += len(batchData)
+			if len(batchData) > maxSeen {
+				maxSeen = len(batchData)
+			}
+			return nil
+		})
+	hook := &dedupKeysHook{}
+	pipeline.WithMetrics(hook)
+
+	dataChan := pipeline.DataChan()
+	go func() {
+		defer close(dataChan)
+		for i := 0; i < 1000; i++ {
+			dataChan <- DedupTestData{ID: "key-" + strconv.Itoa(i)}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pipeline.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if maxSeen > maxKeys {
+		t.Fatalf("dedup window exceeded MaxDedupKeys: %d > %d", maxSeen, maxKeys)
+	}
+	if total != 1000 {
+		t.Fatalf("expected 1000 unique keys flushed, got %d", total)
+	}
+	// 1000/64 = 15 个满窗口 + 关闭时的最终窗口
+	if len(hook.windows) != 16 || hook.capped != 15 {
+		t.Fatalf("expected 16 windows with 15 capped, got %d windows with %d capped", len(hook.windows), hook.capped)
+	}
+}
+
+// TestDeduplicationPipeline_MaxDedupItems 验证重复度很高时按写入条数（含被覆盖的数据）提前 flush
+func TestDeduplicationPipeline_MaxDedupItems(t *testing.T) {
+	var windows []int
+	pipeline := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(128).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour).
+			WithMaxDedupItems(10),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			windows = append(windows, len(batchData))
+			return nil
+		})
+
+	// 25 条数据只有 2 个不同的键：键数远未达到 FlushSize，但每 10 条写入即 flush 一次
+	dataChan := pipeline.DataChan()
+	for i := 0; i < 25; i++ {
+		dataChan <- DedupTestData{ID: "key-" + strconv.Itoa(i%2)}
+	}
+	close(dataChan)
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(windows) != 3 || windows[0] != 2 || windows[1] != 2 || windows[2] != 2 {
+		t.Fatalf("expected 3 windows of 2 keys (10, 10 and 5 writes), got %v", windows)
+	}
+}
+
+// TestDeduplicationPipeline_UseMapReuse 验证启用 UseMapReuse 后同步 flush 复用同一个批处理 map，且每个窗口只含本批数据
+func TestDeduplicationPipeline_UseMapReuse(t *testing.T) {
+	maps := map[string]bool{}
+	var windows [][]string
+	pipeline := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour).
+			WithUseMapReuse(true),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			maps[fmt.Sprintf("%p", batchData)] = true
+			keys := make([]string, 0, len(batchData))
+			for k := range batchData {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			windows = append(windows, keys)
+			return nil
+		})
+
+	dataChan := pipeline.DataChan()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		dataChan <- DedupTestData{ID: id}
+	}
+	close(dataChan)
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(maps) != 1 {
+		t.Fatalf("expected a single reused map, got %d", len(maps))
+	}
+	if got := fmt.Sprint(windows); got != "[[a b] [c d] [e]]" {
+		t.Fatalf("expected windows [[a b] [c d] [e]], got %s", got)
+	}
+}
+
+// TestDeduplicationPipeline_SortedFlush 验证 SortedFlush 以按键升序的切片调用刷新函数
+func TestDeduplicationPipeline_SortedFlush(t *testing.T) {
+	var got []string
+	pipeline := gopipeline.NewDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(8).
+			WithFlushInterval(time.Hour),
+		gopipeline.SortedFlush(func(ctx context.Context, batch []gopipeline.KeyValue[DedupTestData]) error {
+			for _, kv := range batch {
+				got = append(got, kv.Key+"="+kv.Value.Name)
+			}
+			return nil
+		}))
+
+	dataChan := pipeline.DataChan()
+	for _, d := range []DedupTestData{{ID: "c", Name: "3"}, {ID: "a", Name: "1"}, {ID: "d", Name: "4"}, {ID: "b", Name: "2"}, {ID: "a", Name: "5"}} {
+		dataChan <- d
+	}
+	close(dataChan)
+
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if strings.Join(got, ",") != "a=5,b=2,c=3,d=4" {
+		t.Fatalf("expected key-sorted output, got %v", got)
+	}
+}
+
+// TestDeduplicationPipelineWithOptions 测试去重选项组合：自定义键、合并冲突与计数
+func TestDeduplicationPipelineWithOptions(t *testing.T) {
+	var got map[string]DedupTestData
+	var counts map[string]int
+	p := gopipeline.NewDeduplicationPipelineWith(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			got = batchData
+			counts = gopipeline.DedupCounts(ctx)
+			return nil
+		},
+		gopipeline.WithKeyFunc(func(d DedupTestData) string { return d.Name }),
+		gopipeline.WithMerge(func(old, new DedupTestData) DedupTestData {
+			new.Age += old.Age
+			return new
+		}),
+		gopipeline.WithCountingDedup[DedupTestData](),
+	)
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "1", Name: "alice", Age: 1}
+	ch <- DedupTestData{ID: "2", Name: "bob", Age: 10}
+	ch <- DedupTestData{ID: "3", Name: "alice", Age: 2}
+	ch <- DedupTestData{ID: "4", Name: "alice", Age: 3}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(got) != 2 || got["alice"].Age != 6 || got["alice"].ID != "4" || got["bob"].Age != 10 {
+		t.Fatalf("expected merged batch keyed by name, got %v", got)
+	}
+	if counts["alice"] != 3 || counts["bob"] != 1 {
+		t.Fatalf("expected occurrence counts alice=3 bob=1, got %v", counts)
+	}
+}
+
+// TestDeduplicationPipelineWithKeepFirst 测试 WithKeepFirst 保留首次出现的数据，未启用计数时 DedupCounts 为 nil
+func TestDeduplicationPipelineWithKeepFirst(t *testing.T) {
+	var got map[string]DedupTestData
+	var counts map[string]int
+	p := gopipeline.NewDeduplicationPipelineWith(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			got = batchData
+			counts = gopipeline.DedupCounts(ctx)
+			return nil
+		},
+		gopipeline.WithKeepFirst[DedupTestData](),
+	)
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "a", Name: "first"}
+	ch <- DedupTestData{ID: "a", Name: "second"}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(got) != 1 || got["a"].Name != "first" {
+		t.Fatalf("expected first occurrence to be kept, got %v", got)
+	}
+	if counts != nil {
+		t.Fatalf("expected nil counts without WithCountingDedup, got %v", counts)
+	}
+}
+
+// TestDeduplicationPipelineWithKeyNormalizer 测试 WithKeyNormalizer 将仅大小写与空白不同的键合并为同一键
+func TestDeduplicationPipelineWithKeyNormalizer(t *testing.T) {
+	var got map[string]DedupTestData
+	p := gopipeline.NewDeduplicationPipelineWith(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData map[string]DedupTestData) error {
+			got = batchData
+			return nil
+		},
+		gopipeline.WithKeyNormalizer[DedupTestData](func(k string) string {
+			return strings.ToLower(strings.TrimSpace(k))
+		}),
+	)
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "User-1", Name: "v1"}
+	ch <- DedupTestData{ID: " user-1 ", Name: "v2"}
+	ch <- DedupTestData{ID: "user-2", Name: "v3"}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(got) != 2 || got["user-1"].Name != "v2" || got["user-2"].Name != "v3" {
+		t.Fatalf("expected near-duplicate keys to collapse under normalized keys, got %v", got)
+	}
+}
+
+// TestOrderedDeduplicationPipeline 测试按首次插入顺序输出去重批次，覆盖保留原位置
+func TestOrderedDeduplicationPipeline(t *testing.T) {
+	var batches [][]DedupTestData
+	p := gopipeline.NewOrderedDeduplicationPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData []DedupTestData) error {
+			batches = append(batches, append([]DedupTestData(nil), batchData...))
+			return nil
+		})
+
+	ch := p.DataChan()
+	ch <- DedupTestData{ID: "b", Name: "b1"}
+	ch <- DedupTestData{ID: "a", Name: "a1"}
+	ch <- DedupTestData{ID: "b", Name: "b2"}
+	ch <- DedupTestData{ID: "c", Name: "c1"} // 第一批满：b, a, c
+	ch <- DedupTestData{ID: "a", Name: "a2"} // 新批次重新计位
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %v", batches)
+	}
+	var names []string
+	for _, d := range batches[0] {
+		names = append(names, d.Name)
+	}
+	if strings.Join(names, ",") != "b2,a1,c1" {
+		t.Fatalf("expected first-insertion order with in-place overwrite, got %v", names)
+	}
+	if len(batches[1]) != 1 || batches[1][0].Name != "a2" {
+		t.Fatalf("expected second batch [a2], got %v", batches[1])
+	}
+}
+
+// TestDeduplicationPipelineKeyed 验证以非字符串键去重：批次直接使用 map[K]T，后到的数据覆盖先到的数据
+func TestDeduplicationPipelineKeyed(t *testing.T) {
+	type event struct {
+		UserID int
+		Seq    int
+	}
+	var batches []map[int]event
+	p := gopipeline.NewDeduplicationPipelineKeyed(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(e event) int { return e.UserID },
+		func(ctx context.Context, batch map[int]event) error {
+			batches = append(batches, batch)
+			return nil
+		})
+
+	ch := p.DataChan()
+	for _, e := range []event{{1, 1}, {1, 2}, {2, 1}, {3, 1}} {
+		ch <- e
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d: %v", len(batches), batches)
+	}
+	if len(batches[0]) != 2 || batches[0][1].Seq != 2 || batches[0][2].Seq != 1 {
+		t.Fatalf("expected first batch {1:seq2, 2:seq1}, got %v", batches[0])
+	}
+	if len(batches[1]) != 1 || batches[1][3].Seq != 1 {
+		t.Fatalf("expected second batch {3:seq1}, got %v", batches[1])
+	}
+}
@@ -0,0 +1,112 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestDispatchPipeline_ExternalWorkers 验证批次投递给外部 worker，确认结果可追踪且错误经 ErrorChan 上报
+func TestDispatchPipeline_ExternalWorkers(t *testing.T) {
+	errWorker := errors.New("worker failed")
+	p := gopipeline.NewDispatchPipeline[int](
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(32).
+			WithFlushSize(4).
+			WithFlushInterval(time.Hour),
+		2)
+	errs := p.ErrorChan(8)
+
+	var mu sync.Mutex
+	var total, batches int
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	for w := 0; w < 3; w++ {
+		go func() {
+			for {
+				select {
+				case batch := <-p.Batches():
+					mu.Lock()
+					batches++
+					total += len(batch)
+					first := batches == 1
+					mu.Unlock()
+					if first {
+						p.AckBatch(errWorker)
+					} else {
+						p.AckBatch(nil)
+					}
+				case <-workerCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if err := p.WaitAcked(ctx); err != nil {
+		t.Fatalf("WaitAcked returned error: %v", err)
+	}
+	if n := p.InflightBatches(); n != 0 {
+		t.Fatalf("expected no inflight batches, got %d", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// 10 条按 FlushSize=4 组批：两个满批 + 关闭时的最终批
+	if batches != 3 || total != 10 {
+		t.Fatalf("expected 3 batches with 10 items, got %d batches with %d items", batches, total)
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errWorker) {
+			t.Fatalf("expected worker error, got %v", err)
+		}
+	default:
+		t.Fatal("expected acked error to be reported")
+	}
+}
+
+// TestDispatchPipeline_WaitAckedTimeout 验证未确认的批次会使 WaitAcked 等待直到 ctx 结束
+func TestDispatchPipeline_WaitAckedTimeout(t *testing.T) {
+	p := gopipeline.NewDispatchPipeline[int](
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		4)
+
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.WaitAcked(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded while batch is unacked, got %v", err)
+	}
+
+	<-p.Batches()
+	p.AckBatch(nil)
+	if err := p.WaitAcked(context.Background()); err != nil {
+		t.Fatalf("expected WaitAcked to return after ack, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package gopipeline_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -217,3 +219,176 @@ func TestErrorChanBufferSizeCalculation(t *testing.T) {
 		})
 	}
 }
+
+// TestUnboundedErrors 验证启用 WithUnboundedErrors 后错误通道满时错误不丢失且按顺序投递
+func TestUnboundedErrors(t *testing.T) {
+	var n int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(32).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			n++
+			return fmt.Errorf("flush %d failed", batch[0])
+		})
+	p.WithUnboundedErrors()
+	errs := p.ErrorChan(1)
+
+	ch := p.DataChan()
+	for i := 0; i < 20; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if n != 20 {
+		t.Fatalf("expected 20 flushes, got %d", n)
+	}
+	if pending := p.PendingErrors(); pending < 18 {
+		t.Fatalf("expected errors to queue up behind a full channel, got %d", pending)
+	}
+
+	for i := 0; i < 20; i++ {
+		select {
+		case err := <-errs:
+			if want := fmt.Sprintf("flush %d failed", i); err.Error() != want {
+				t.Fatalf("expected %q, got %q", want, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for error %d", i)
+		}
+	}
+	if pending := p.PendingErrors(); pending != 0 {
+		t.Fatalf("expected queue drained, got %d pending", pending)
+	}
+}
+
+// TestErrorCoalescing 验证连续同类错误合并为一条带次数的错误，不同类错误按发生顺序上报
+func TestErrorCoalescing(t *testing.T) {
+	errDown := errors.New("downstream unavailable")
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			if batch[0] == 5 {
+				return errors.New("bad row")
+			}
+			return errDown
+		})
+	p.WithErrorCoalescing(50*time.Millisecond, nil)
+	errs := p.ErrorChan(8)
+
+	ch := p.DataChan()
+	for i := 0; i < 7; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	next := func() error {
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for error")
+			return nil
+		}
+	}
+	var cerr *gopipeline.CoalescedError
+	if err := next(); !errors.As(err, &cerr) || cerr.Count != 5 || !errors.Is(err, errDown) {
+		t.Fatalf("expected 5 coalesced downstream errors, got %v", err)
+	}
+	if err := next(); err.Error() != "bad row" {
+		t.Fatalf("expected bad row error next, got %v", err)
+	}
+	// 最后一条在窗口结束后原样上报
+	if err := next(); err != errDown {
+		t.Fatalf("expected single trailing downstream error, got %v", err)
+	}
+}
+
+// TestTypedErrorChan 验证启用 WithBatchErrors 后失败批次以 FlushError[T] 下发，且不影响原错误通道
+func TestTypedErrorChan(t *testing.T) {
+	errBoom := errors.New("boom")
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error { return errBoom })
+	if p.TypedErrorChan() != nil {
+		t.Fatal("expected nil typed error channel before WithBatchErrors")
+	}
+	p.WithBatchErrors(4)
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i := 1; i <= 4; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	typed := p.TypedErrorChan()
+	for _, want := range [][]int{{1, 2}, {3, 4}} {
+		fe := <-typed
+		if !errors.Is(fe, errBoom) || len(fe.Batch) != 2 || fe.Batch[0] != want[0] || fe.Batch[1] != want[1] {
+			t.Fatalf("expected FlushError for batch %v, got %v (batch %v)", want, fe, fe.Batch)
+		}
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected plain error channel to still receive 2 errors, got %d", len(errs))
+	}
+}
+
+// TestCloseErrorChanOnStop 验证启用 WithCloseErrorChanOnStop 后，Start 运行结束时错误通道在在途异步 flush 的错误写入后关闭
+func TestCloseErrorChanOnStop(t *testing.T) {
+	errBoom := errors.New("boom")
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			time.Sleep(20 * time.Millisecond)
+			return errBoom
+		})
+	p.WithCloseErrorChanOnStop(true)
+
+	done, errs := p.Start(context.Background())
+	ch := p.DataChan()
+	for i := 1; i <= 4; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	var got []error
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for err := range errs {
+			got = append(got, err)
+		}
+	}()
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the error channel to be closed after the run stopped")
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("expected done to be closed before the error channel")
+	}
+	if len(got) != 2 || !errors.Is(got[0], errBoom) || !errors.Is(got[1], errBoom) {
+		t.Fatalf("expected both async flush errors before close, got %v", got)
+	}
+}
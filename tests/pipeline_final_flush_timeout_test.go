@@ -233,3 +233,87 @@ READ:
 		t.Fatalf("expected ctx deadline exceeded or nil, got %v", gotErr)
 	}
 }
+
+// TestFlushDeadlineFactor verifies that WithFlushDeadlineFactor bounds every flush's
+// ctx to factor*FlushInterval, so a flush that waits on ctx ends with DeadlineExceeded.
+func TestFlushDeadlineFactor(t *testing.T) {
+	var flushErr atomic.Value
+	var elapsed atomic.Int64
+	p := gopipeline.NewStandardPipeline[int](
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(4).
+			WithFlushSize(1).
+			WithFlushInterval(10*time.Millisecond),
+		func(ctx context.Context, batch []int) error {
+			start := time.Now()
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("expected flush ctx to carry a deadline")
+			}
+			<-ctx.Done()
+			elapsed.Store(int64(time.Since(start)))
+			flushErr.Store(ctx.Err())
+			return ctx.Err()
+		}).WithFlushDeadlineFactor(2)
+	_ = p.ErrorChan(4)
+
+	p.DataChan() <- 1
+	close(p.DataChan())
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if err, _ := flushErr.Load().(error); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected flush ctx to hit its deadline, got %v", err)
+	}
+	if d := time.Duration(elapsed.Load()); d > 500*time.Millisecond {
+		t.Fatalf("expected deadline near 2*FlushInterval, flush ran for %v", d)
+	}
+}
+
+// TestCloseDuringSlowFlush 验证在慢速 flush 进行中关闭数据通道：剩余数据由主循环做最终 flush，
+// 错误通道不会被关闭，在途 flush 结束后上报的错误照常送达而不会 panic
+func TestCloseDuringSlowFlush(t *testing.T) {
+	errSlow := errors.New("slow flush failed")
+	started := make(chan struct{})
+	var flushed atomic.Int32
+
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed.Add(int32(len(batch)))
+			if batch[0] == 1 {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+				return errSlow
+			}
+			return nil
+		})
+
+	done, errs := p.Start(context.Background())
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	<-started
+	ch <- 3
+	close(ch)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pipeline did not exit after closing the data channel")
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errSlow) {
+			t.Fatalf("expected the in-flight flush error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the in-flight flush error to be delivered after exit")
+	}
+	if n := flushed.Load(); n != 3 {
+		t.Fatalf("expected all 3 items flushed, got %d", n)
+	}
+}
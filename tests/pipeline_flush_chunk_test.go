@@ -0,0 +1,107 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestMaxFlushChunk_Standard 验证标准管道按 MaxFlushChunk 拆分批次并聚合分片错误
+func TestMaxFlushChunk_Standard(t *testing.T) {
+	errChunk := errors.New("chunk failed")
+	var sizes []int
+	var items []int
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(32).
+		WithFlushSize(10).
+		WithFlushInterval(time.Hour).
+		WithMaxFlushChunk(4)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		sizes = append(sizes, len(batch))
+		items = append(items, batch...)
+		if len(sizes) == 2 {
+			return errChunk
+		}
+		return nil
+	})
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(sizes) != 3 || sizes[0] != 4 || sizes[1] != 4 || sizes[2] != 2 {
+		t.Fatalf("expected chunk sizes [4 4 2], got %v", sizes)
+	}
+	for i, v := range items {
+		if v != i {
+			t.Fatalf("expected chunks to preserve order, got %v", items)
+		}
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errChunk) {
+			t.Fatalf("expected aggregated chunk error, got %v", err)
+		}
+	default:
+		t.Fatal("expected the failed chunk to be reported")
+	}
+}
+
+// TestMaxFlushChunk_Dedup 验证去重管道按键数拆分为子 map
+func TestMaxFlushChunk_Dedup(t *testing.T) {
+	seen := map[string]int{}
+	var chunks int
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(32).
+		WithFlushSize(10).
+		WithFlushInterval(time.Hour).
+		WithMaxFlushChunk(3)
+
+	p := gopipeline.NewDeduplicationPipeline[DedupTestData](cfg, func(ctx context.Context, batch map[string]DedupTestData) error {
+		chunks++
+		if len(batch) > 3 {
+			t.Errorf("chunk exceeds MaxFlushChunk: %d", len(batch))
+		}
+		for k := range batch {
+			seen[k]++
+		}
+		return nil
+	})
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- DedupTestData{ID: strconv.Itoa(i)}
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if chunks != 4 {
+		t.Fatalf("expected 4 chunks for 10 keys, got %d", chunks)
+	}
+	if len(seen) != 10 {
+		t.Fatalf("expected all 10 keys flushed once, got %v", seen)
+	}
+	for k, n := range seen {
+		if n != 1 {
+			t.Fatalf("key %s flushed %d times", k, n)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gopipeline_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestFramedPipeline 验证每条数据以 4 字节大端长度前缀成帧写出，编码失败的数据上报后跳过
+func TestFramedPipeline(t *testing.T) {
+	errBad := errors.New("bad item")
+	var out bytes.Buffer
+	p := gopipeline.NewFramedPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		&out,
+		func(s string) ([]byte, error) {
+			if s == "bad" {
+				return nil, errBad
+			}
+			return []byte(s), nil
+		})
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for _, s := range []string{"a", "bad", "hello", "", "xyz"} {
+		ch <- s
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	var got []string
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(&out, header[:]); err != nil {
+			break
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(&out, frame); err != nil {
+			t.Fatalf("truncated frame: %v", err)
+		}
+		got = append(got, string(frame))
+	}
+	if len(got) != 4 || got[0] != "a" || got[1] != "hello" || got[2] != "" || got[3] != "xyz" {
+		t.Fatalf("expected frames [a hello  xyz], got %q", got)
+	}
+
+	select {
+	case err := <-errs:
+		var fe *gopipeline.FrameEncodeError
+		if !errors.As(err, &fe) || fe.Index != 1 || !errors.Is(err, errBad) {
+			t.Fatalf("expected FrameEncodeError at index 1, got %v", err)
+		}
+	default:
+		t.Fatal("expected the encode error to be reported")
+	}
+}
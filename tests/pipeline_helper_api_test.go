@@ -3,6 +3,8 @@ package gopipeline_test
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -122,3 +124,605 @@ func TestRun_BlocksUntilCancelAndReturnsContextError(t *testing.T) {
 		t.Fatalf("expected at least one flush call, got 0")
 	}
 }
+
+// TestWarmup_BeforeStart 验证 Warmup 初始化错误通道，且预热资源被首次运行正确使用、后续运行不受影响
+func TestWarmup_BeforeStart(t *testing.T) {
+	var calls int32
+	p := gopipeline.NewStandardPipeline[int](quickConfig(), okFlush[int](&calls))
+	p.Warmup()
+	p.Warmup() // 幂等
+
+	errs := p.ErrorChan(128)
+	if cap(errs) == 128 {
+		t.Fatal("expected Warmup to have initialized the error channel with the default size")
+	}
+
+	for run := 0; run < 2; run++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		done, _ := p.Start(ctx)
+		before := atomic.LoadInt32(&calls)
+		for i := 0; i < 4; i++ {
+			p.DataChan() <- i
+		}
+		deadline := time.Now().Add(time.Second)
+		for atomic.LoadInt32(&calls) == before && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+		if atomic.LoadInt32(&calls) == before {
+			t.Fatalf("run %d: expected a flush", run)
+		}
+	}
+}
+
+// TestUpdateTuning_ConsistentSnapshot 验证 UpdateTuning 成组更新、CurrentTuning 读到一致快照
+func TestUpdateTuning_ConsistentSnapshot(t *testing.T) {
+	var calls int32
+	p := gopipeline.NewStandardPipeline[int](quickConfig(), okFlush[int](&calls))
+
+	p.UpdateTuning(0, 0)
+	if size, interval := p.CurrentTuning(); size != 1 || interval != time.Millisecond {
+		t.Fatalf("expected normalized (1, 1ms), got (%d, %v)", size, interval)
+	}
+
+	// 并发成组更新：任一快照都必须来自同一次更新
+	pairs := map[uint32]time.Duration{8: 8 * time.Millisecond, 16: 16 * time.Millisecond}
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				p.UpdateTuning(8, 8*time.Millisecond)
+				p.UpdateTuning(16, 16*time.Millisecond)
+			}
+		}
+	}()
+	for i := 0; i < 10000; i++ {
+		size, interval := p.CurrentTuning()
+		if size == 1 {
+			continue
+		}
+		if pairs[size] != interval {
+			close(stop)
+			t.Fatalf("inconsistent tuning snapshot: (%d, %v)", size, interval)
+		}
+	}
+	close(stop)
+	if p.CurrentFlushSize() == 0 || p.CurrentFlushInterval() <= 0 {
+		t.Fatal("expected individual getters to remain valid")
+	}
+}
+
+// TestSnapshot_ReturnsInFlightDataWithoutLosingIt 验证 Snapshot 返回在途数据，且这些数据随后照常被 flush
+func TestSnapshot_ReturnsInFlightDataWithoutLosingIt(t *testing.T) {
+	var flushed [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed = append(flushed, append([]int(nil), batch...))
+			return nil
+		})
+
+	if buffered, batch := p.Snapshot(); buffered != nil || batch != nil {
+		t.Fatalf("expected nil snapshot when not running, got %v %v", buffered, batch)
+	}
+
+	done, _ := p.Start(context.Background())
+	ch := p.DataChan()
+	for i := 1; i <= 3; i++ {
+		ch <- i
+	}
+
+	// 在事件之间应答：缓冲中的数据与当前批次合起来恰好是已发送的全部数据
+	buffered, batch := p.Snapshot()
+	all := append(append([]int(nil), batch...), buffered...)
+	if len(all) != 3 || all[0] != 1 || all[1] != 2 || all[2] != 3 {
+		t.Fatalf("expected in-flight data [1 2 3], got batch=%v buffered=%v", batch, buffered)
+	}
+
+	close(ch)
+	<-done
+	if len(flushed) != 1 || len(flushed[0]) != 3 {
+		t.Fatalf("expected snapshotted data to be flushed exactly once, got %v", flushed)
+	}
+}
+
+// TestSnapshot_DoesNotFlush 验证 Snapshot 不消费通道数据、不触发 flush，取出的数据之后按原顺序照常组批
+func TestSnapshot_DoesNotFlush(t *testing.T) {
+	var mu sync.Mutex
+	var flushed [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(4).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			mu.Lock()
+			flushed = append(flushed, append([]int(nil), batch...))
+			mu.Unlock()
+			return nil
+		})
+
+	// 暂停 flush，使批次保持已满、缓冲数据保持在途
+	p.PauseFlush()
+	done := make(chan error, 1)
+	go func() { done <- p.SyncPerform(context.Background()) }()
+	// 写入超过缓冲容量，发送完成时主循环必然已在运行
+	ch := p.DataChan()
+	for i := 1; i <= 6; i++ {
+		ch <- i
+	}
+
+	for round := 0; round < 2; round++ {
+		buffered, batch := p.Snapshot()
+		if len(batch) != 2 || len(buffered) != 4 || buffered[0] != 3 || buffered[3] != 6 {
+			t.Fatalf("round %d: expected batch [1 2] and buffered [3 4 5 6], got batch=%v buffered=%v", round, batch, buffered)
+		}
+	}
+	mu.Lock()
+	n := len(flushed)
+	mu.Unlock()
+	if n != 0 {
+		t.Fatalf("expected Snapshot not to trigger a flush, got %d flushes", n)
+	}
+
+	p.ResumeFlush()
+	close(ch)
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(flushed) != 3 || flushed[0][0] != 1 || flushed[1][0] != 3 || flushed[2][0] != 5 {
+		t.Fatalf("expected snapshotted data flushed in order in FlushSize batches, got %v", flushed)
+	}
+}
+
+// TestReconfigure_AppliesHotFieldsAndRejectsColdOnes 验证 Reconfigure 成组应用可热更新字段，拒绝需要重建的修改
+func TestReconfigure_AppliesHotFieldsAndRejectsColdOnes(t *testing.T) {
+	var calls int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(10).
+			WithFlushInterval(time.Second),
+		okFlush[int](&calls))
+
+	if err := p.Reconfigure(p.Config().WithFlushSize(20).WithFlushInterval(time.Minute)); err != nil {
+		t.Fatalf("expected hot-swappable change to apply, got %v", err)
+	}
+	if size, interval := p.CurrentTuning(); size != 20 || interval != time.Minute {
+		t.Fatalf("expected tuning 20/1m, got %d/%v", size, interval)
+	}
+
+	if err := p.Reconfigure(p.Config().WithMaxConcurrentFlushes(4)); err != nil {
+		t.Fatalf("expected MaxConcurrentFlushes to be hot-swappable, got %v", err)
+	}
+	if got := p.MaxConcurrentFlushes(); got != 4 || p.Config().MaxConcurrentFlushes != 4 {
+		t.Fatalf("expected concurrency cap 4, got %d (config %d)", got, p.Config().MaxConcurrentFlushes)
+	}
+
+	err := p.Reconfigure(p.Config().WithFlushSize(30).WithBufferSize(128).WithDrainOnCancel(true).WithMaxConcurrentFlushes(8))
+	if !errors.Is(err, gopipeline.ErrRestartRequired) {
+		t.Fatalf("expected ErrRestartRequired, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "BufferSize") || !strings.Contains(err.Error(), "DrainOnCancel") {
+		t.Fatalf("expected error to name the cold fields, got %v", err)
+	}
+	if size, _ := p.CurrentTuning(); size != 20 || p.MaxConcurrentFlushes() != 4 {
+		t.Fatalf("expected rejected change to leave tuning untouched, got %d/%d", size, p.MaxConcurrentFlushes())
+	}
+}
+
+// TestReconfigure_ConcurrentCallsStayAtomic 验证并发的 Reconfigure 不会交错，Config 总是读到同一次调用的完整结果
+func TestReconfigure_ConcurrentCallsStayAtomic(t *testing.T) {
+	var calls int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(10).
+			WithFlushInterval(time.Second).
+			WithMaxConcurrentFlushes(10),
+		okFlush[int](&calls))
+	base := p.Config()
+
+	var wg sync.WaitGroup
+	for _, n := range []uint32{10, 20} {
+		n := n
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				if err := p.Reconfigure(base.WithFlushSize(n).WithMaxConcurrentFlushes(n)); err != nil {
+					t.Errorf("Reconfigure returned error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	stop := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if c := p.Config(); c.FlushSize != c.MaxConcurrentFlushes {
+				t.Errorf("Config mixed two reconfigurations: FlushSize=%d MaxConcurrentFlushes=%d", c.FlushSize, c.MaxConcurrentFlushes)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-readerDone
+
+	if c := p.Config(); c.FlushSize != c.MaxConcurrentFlushes {
+		t.Fatalf("expected the last Reconfigure to win as a whole, got FlushSize=%d MaxConcurrentFlushes=%d", c.FlushSize, c.MaxConcurrentFlushes)
+	}
+}
+
+// TestProcessorRegistry 验证按名称登记与查找处理器工厂，以及未知名称/类型不一致时的错误
+func TestProcessorRegistry(t *testing.T) {
+	var built gopipeline.PipelineConfig
+	gopipeline.RegisterProcessor("test-capped", func(config gopipeline.PipelineConfig, flush gopipeline.FlushStandardFunc[int]) *gopipeline.PipelineImpl[int] {
+		built = config.WithFlushSize(2)
+		return gopipeline.NewStandardPipeline(built, flush).PipelineImpl
+	})
+
+	var batches [][]int
+	p, err := gopipeline.NewFromRegistry("test-capped", gopipeline.NewPipelineConfig().WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, batch)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("NewFromRegistry returned error: %v", err)
+	}
+	ch := p.DataChan()
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 {
+		t.Fatalf("expected the registered factory's FlushSize to apply, got %v", batches)
+	}
+
+	if _, err := gopipeline.NewFromRegistry(gopipeline.StandardProcessor, gopipeline.NewPipelineConfig(),
+		func(ctx context.Context, batch []string) error { return nil }); err != nil {
+		t.Fatalf("expected the built-in standard processor, got %v", err)
+	}
+	if _, err := gopipeline.NewFromRegistry("missing", gopipeline.NewPipelineConfig(),
+		func(ctx context.Context, batch []int) error { return nil }); !errors.Is(err, gopipeline.ErrUnknownProcessor) {
+		t.Fatalf("expected ErrUnknownProcessor for an unknown name, got %v", err)
+	}
+	if _, err := gopipeline.NewFromRegistry("test-capped", gopipeline.NewPipelineConfig(),
+		func(ctx context.Context, batch []string) error { return nil }); !errors.Is(err, gopipeline.ErrUnknownProcessor) {
+		t.Fatalf("expected ErrUnknownProcessor for a data type mismatch, got %v", err)
+	}
+}
+
+// TestTimerResets 验证轻推（UpdateFlushInterval）与定时触发都会计入定时器重置次数
+func TestTimerResets(t *testing.T) {
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error { return nil })
+
+	done, _ := p.Start(context.Background())
+	for i := 0; i < 5; i++ {
+		p.UpdateFlushInterval(time.Hour)
+		time.Sleep(5 * time.Millisecond)
+	}
+	nudged := p.TimerResets()
+	if nudged == 0 {
+		t.Fatal("expected UpdateFlushInterval nudges to reset the timer")
+	}
+
+	p.UpdateFlushInterval(5 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(p.DataChan())
+	<-done
+	if got := p.TimerResets(); got <= nudged+1 {
+		t.Fatalf("expected interval ticks to keep resetting the timer, got %d (after nudges: %d)", got, nudged)
+	}
+}
+
+// TestStopAndCollect_ReturnsRemainderWithoutFlushing 验证 StopAndCollect 交还当前批次与缓冲数据、不调用 flush 并结束运行
+func TestStopAndCollect_ReturnsRemainderWithoutFlushing(t *testing.T) {
+	var calls int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(10).
+			WithFlushInterval(time.Hour),
+		okFlush[int](&calls))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(context.Background()) }()
+	for p.Done() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 1; i <= 5; i++ {
+		p.DataChan() <- i
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	items, err := p.StopAndCollect(ctx)
+	if err != nil {
+		t.Fatalf("StopAndCollect returned error: %v", err)
+	}
+	if len(items) != 5 {
+		t.Fatalf("expected all 5 items handed back, got %v", items)
+	}
+	for i, v := range items {
+		if v != i+1 {
+			t.Fatalf("expected items in arrival order, got %v", items)
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("expected SyncPerform to return nil after collect, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("expected flush not to be called for collected items, got %d calls", n)
+	}
+}
+
+// TestFlushSync_WaitsForThatFlush 验证 FlushSync 立即 flush 已写入的数据并等待该次 flush 完成、返回其错误
+func TestFlushSync_WaitsForThatFlush(t *testing.T) {
+	errWrite := errors.New("write failed")
+	var flushed atomic.Int32
+	var fail atomic.Bool
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			time.Sleep(20 * time.Millisecond)
+			flushed.Add(int32(len(batch)))
+			if fail.Load() {
+				return errWrite
+			}
+			return nil
+		})
+
+	if err := p.FlushSync(context.Background()); !errors.Is(err, gopipeline.ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning before start, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := p.Add(ctx, i); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	if err := p.FlushSync(ctx); err != nil {
+		t.Fatalf("expected FlushSync to succeed, got %v", err)
+	}
+	if n := flushed.Load(); n != 3 {
+		t.Fatalf("expected the 3 added items flushed before FlushSync returns, got %d", n)
+	}
+
+	fail.Store(true)
+	_ = p.Add(ctx, 3)
+	if err := p.FlushSync(ctx); !errors.Is(err, errWrite) {
+		t.Fatalf("expected FlushSync to return the flush error, got %v", err)
+	}
+	if err := p.FlushSync(ctx); err != nil {
+		t.Fatalf("expected nil for an empty batch, got %v", err)
+	}
+
+	close(p.DataChan())
+	<-done
+}
+
+// TestBarrier_CheckpointSeesAllPriorData 验证 Barrier 在 checkpoint 之前 flush 完屏障前的全部数据（含在飞的异步 flush），之后恢复接收
+func TestBarrier_CheckpointSeesAllPriorData(t *testing.T) {
+	var flushed atomic.Int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			time.Sleep(20 * time.Millisecond)
+			flushed.Add(int32(len(batch)))
+			return nil
+		})
+
+	if err := p.Barrier(context.Background(), nil); !errors.Is(err, gopipeline.ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning before start, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		if err := p.Add(ctx, i); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	var seen int32
+	errCheckpoint := errors.New("checkpoint failed")
+	if err := p.Barrier(ctx, func() error {
+		seen = flushed.Load()
+		return errCheckpoint
+	}); !errors.Is(err, errCheckpoint) {
+		t.Fatalf("expected the checkpoint error, got %v", err)
+	}
+	if seen != 5 {
+		t.Fatalf("expected all 5 prior items flushed before the checkpoint, got %d", seen)
+	}
+
+	if err := p.Add(ctx, 5); err != nil {
+		t.Fatalf("expected intake to resume after the barrier, got %v", err)
+	}
+	close(p.DataChan())
+	<-done
+	if n := flushed.Load(); n != 6 {
+		t.Fatalf("expected 6 items flushed in total, got %d", n)
+	}
+}
+
+// TestAdaptiveInterval 验证自适应间隔在批次偏满时缩短、偏小时延长，并受上下限约束
+func TestAdaptiveInterval(t *testing.T) {
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(10).
+			WithFlushInterval(time.Second),
+		func(ctx context.Context, batch []int) error { return nil })
+	p.WithAdaptiveInterval(gopipeline.AdaptiveInterval{
+		TargetFill:  0.7,
+		MinInterval: 100 * time.Millisecond,
+		MaxInterval: 2 * time.Second,
+		Window:      4,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	// 4 个满批次：平均填充率 1，间隔缩短为 1s × 0.7
+	for i := 0; i < 40; i++ {
+		_ = p.Add(ctx, i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for p.CurrentFlushInterval() == time.Second && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := p.CurrentFlushInterval(); got != 700*time.Millisecond {
+		t.Fatalf("expected interval shortened to 700ms, got %v", got)
+	}
+
+	// 每个窗口 4 次单条 flush：填充率 0.1，间隔每窗口至多翻倍，且不超过上限
+	for _, want := range []time.Duration{1400 * time.Millisecond, 2 * time.Second} {
+		for i := 0; i < 4; i++ {
+			_ = p.Add(ctx, i)
+			if err := p.FlushSync(ctx); err != nil {
+				t.Fatalf("FlushSync returned error: %v", err)
+			}
+		}
+		if got := p.CurrentFlushInterval(); got != want {
+			t.Fatalf("expected interval lengthened to %v, got %v", want, got)
+		}
+	}
+
+	close(p.DataChan())
+	<-done
+}
+
+// TestBatchReady_FlushesOnCommitOrTimeout 验证就绪批次等待 commit 后才 flush；超时未提交时照常 flush 并上报 ErrCommitTimeout
+func TestBatchReady_FlushesOnCommitOrTimeout(t *testing.T) {
+	var flushed atomic.Int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed.Add(int32(len(batch)))
+			return nil
+		})
+	commits := make(chan func() error, 2)
+	p.WithBatchReady(func(commit func() error) { commits <- commit }, 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+
+	// 提交路径：commit 之前批次不会 flush
+	p.DataChan() <- 1
+	p.DataChan() <- 2
+	commit := <-commits
+	time.Sleep(10 * time.Millisecond)
+	if n := flushed.Load(); n != 0 {
+		t.Fatalf("expected no flush before commit, got %d items", n)
+	}
+	if err := commit(); err != nil {
+		t.Fatalf("commit returned error: %v", err)
+	}
+	if n := flushed.Load(); n != 2 {
+		t.Fatalf("expected commit to return after the flush, got %d items", n)
+	}
+
+	// 超时路径：不提交时照常 flush 并上报 ErrCommitTimeout，迟到的 commit 返回 ErrCommitTimeout
+	p.DataChan() <- 3
+	p.DataChan() <- 4
+	late := <-commits
+	select {
+	case err := <-errs:
+		if !errors.Is(err, gopipeline.ErrCommitTimeout) {
+			t.Fatalf("expected ErrCommitTimeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ErrCommitTimeout to be reported")
+	}
+	if err := late(); !errors.Is(err, gopipeline.ErrCommitTimeout) {
+		t.Fatalf("expected late commit to return ErrCommitTimeout, got %v", err)
+	}
+
+	close(p.DataChan())
+	<-done
+	// 异步模式下超时批次在独立协程中 flush，运行结束时可能仍在进行
+	deadline := time.Now().Add(time.Second)
+	for flushed.Load() != 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := flushed.Load(); n != 4 {
+		t.Fatalf("expected the timed-out batch to be flushed anyway, got %d items", n)
+	}
+}
+
+// TestWithBaseContext 验证基础 ctx 的取消结束当前运行，且之后的运行立即按取消语义退出
+func TestWithBaseContext(t *testing.T) {
+	var calls int32
+	base, cancelBase := context.WithCancel(context.Background())
+	p := gopipeline.NewStandardPipeline[int](quickConfig(), okFlush[int](&calls))
+	p.WithBaseContext(base)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	cancelBase()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, gopipeline.ErrContextIsClosed) {
+			t.Fatalf("expected ErrContextIsClosed once the base context was canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the run to stop when the base context was canceled")
+	}
+
+	if err := p.SyncPerform(context.Background()); !errors.Is(err, gopipeline.ErrContextIsClosed) {
+		t.Fatalf("expected later runs to stop immediately, got %v", err)
+	}
+}
@@ -3,7 +3,10 @@ package gopipeline_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"log"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -192,6 +195,47 @@ func TestWithLogger(t *testing.T) {
 	// 可选地，我们可以检查 buf.Len() >= 0（显然恒为真）。
 }
 
+// TestBatchMeta_CorrelatesLogs 验证每个批次获得递增的关联 ID，并以该 ID 标注失败批次的日志
+func TestBatchMeta_CorrelatesLogs(t *testing.T) {
+	var buf bytes.Buffer
+	var metas []gopipeline.BatchMeta
+	p := gopipeline.NewStandardPipelineWithMeta(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, meta gopipeline.BatchMeta, batch []int) error {
+			metas = append(metas, meta)
+			if meta.ID == 2 {
+				return errors.New("write failed")
+			}
+			return nil
+		})
+	p.WithLogger(log.New(&buf, "", 0))
+	_ = p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(metas) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(metas))
+	}
+	for i, m := range metas {
+		if m.ID != uint64(i+1) || m.OpenedAt.IsZero() {
+			t.Fatalf("expected batch %d to carry ID %d and an open time, got %+v", i, i+1, m)
+		}
+	}
+	if out := buf.String(); !strings.Contains(out, "batch=2 flush failed: write failed") {
+		t.Fatalf("expected the failed batch to be logged with its ID, got %q", out)
+	}
+}
+
 // 断言：Done() 关闭后进行两值接收，ok 恒为 false（DrainOnCancel=true）
 func TestDoneTwoValueRecv_DrainOnCancel(t *testing.T) {
 	cfg := gopipeline.NewPipelineConfig().
@@ -305,3 +349,530 @@ func TestDoneTwoValueRecv_NoDrain_BufferLeft(t *testing.T) {
 		t.Fatalf("timeout reading from done snapshot")
 	}
 }
+
+// saturationHook 在 dummyHook 基础上实现了可选的 BufferSaturationHook 扩展
+type saturationHook struct {
+	dummyHook
+	samples int32
+	maxPct  int32 // 观测到的最大占用率（百分比）
+}
+
+func (h *saturationHook) BufferSaturation(ratio float64) {
+	atomic.AddInt32(&h.samples, 1)
+	pct := int32(ratio * 100)
+	for {
+		old := atomic.LoadInt32(&h.maxPct)
+		if pct <= old || atomic.CompareAndSwapInt32(&h.maxPct, old, pct) {
+			return
+		}
+	}
+}
+
+// TestBufferSaturationHook 验证主循环在定时器触发时上报缓冲占用率
+func TestBufferSaturationHook(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(10).
+		WithFlushSize(100).
+		WithFlushInterval(10 * time.Millisecond)
+
+	release := make(chan struct{})
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		// 阻塞第一次 flush，使后续数据堆积在缓冲中
+		<-release
+		return nil
+	})
+	h := &saturationHook{}
+	p.WithMetrics(h)
+
+	ch := p.DataChan()
+	ch <- 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(ctx) }()
+
+	// 等待第一次 flush 被阻塞后再填充缓冲，然后放行
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(release)
+	close(ch)
+
+	select {
+	case <-errCh:
+	case <-time.After(time.Second):
+		t.Fatal("pipeline did not finish in time")
+	}
+
+	if atomic.LoadInt32(&h.samples) < 1 {
+		t.Fatalf("expected BufferSaturation to be sampled at least once")
+	}
+	if got := atomic.LoadInt32(&h.maxPct); got < 0 || got > 100 {
+		t.Fatalf("saturation ratio out of range: %d%%", got)
+	}
+}
+
+// residenceHook 在 dummyHook 基础上实现了可选的 ItemResidenceHook 扩展
+type residenceHook struct {
+	dummyHook
+	observed int32
+}
+
+func (h *residenceHook) ItemResidence(d time.Duration) { atomic.AddInt32(&h.observed, 1) }
+
+// TestResidenceTracking 验证启用 WithResidenceTracking 后，flush 触发时按条观测批内驻留时长
+func TestResidenceTracking(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(100).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		return nil
+	})
+	h := &residenceHook{}
+	p.WithMetrics(h).WithResidenceTracking(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(ctx) }()
+
+	ch := p.DataChan()
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+	// 数据在批次中等待一段时间后由关闭路径 flush
+	time.Sleep(30 * time.Millisecond)
+	close(ch)
+	if err := <-errCh; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	stats := p.ResidenceStats()
+	if stats.Count != 3 {
+		t.Fatalf("expected 3 observed items, got %d", stats.Count)
+	}
+	if stats.Max < 20*time.Millisecond || stats.Mean() > stats.Max {
+		t.Fatalf("unexpected residence stats: %+v (mean %v)", stats, stats.Mean())
+	}
+	if got := atomic.LoadInt32(&h.observed); got != 3 {
+		t.Fatalf("expected ItemResidence hook to observe 3 items, got %d", got)
+	}
+}
+
+// fillHook 在 dummyHook 基础上实现了可选的 FillDurationHook 扩展
+type fillHook struct {
+	dummyHook
+	mu    sync.Mutex
+	fills []time.Duration
+}
+
+func (h *fillHook) FillDuration(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fills = append(h.fills, d)
+}
+
+// lingerHook 在 dummyHook 基础上实现了可选的 FlushLingerHook 扩展
+type lingerHook struct {
+	dummyHook
+	timeouts atomic.Int32
+}
+
+func (h *lingerHook) FlushLingerTimeout(timeout time.Duration) { h.timeouts.Add(1) }
+
+// TestMaxFlushLingerTimeout 验证主循环退出后卡住的异步 flush 在 MaxFlushLingerTimeout 后被取消并上报，Done 随之关闭
+func TestMaxFlushLingerTimeout(t *testing.T) {
+	canceled := make(chan struct{})
+	hook := &lingerHook{}
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour).
+			WithMaxFlushLingerTimeout(50*time.Millisecond),
+		func(ctx context.Context, batch []int) error {
+			<-ctx.Done()
+			close(canceled)
+			return ctx.Err()
+		})
+	p.WithMetrics(hook)
+
+	done, _ := p.Start(context.Background())
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected done once the linger timeout canceled the stuck flush")
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the lingering flush ctx to be canceled")
+	}
+	if n := hook.timeouts.Load(); n != 1 {
+		t.Fatalf("expected one linger timeout reported, got %d", n)
+	}
+}
+
+// TestFillDuration 验证每个非空批次上报从首条数据入批到 flush 触发的组批耗时，且计入 Stats
+func TestFillDuration(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		return nil
+	})
+	h := &fillHook{}
+	p.WithMetrics(h)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(ctx) }()
+
+	// 第一批的第二条数据延迟到达：组批慢；第二批两条数据连续到达：组批快
+	ch := p.DataChan()
+	ch <- 1
+	time.Sleep(30 * time.Millisecond)
+	ch <- 2
+	ch <- 3
+	ch <- 4
+	close(ch)
+	if err := <-errCh; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.fills) != 2 || h.fills[0] < 20*time.Millisecond || h.fills[1] >= h.fills[0] {
+		t.Fatalf("expected a slow then a fast fill, got %v", h.fills)
+	}
+	if st := p.Stats(); st.FilledBatches != 2 || st.FillDuration != h.fills[0]+h.fills[1] {
+		t.Fatalf("expected stats to match hook observations, got %+v", st)
+	}
+}
+
+// TestFillDuration_FlushSizeOne 验证 FlushSize == 1 时每条数据都按零组批耗时上报并计入 Stats
+func TestFillDuration_FlushSizeOne(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		return nil
+	})
+	h := &fillHook{}
+	p.WithMetrics(h)
+
+	ch := p.DataChan()
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.fills) != 3 {
+		t.Fatalf("expected one fill observation per item, got %v", h.fills)
+	}
+	if st := p.Stats(); st.FilledBatches != 3 {
+		t.Fatalf("expected 3 filled batches in stats, got %+v", st)
+	}
+}
+
+// namedHook 在 dummyHook 基础上实现了可选的 PipelineNameHook 扩展
+type namedHook struct {
+	dummyHook
+	name string
+}
+
+func (h *namedHook) PipelineName(name string) { h.name = name }
+
+// TestWithName 验证名称标签出现在内部日志中并传递给指标钩子
+func TestWithName(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	hook := &namedHook{}
+
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(4).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		panic("boom")
+	}).WithMetrics(hook).WithLogger(logger).WithName("orders")
+
+	if p.Name() != "orders" || hook.name != "orders" {
+		t.Fatalf("expected name to reach pipeline and hook, got %q / %q", p.Name(), hook.name)
+	}
+
+	ch := p.DataChan()
+	ch <- 1
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = p.SyncPerform(ctx)
+
+	if !bytes.Contains(buf.Bytes(), []byte("[orders] panic recovered in pipeline")) {
+		t.Fatalf("expected named log line, got %q", buf.String())
+	}
+}
+
+// TestOnCloseOnCancel 验证数据通道关闭与 ctx 取消分别触发对应回调
+func TestOnCloseOnCancel(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(4).
+		WithFlushSize(10).
+		WithFlushInterval(time.Hour)
+
+	var closed, canceled, flushedBeforeClose int32
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		atomic.AddInt32(&flushedBeforeClose, int32(len(batch)))
+		return nil
+	})
+	p.WithOnClose(func() {
+		if atomic.LoadInt32(&flushedBeforeClose) != 2 {
+			t.Errorf("expected final flush to complete before OnClose")
+		}
+		atomic.AddInt32(&closed, 1)
+	}).WithOnCancel(func() { atomic.AddInt32(&canceled, 1) })
+
+	// 取消路径
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.SyncPerform(ctx); !errors.Is(err, gopipeline.ErrContextIsClosed) {
+		t.Fatalf("expected ErrContextIsClosed, got %v", err)
+	}
+	if atomic.LoadInt32(&canceled) != 1 || atomic.LoadInt32(&closed) != 0 {
+		t.Fatalf("expected only OnCancel, got closed=%d canceled=%d", closed, canceled)
+	}
+
+	// 关闭路径
+	p.DataChan() <- 1
+	p.DataChan() <- 2
+	close(p.DataChan())
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("expected nil on close path, got %v", err)
+	}
+	if atomic.LoadInt32(&closed) != 1 || atomic.LoadInt32(&canceled) != 1 {
+		t.Fatalf("expected OnClose once, got closed=%d canceled=%d", closed, canceled)
+	}
+}
+
+// TestStrictConfig 验证 FlushSize > BufferSize 时默认仅记录告警，严格模式下拒绝运行
+func TestStrictConfig(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(4).
+		WithFlushSize(8).
+		WithFlushInterval(time.Hour)
+	if err := cfg.Validate(); !errors.Is(err, gopipeline.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig from Validate, got %v", err)
+	}
+	if err := cfg.WithBufferSize(0).Validate(); err != nil {
+		t.Fatalf("expected unbuffered mode to pass validation, got %v", err)
+	}
+
+	// 告警在构造时输出（此时尚未注入日志器，写入标准库 log）
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+	lenient := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error { return nil })
+	close(lenient.DataChan())
+	for i := 0; i < 2; i++ {
+		if err := lenient.SyncPerform(context.Background()); err != nil {
+			t.Fatalf("expected lenient config to run, got %v", err)
+		}
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("FlushSize (8) exceeds BufferSize (4)")); n != 1 {
+		t.Fatalf("expected the config warning to be logged exactly once, got %d in %q", n, buf.String())
+	}
+
+	strict := gopipeline.NewStandardPipeline[int](cfg.WithStrictConfig(true), func(ctx context.Context, batch []int) error { return nil })
+	done, errs := strict.Start(context.Background())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected strict run to finish immediately")
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, gopipeline.ErrInvalidConfig) {
+			t.Fatalf("expected ErrInvalidConfig, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ErrInvalidConfig to be reported")
+	}
+}
+
+// TestRecentLatencies 验证环形缓冲只保留最近 N 次 flush 的耗时，并按从旧到新排列
+func TestRecentLatencies(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour)
+
+	var n int
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		n++
+		time.Sleep(time.Duration(n) * 5 * time.Millisecond) // 耗时逐次递增
+		return nil
+	})
+	if got := p.RecentLatencies(); got != nil {
+		t.Fatalf("expected nil latencies when disabled, got %v", got)
+	}
+	p.WithRecentLatencies(3)
+
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	got := p.RecentLatencies()
+	if len(got) != 3 {
+		t.Fatalf("expected the last 3 latencies, got %v", got)
+	}
+	for i, d := range got {
+		// 第 i 个应为第 i+3 次 flush 的耗时（至少 (i+3)*5ms）
+		if min := time.Duration(i+3) * 5 * time.Millisecond; d < min {
+			t.Fatalf("expected latencies of flushes 3..5 from oldest to newest, got %v", got)
+		}
+	}
+}
+
+// TestSuccessRate 验证滑动窗口内的 flush 成功率，以及窗口过期后恢复为 1
+func TestSuccessRate(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour)
+
+	var n int
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		n++
+		if n%4 == 0 {
+			return errors.New("flush failed")
+		}
+		return nil
+	})
+	p.WithSuccessWindow(200*time.Millisecond, 4)
+	go func() {
+		for range p.ErrorChan(8) {
+		}
+	}()
+
+	ch := p.DataChan()
+	for i := 0; i < 8; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if got := p.SuccessRate(); got != 0.75 {
+		t.Fatalf("expected success rate 0.75 within the window, got %v", got)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if got := p.SuccessRate(); got != 1 {
+		t.Fatalf("expected success rate 1 once the window expired, got %v", got)
+	}
+}
+
+// TestAvgBatchSize 验证批大小的指数移动平均：首个批次作为初值，之后按 alpha 平滑
+func TestAvgBatchSize(t *testing.T) {
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(4).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error { return nil })
+	if got := p.AvgBatchSize(); got != 0 {
+		t.Fatalf("expected 0 when disabled, got %v", got)
+	}
+	p.WithBatchSizeEMA(0.5)
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	// 批次 4、4、2：4 → 4 → 4 + 0.5×(2-4) = 3
+	if got := p.AvgBatchSize(); got != 3 {
+		t.Fatalf("expected average batch size 3, got %v", got)
+	}
+}
+
+// labeledHook 在 dummyHook 基础上实现了可选的 LabeledFlushHook 扩展
+type labeledHook struct {
+	dummyHook
+	mu        sync.Mutex
+	items     map[string]int
+	unlabeled int32
+}
+
+func (h *labeledHook) Flush(items int, duration time.Duration) { atomic.AddInt32(&h.unlabeled, 1) }
+
+func (h *labeledHook) FlushLabeled(label string, items int, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.items[label] += items
+}
+
+// TestMetricsLabeler 验证配置 WithMetricsLabeler 后按批次标签上报 flush，且不再重复调用 Flush
+func TestMetricsLabeler(t *testing.T) {
+	type event struct {
+		tenant string
+		n      int
+	}
+	cfg := gopipeline.NewPipelineConfig().WithBufferSize(8).WithFlushSize(2).WithFlushInterval(time.Hour)
+	h := &labeledHook{items: map[string]int{}}
+	p := gopipeline.NewStandardPipeline(cfg, func(ctx context.Context, batch []event) error { return nil })
+	p.WithMetrics(h).WithMetricsLabeler(func(batch []event) string {
+		if len(batch) == 0 {
+			return ""
+		}
+		return batch[0].tenant
+	})
+
+	ch := p.DataChan()
+	for _, e := range []event{{"a", 1}, {"a", 2}, {"b", 3}, {"b", 4}, {"b", 5}} {
+		ch <- e
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.items["a"] != 2 || h.items["b"] != 3 {
+		t.Fatalf("expected per-label item counts a=2 b=3, got %v", h.items)
+	}
+	if n := atomic.LoadInt32(&h.unlabeled); n != 0 {
+		t.Fatalf("expected Flush not to be called when labeled, got %d calls", n)
+	}
+}
@@ -0,0 +1,70 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+type tenantEvent struct {
+	Tenant string
+	Seq    int
+}
+
+// TestKeyedPipeline_GroupsPerKey 验证按键分组、每组一次 flush，且失败按分组隔离
+func TestKeyedPipeline_GroupsPerKey(t *testing.T) {
+	errTenant := errors.New("tenant b unavailable")
+	groups := map[string][]int{}
+	var order []string
+
+	p := gopipeline.NewKeyedPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(6).
+			WithFlushInterval(time.Hour),
+		func(e tenantEvent) string { return e.Tenant },
+		func(ctx context.Context, key string, batch []tenantEvent) error {
+			order = append(order, key)
+			for _, e := range batch {
+				groups[key] = append(groups[key], e.Seq)
+			}
+			if key == "b" {
+				return errTenant
+			}
+			return nil
+		})
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i, tenant := range []string{"a", "b", "a", "c", "b", "a"} {
+		ch <- tenantEvent{Tenant: tenant, Seq: i}
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if strings.Join(order, ",") != "a,b,c" {
+		t.Fatalf("expected one flush per key in first-seen order, got %v", order)
+	}
+	if got := groups["a"]; len(got) != 3 || got[0] != 0 || got[1] != 2 || got[2] != 5 {
+		t.Fatalf("unexpected group a: %v", got)
+	}
+
+	select {
+	case err := <-errs:
+		var kerr *gopipeline.KeyedFlushError
+		if !errors.As(err, &kerr) || kerr.Key != "b" || !errors.Is(err, errTenant) {
+			t.Fatalf("expected KeyedFlushError for key b, got %v", err)
+		}
+	default:
+		t.Fatal("expected failed group to be reported")
+	}
+}
@@ -0,0 +1,55 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestLIFOPipeline_NewestFirst 验证积压时批次由最新的数据组成，栈满按 DropOldest 丢弃栈底，Close 后取尽剩余数据
+func TestLIFOPipeline_NewestFirst(t *testing.T) {
+	var batches [][]int
+	p := gopipeline.NewLIFOPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(5).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour).
+			WithOverloadPolicy(gopipeline.DropOldest),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, append([]int(nil), batch...))
+			return nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 6; i++ {
+		if err := p.Add(ctx, i); err != nil {
+			t.Fatalf("Add(%d) returned error: %v", i, err)
+		}
+	}
+	if err := p.TryAdd(6); !errors.Is(err, gopipeline.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull from TryAdd on a full stack, got %v", err)
+	}
+	if p.Len() != 5 || p.Dropped() != 1 {
+		t.Fatalf("expected 5 stacked and 1 dropped, got %d stacked, %d dropped", p.Len(), p.Dropped())
+	}
+	p.Close()
+	if err := p.Add(ctx, 7); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed after Close, got %v", err)
+	}
+
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	want := [][]int{{5, 4}, {3, 2}, {1}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Fatalf("expected newest-first batches %v, got %v", want, batches)
+	}
+	if p.Len() != 0 {
+		t.Fatalf("expected the stack to be drained, got %d", p.Len())
+	}
+}
@@ -150,3 +150,108 @@ func itoa(u uint32) string {
 	}
 	return string(buf[i:])
 }
+
+// TestSetMaxConcurrentFlushes 验证运行前后均可读取当前上限，调大后新发起的异步 flush 按新上限并行
+func TestSetMaxConcurrentFlushes(t *testing.T) {
+	var current, maxObserved int32
+	release := make(chan struct{})
+	flush := func(ctx context.Context, batch []int) error {
+		cur := atomic.AddInt32(&current, 1)
+		for {
+			old := atomic.LoadInt32(&maxObserved)
+			if cur <= old || atomic.CompareAndSwapInt32(&maxObserved, old, cur) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&current, -1)
+		return nil
+	}
+
+	p := gopipeline.NewStandardPipeline[int](gopipeline.NewPipelineConfig().
+		WithBufferSize(64).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour).
+		WithMaxConcurrentFlushes(1), flush)
+	if got := p.MaxConcurrentFlushes(); got != 1 {
+		t.Fatalf("expected configured cap 1, got %d", got)
+	}
+	p.SetMaxConcurrentFlushes(3)
+	if got := p.MaxConcurrentFlushes(); got != 3 {
+		t.Fatalf("expected cap 3 after resize, got %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	done, _ := p.Start(ctx)
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	// 等待并发达到新上限，且不会超过
+	for atomic.LoadInt32(&maxObserved) < 3 && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&maxObserved); got != 3 {
+		t.Fatalf("expected 3 concurrent flushes under the resized cap, got %d", got)
+	}
+	close(release)
+	close(ch)
+	<-done
+
+	p.SetMaxConcurrentFlushes(0)
+	if got := p.MaxConcurrentFlushes(); got != 0 {
+		t.Fatalf("expected unlimited (0) after reset, got %d", got)
+	}
+}
+
+// TestSetMaxConcurrentFlushes_DecreaseHoldsImmediately 验证调小上限后立即生效：在飞 flush 降到新上限以下前不会发起新的 flush
+func TestSetMaxConcurrentFlushes_DecreaseHoldsImmediately(t *testing.T) {
+	var started int32
+	release := make(chan struct{})
+	p := gopipeline.NewStandardPipeline[int](gopipeline.NewPipelineConfig().
+		WithBufferSize(64).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour).
+		WithMaxConcurrentFlushes(3), func(ctx context.Context, batch []int) error {
+		atomic.AddInt32(&started, 1)
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	done, _ := p.Start(ctx)
+	ch := p.DataChan()
+	for i := 0; i < 6; i++ {
+		ch <- i
+	}
+	waitStarted := func(n int32) {
+		for atomic.LoadInt32(&started) < n && ctx.Err() == nil {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := atomic.LoadInt32(&started); got != n {
+			t.Fatalf("expected %d flushes started, got %d", n, got)
+		}
+	}
+	waitStarted(3)
+
+	p.SetMaxConcurrentFlushes(1)
+	if got := p.Config().MaxConcurrentFlushes; got != 1 {
+		t.Fatalf("expected Config to report the resized cap 1, got %d", got)
+	}
+	// 3 → 2 → 1 条在飞：仍不低于新上限，不应发起新的 flush
+	release <- struct{}{}
+	waitStarted(3)
+	release <- struct{}{}
+	waitStarted(3)
+	// 全部完成后按新上限逐个发起
+	release <- struct{}{}
+	waitStarted(4)
+
+	close(release)
+	close(ch)
+	<-done
+}
@@ -173,3 +173,58 @@ func TestPipeline_PointerNoDuplicateMemoryButShared(t *testing.T) {
 		t.Fatalf("pointer target should be shared (no duplicate copy): got[0]=%d got[7]=%d", got[0], got[7])
 	}
 }
+
+// 验证：WithDeepCopy 使异步 flush 持有指针负载的拷贝，生产者在慢 flush 期间修改原对象不产生数据竞争（需配合 -race 运行）
+func TestPipeline_PointerDeepCopyUnderAsyncFlush(t *testing.T) {
+	type Record struct {
+		ID   int
+		Tags []string
+	}
+
+	cfg := gopipeline.NewPipelineConfig().
+		WithFlushSize(4).
+		WithBufferSize(16).
+		WithFlushInterval(time.Hour)
+
+	flushing := make(chan struct{})
+	seen := make(chan []Record, 1)
+	p := gopipeline.NewStandardPipeline[*Record](cfg, func(ctx context.Context, batch []*Record) error {
+		close(flushing)
+		// 慢 flush：生产者在此期间修改原对象
+		time.Sleep(20 * time.Millisecond)
+		out := make([]Record, 0, len(batch))
+		for _, r := range batch {
+			out = append(out, Record{ID: r.ID, Tags: append([]string(nil), r.Tags...)})
+		}
+		seen <- out
+		return nil
+	})
+	p.WithDeepCopy(func(r *Record) *Record {
+		return &Record{ID: r.ID, Tags: append([]string(nil), r.Tags...)}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go func() { _ = p.AsyncPerform(ctx) }()
+
+	src := make([]*Record, 4)
+	for i := range src {
+		src[i] = &Record{ID: i, Tags: []string{"orig"}}
+		if err := p.Add(ctx, src[i]); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+
+	<-flushing
+	for _, r := range src {
+		r.ID += 100
+		r.Tags[0] = "mutated"
+	}
+
+	got := <-seen
+	for i, r := range got {
+		if r.ID != i || r.Tags[0] != "orig" {
+			t.Fatalf("expected flushed copy to keep values at send time, got %+v", r)
+		}
+	}
+}
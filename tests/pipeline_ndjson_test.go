@@ -0,0 +1,63 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+type ndjsonEvent struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+// TestDecodeNDJSON 验证逐行解码、跳过空行、处理超长行与无结尾换行，并上报解码错误
+func TestDecodeNDJSON(t *testing.T) {
+	long := strings.Repeat("x", 128*1024) // 超过 bufio.Scanner 默认的 64KB 行限制
+	input := `{"id":1,"body":"a"}` + "\n" +
+		"\n" +
+		`{"id":2,"body":"` + long + `"}` + "\n" +
+		`not json` + "\n" +
+		`{"id":3,"body":"c"}` // 最后一行没有换行符
+
+	var mu sync.Mutex
+	var got []ndjsonEvent
+	done, errs := gopipeline.DecodeNDJSON(context.Background(), strings.NewReader(input),
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []ndjsonEvent) error {
+			mu.Lock()
+			got = append(got, batch...)
+			mu.Unlock()
+			return nil
+		})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for DecodeNDJSON to finish")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 3 || got[0].ID != 1 || got[1].ID != 2 || len(got[1].Body) != len(long) || got[2].ID != 3 {
+		t.Fatalf("expected events 1, 2 (long body), 3; got %d events", len(got))
+	}
+
+	select {
+	case err := <-errs:
+		var derr *gopipeline.NDJSONDecodeError
+		if !errors.As(err, &derr) || derr.Line != 4 {
+			t.Fatalf("expected decode error on line 4, got %v", err)
+		}
+	default:
+		t.Fatal("expected decode error to be reported")
+	}
+}
@@ -0,0 +1,79 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestNextFlush_MultipleWaiters 验证多个并发等待者都会在下一次 flush 完成后返回该次 flush 的结果
+func TestNextFlush_MultipleWaiters(t *testing.T) {
+	errFlush := errors.New("flush failed")
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(16).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		if batch[0] < 0 {
+			return errFlush
+		}
+		return nil
+	})
+	_ = p.ErrorChan(8)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	runDone := make(chan error, 1)
+	go func() { runDone <- p.SyncPerform(ctx) }()
+
+	waitAll := func(n int, send func()) []error {
+		var wg sync.WaitGroup
+		results := make([]error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = p.NextFlush(ctx)
+			}(i)
+		}
+		// 给等待者注册的时间，再触发 flush
+		time.Sleep(20 * time.Millisecond)
+		send()
+		wg.Wait()
+		return results
+	}
+
+	ch := p.DataChan()
+	for i, err := range waitAll(3, func() { ch <- 1; ch <- 2 }) {
+		if err != nil {
+			t.Fatalf("waiter %d: expected nil from successful flush, got %v", i, err)
+		}
+	}
+	for i, err := range waitAll(2, func() { ch <- -1; ch <- -2 }) {
+		if !errors.Is(err, errFlush) {
+			t.Fatalf("waiter %d: expected flush error, got %v", i, err)
+		}
+	}
+
+	close(ch)
+	if err := <-runDone; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+}
+
+// TestNextFlush_ContextTimeout 验证没有 flush 发生时 NextFlush 随 ctx 超时返回
+func TestNextFlush_ContextTimeout(t *testing.T) {
+	p := gopipeline.NewStandardPipeline[int](gopipeline.NewPipelineConfig(), func(ctx context.Context, batch []int) error {
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.NextFlush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestOrderedCommitPipeline 验证准备阶段并行执行、提交阶段按派发顺序串行执行，且准备失败不阻塞后续批次
+func TestOrderedCommitPipeline(t *testing.T) {
+	errPrepare := errors.New("prepare failed")
+	var inPrepare, maxInPrepare atomic.Int32
+	var mu sync.Mutex
+	var seqs []uint64
+	var committed []int
+
+	p := gopipeline.NewOrderedCommitPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(32).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) ([]int, error) {
+			n := inPrepare.Add(1)
+			defer inPrepare.Add(-1)
+			for {
+				old := maxInPrepare.Load()
+				if n <= old || maxInPrepare.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			// 越早的批次准备越慢，迫使后续批次先完成准备
+			time.Sleep(time.Duration(10-batch[0]/2) * 3 * time.Millisecond)
+			if batch[0] == 4 {
+				return nil, errPrepare
+			}
+			return append([]int(nil), batch...), nil
+		},
+		func(ctx context.Context, seq uint64, prepared []int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seqs = append(seqs, seq)
+			committed = append(committed, prepared...)
+			return nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done, errs := p.Start(ctx)
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+	<-done
+
+	// 异步 flush 不被 done 等待：等待最后一个批次提交
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(seqs)
+		mu.Unlock()
+		if n == 4 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	wantSeqs := []uint64{0, 1, 3, 4}
+	wantItems := []int{0, 1, 2, 3, 6, 7, 8, 9}
+	if len(seqs) != len(wantSeqs) || len(committed) != len(wantItems) {
+		t.Fatalf("expected seqs %v and items %v, got %v and %v", wantSeqs, wantItems, seqs, committed)
+	}
+	for i := range wantSeqs {
+		if seqs[i] != wantSeqs[i] {
+			t.Fatalf("expected commits in seq order %v, got %v", wantSeqs, seqs)
+		}
+	}
+	for i := range wantItems {
+		if committed[i] != wantItems[i] {
+			t.Fatalf("expected items committed in order %v, got %v", wantItems, committed)
+		}
+	}
+	if maxInPrepare.Load() < 2 {
+		t.Fatalf("expected prepares to overlap, max concurrent %d", maxInPrepare.Load())
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errPrepare) {
+			t.Fatalf("expected prepare error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected prepare failure to be reported")
+	}
+}
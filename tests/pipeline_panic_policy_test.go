@@ -0,0 +1,165 @@
+package gopipeline_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+func newPanickingPipeline(policy gopipeline.PanicPolicy) *gopipeline.StandardPipeline[int] {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(8).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour).
+		WithPanicPolicy(policy)
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		panic("boom")
+	})
+	p.WithLogger(log.New(&bytes.Buffer{}, "", 0))
+	return p
+}
+
+// 默认策略（PanicRecover）：恢复并继续运行，不上报错误
+func TestPanicPolicy_RecoverByDefault(t *testing.T) {
+	if gopipeline.NewPipelineConfig().PanicPolicy != gopipeline.PanicRecover {
+		t.Fatal("expected PanicRecover as default policy")
+	}
+	p := newPanickingPipeline(gopipeline.PanicRecover)
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i := 0; i < 4; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("expected nil after recovered panics, got %v", err)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("expected no reported error with PanicRecover, got %v", err)
+	default:
+	}
+}
+
+// 默认策略下 panic 虽不上报错误通道，但 FlushSync/NextFlush 返回 ErrFlushPanic，成功率计为失败
+func TestPanicPolicy_RecoverCountsAsFailure(t *testing.T) {
+	p := newPanickingPipeline(gopipeline.PanicRecover)
+	p.WithSuccessWindow(time.Minute, 1)
+	errs := p.ErrorChan(4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done, _ := p.Start(ctx)
+
+	next := make(chan error, 1)
+	go func() { next <- p.NextFlush(ctx) }()
+	// 等待 NextFlush 登记后再触发 flush
+	time.Sleep(10 * time.Millisecond)
+	if err := p.Add(ctx, 1); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if err := p.FlushSync(ctx); !errors.Is(err, gopipeline.ErrFlushPanic) {
+		t.Fatalf("expected FlushSync to return ErrFlushPanic, got %v", err)
+	}
+	if err := <-next; !errors.Is(err, gopipeline.ErrFlushPanic) {
+		t.Fatalf("expected NextFlush to return ErrFlushPanic, got %v", err)
+	}
+	if got := p.SuccessRate(); got != 0 {
+		t.Fatalf("expected panicked flush to count as failure, got success rate %v", got)
+	}
+
+	close(p.DataChan())
+	<-done
+	select {
+	case err := <-errs:
+		t.Fatalf("expected no reported error with PanicRecover, got %v", err)
+	default:
+	}
+}
+
+// PanicRecoverAndReport：恢复并通过错误通道上报 ErrFlushPanic
+func TestPanicPolicy_RecoverAndReport(t *testing.T) {
+	p := newPanickingPipeline(gopipeline.PanicRecoverAndReport)
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("expected nil after recovered panic, got %v", err)
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, gopipeline.ErrFlushPanic) {
+			t.Fatalf("expected ErrFlushPanic, got %v", err)
+		}
+	default:
+		t.Fatal("expected panic to be reported on ErrorChan")
+	}
+}
+
+// PanicRethrow：记录日志后重新抛出，SyncPerform 中 panic 传播至调用方
+func TestPanicPolicy_Rethrow(t *testing.T) {
+	p := newPanickingPipeline(gopipeline.PanicRethrow)
+
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("expected rethrown panic \"boom\", got %v", r)
+		}
+	}()
+	_ = p.SyncPerform(ctx)
+	t.Fatal("expected SyncPerform to panic with PanicRethrow")
+}
+
+// WithPanicStackTrace：恢复 panic 时通过日志器输出完整调用栈，默认不输出
+func TestPanicPolicy_StackTrace(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var buf bytes.Buffer
+		cfg := gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour).
+			WithPanicStackTrace(enabled)
+		p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+			panic("boom")
+		})
+		p.WithLogger(log.New(&buf, "", 0))
+
+		ch := p.DataChan()
+		ch <- 1
+		ch <- 2
+		close(ch)
+		if err := p.SyncPerform(context.Background()); err != nil {
+			t.Fatalf("expected nil after recovered panic, got %v", err)
+		}
+
+		out := buf.String()
+		if !strings.Contains(out, "panic recovered in pipeline") {
+			t.Fatalf("expected recovered panic to be logged, got %q", out)
+		}
+		if got := strings.Contains(out, "runtime/debug.Stack"); got != enabled {
+			t.Fatalf("PanicStackTrace=%v: expected stack in log = %v, got %q", enabled, enabled, out)
+		}
+	}
+}
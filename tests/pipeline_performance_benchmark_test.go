@@ -240,3 +240,54 @@ func BenchmarkPipelineMemoryUsage(b *testing.B) {
 		time.Sleep(time.Millisecond * 10)
 	}
 }
+
+// BenchmarkPipelineMemoryUsageResults 与 BenchmarkPipelineMemoryUsage 相同的负载，结果切片改由 NewResultsPipeline 池化借出
+func BenchmarkPipelineMemoryUsageResults(b *testing.B) {
+	var processedCount int64
+
+	pipeline := gopipeline.NewResultsPipeline(
+		gopipeline.PipelineConfig{
+			BufferSize:    200,
+			FlushSize:     50,
+			FlushInterval: time.Millisecond * 10,
+		},
+		func(ctx context.Context, batchData []BenchmarkTestData, out []map[string]interface{}) ([]map[string]interface{}, error) {
+			// 模拟内存密集型操作
+			for i, data := range batchData {
+				out = append(out, map[string]interface{}{
+					"name":    data.Name,
+					"address": data.Address,
+					"age":     data.Age,
+					"id":      fmt.Sprintf("ID-%d", i),
+				})
+			}
+			atomic.AddInt64(&processedCount, int64(len(batchData)))
+			return out, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go pipeline.AsyncPerform(ctx)
+	dataChan := pipeline.DataChan()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		dataChan <- BenchmarkTestData{
+			Name:    fmt.Sprintf("MemUser%d", i),
+			Address: fmt.Sprintf("MemAddr%d", i),
+			Age:     uint(25 + i%40),
+		}
+	}
+
+	b.StopTimer()
+	close(dataChan)
+
+	// 等待处理完成
+	deadline := time.Now().Add(time.Second * 10)
+	for atomic.LoadInt64(&processedCount) < int64(b.N) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+}
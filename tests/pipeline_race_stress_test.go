@@ -255,3 +255,62 @@ func TestRace_MaxConcurrentFlushes(t *testing.T) {
 		t.Fatalf("MaxConcurrentFlushes exceeded: got %d, want <= 2", got)
 	}
 }
+
+// Race: close and cancel both ready when the loop returns to select; the final partial batch must be flushed exactly once
+// (or, with DropOnCloseAfterCancel, never), whichever branch select picks.
+// The loop is pinned deterministically by blocking the injected size estimator (called on the loop goroutine for direct
+// DataChan sends) until both close and cancel have happened.
+func TestRace_CloseAndCancelFinalFlushOnce(t *testing.T) {
+	run := func(t *testing.T, dropAfterCancel bool) {
+		for i := 0; i < 50; i++ {
+			var flushes, items atomic.Int32
+			cfg := gopipeline.NewPipelineConfig().
+				WithBufferSize(4).
+				WithFlushSize(10).
+				WithFlushInterval(time.Hour).
+				WithMaxBufferedBytes(1 << 20).
+				WithDropOnCloseAfterCancel(dropAfterCancel)
+			p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+				flushes.Add(1)
+				items.Add(int32(len(batch)))
+				return nil
+			})
+			gate := make(chan struct{})
+			reached := make(chan struct{})
+			p.WithSizeOf(func(v int) int {
+				if v == 3 {
+					close(reached)
+					<-gate
+				}
+				return 1
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- p.SyncPerform(ctx) }()
+
+			ch := p.DataChan()
+			ch <- 1
+			ch <- 2
+			ch <- 3
+			<-reached
+			close(ch)
+			cancel()
+			close(gate)
+
+			err := <-done
+			if dropAfterCancel {
+				if flushes.Load() != 0 || !errors.Is(err, gopipeline.ErrContextIsClosed) {
+					t.Fatalf("iteration %d: expected batch dropped with ErrContextIsClosed, got %d flushes, err %v", i, flushes.Load(), err)
+				}
+				continue
+			}
+			if flushes.Load() != 1 || items.Load() != 3 || err != nil {
+				t.Fatalf("iteration %d: expected one final flush of 3 items and nil error, got %d flushes, %d items, err %v",
+					i, flushes.Load(), items.Load(), err)
+			}
+		}
+	}
+	t.Run("flush", func(t *testing.T) { run(t, false) })
+	t.Run("drop_on_close_after_cancel", func(t *testing.T) { run(t, true) })
+}
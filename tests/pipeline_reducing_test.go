@@ -0,0 +1,51 @@
+package gopipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestReducingPipeline 验证每个批次被归约进累计结果，且结果通道只保留最新值
+func TestReducingPipeline(t *testing.T) {
+	p := gopipeline.NewReducingPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		100,
+		func(sum int, batch []int) int {
+			for _, v := range batch {
+				sum += v
+			}
+			return sum
+		})
+
+	ch := p.DataChan()
+	for i := 1; i <= 10; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if got := p.Result(); got != 155 {
+		t.Fatalf("expected accumulated result 155, got %d", got)
+	}
+	select {
+	case got := <-p.Results():
+		if got != 155 {
+			t.Fatalf("expected the latest published result 155, got %d", got)
+		}
+	default:
+		t.Fatal("expected a published result")
+	}
+	select {
+	case got := <-p.Results():
+		t.Fatalf("expected only the latest result to be kept, got extra %d", got)
+	default:
+	}
+}
@@ -0,0 +1,219 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestRetryQueue_ReplaysUntilSuccess 验证失败批次进入重试队列并在后续重放中成功
+func TestRetryQueue_ReplaysUntilSuccess(t *testing.T) {
+	var calls int32
+	var succeeded int32
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(8).
+		WithFlushSize(4).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		// 前两次失败，第三次成功
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return errors.New("transient")
+		}
+		atomic.AddInt32(&succeeded, int32(len(batch)))
+		return nil
+	})
+	var deadLetters int32
+	p.WithRetryQueue(4, 5*time.Millisecond, 3).
+		WithDeadLetter(func(ctx context.Context, batch any, err error) { atomic.AddInt32(&deadLetters, 1) })
+	_ = p.ErrorChan(8)
+
+	ch := p.DataChan()
+	for i := 0; i < 4; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&succeeded) != 4 {
+		select {
+		case <-deadline:
+			t.Fatalf("timeout waiting for retry to succeed, calls=%d", atomic.LoadInt32(&calls))
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("expected 3 flush attempts, got %d", got)
+	}
+	if got := atomic.LoadInt32(&deadLetters); got != 0 {
+		t.Fatalf("expected no dead letters, got %d", got)
+	}
+	if got := p.PendingRetries(); got != 0 {
+		t.Fatalf("expected empty retry queue, got %d", got)
+	}
+}
+
+// TestRetryQueue_DeadLetterAfterMaxAttempts 验证超过最大尝试次数后进入死信处理
+func TestRetryQueue_DeadLetterAfterMaxAttempts(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	var calls int32
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(8).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		atomic.AddInt32(&calls, 1)
+		return errPermanent
+	})
+
+	var mu sync.Mutex
+	var dead []any
+	var deadErr error
+	p.WithRetryQueue(4, 5*time.Millisecond, 2).
+		WithDeadLetter(func(ctx context.Context, batch any, err error) {
+			mu.Lock()
+			dead = append(dead, batch)
+			deadErr = err
+			mu.Unlock()
+		})
+	_ = p.ErrorChan(8)
+
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = p.SyncPerform(ctx)
+
+	deadline := time.After(time.Second)
+	for {
+		mu.Lock()
+		n := len(dead)
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timeout waiting for dead letter, calls=%d", atomic.LoadInt32(&calls))
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	batch, ok := dead[0].([]int)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected dead-lettered []int of len 2, got %#v", dead[0])
+	}
+	if !errors.Is(deadErr, errPermanent) {
+		t.Fatalf("expected last error to be passed to dead letter, got %v", deadErr)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected exactly maxAttempts=2 flush attempts, got %d", got)
+	}
+}
+
+// TestRetryQueue_FullQueueGoesToDeadLetter 验证重试队列已满时新的失败批次直接进入死信
+func TestRetryQueue_FullQueueGoesToDeadLetter(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(8).
+		WithFlushSize(1).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		return errors.New("down")
+	})
+	var deadLetters int32
+	// 长轮询间隔，确保测试期间不发生重放
+	p.WithRetryQueue(1, time.Hour, 5).
+		WithDeadLetter(func(ctx context.Context, batch any, err error) { atomic.AddInt32(&deadLetters, 1) })
+	_ = p.ErrorChan(8)
+
+	ch := p.DataChan()
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = p.SyncPerform(ctx)
+
+	if got := p.PendingRetries(); got != 1 {
+		t.Fatalf("expected 1 pending retry, got %d", got)
+	}
+	if got := atomic.LoadInt32(&deadLetters); got != 2 {
+		t.Fatalf("expected 2 dead letters from full queue, got %d", got)
+	}
+}
+
+// TestRetryQueue_PermanentErrorSkipsRetry 验证分类为 Permanent 的错误不进入重试队列而直接进入死信
+func TestRetryQueue_PermanentErrorSkipsRetry(t *testing.T) {
+	errInvalid := errors.New("invalid row")
+	var calls int32
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(8).
+		WithFlushSize(2).
+		WithFlushInterval(time.Hour)
+
+	p := gopipeline.NewStandardPipeline[int](cfg, func(ctx context.Context, batch []int) error {
+		atomic.AddInt32(&calls, 1)
+		return errInvalid
+	})
+	var deadLetters int32
+	p.WithRetryQueue(4, 5*time.Millisecond, 5).
+		WithDeadLetter(func(ctx context.Context, batch any, err error) { atomic.AddInt32(&deadLetters, 1) }).
+		WithErrorClassifier(func(err error) gopipeline.ErrorClass {
+			if errors.Is(err, errInvalid) {
+				return gopipeline.Permanent
+			}
+			return gopipeline.NewErrorClassifier(gopipeline.Transient)(err)
+		})
+	_ = p.ErrorChan(8)
+
+	ch := p.DataChan()
+	ch <- 1
+	ch <- 2
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	// 同步 flush：死信处理在 SyncPerform 返回前已完成
+	if got := atomic.LoadInt32(&deadLetters); got != 1 {
+		t.Fatalf("expected permanent failure to be dead-lettered immediately, got %d", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 1 || p.PendingRetries() != 0 {
+		t.Fatalf("expected no retries, got %d calls, %d pending", got, p.PendingRetries())
+	}
+}
+
+// TestNewErrorClassifier 验证基础分类函数：context 错误为 Transient，其余归为 fallback
+func TestNewErrorClassifier(t *testing.T) {
+	classify := gopipeline.NewErrorClassifier(gopipeline.Permanent)
+	if got := classify(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)); got != gopipeline.Transient {
+		t.Fatalf("expected context errors to be transient, got %v", got)
+	}
+	if got := classify(errors.New("boom")); got != gopipeline.Permanent {
+		t.Fatalf("expected fallback class for other errors, got %v", got)
+	}
+}
@@ -0,0 +1,107 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestShardedPipeline_RoutesByShard 验证数据按 shardFn 路由到对应分片，各分片独立 flush，Close 后全部退出且可重复调用
+func TestShardedPipeline_RoutesByShard(t *testing.T) {
+	var mu sync.Mutex
+	got := map[int][]int{}
+
+	p := gopipeline.NewShardedPipeline(3,
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(v int) int { return v },
+		func(ctx context.Context, shard int, batch []int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[shard] = append(got[shard], batch...)
+			return nil
+		})
+
+	done, _ := p.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := -3; i < 9; i++ {
+		if err := p.Add(ctx, i); err != nil {
+			t.Fatalf("Add(%d) returned error: %v", i, err)
+		}
+	}
+	p.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected all shards to exit after Close")
+	}
+	// Close 幂等，关闭后 Add 返回 ErrChannelIsClosed
+	p.Close()
+	if err := p.Add(ctx, 0); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed after Close, got %v", err)
+	}
+	// 异步 flush 不被 done 等待，轮询直到全部写入
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(got[0]) + len(got[1]) + len(got[2])
+		mu.Unlock()
+		if n == 12 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for shard, items := range got {
+		if len(items) != 4 {
+			t.Fatalf("expected 4 items in shard %d, got %v", shard, items)
+		}
+		for _, v := range items {
+			if (v%3+3)%3 != shard {
+				t.Fatalf("item %d routed to shard %d", v, shard)
+			}
+		}
+	}
+}
+
+// TestShardedPipeline_ErrorsCarryShard 验证各分片错误汇总到同一通道并携带分片下标
+func TestShardedPipeline_ErrorsCarryShard(t *testing.T) {
+	errWrite := errors.New("shard down")
+	p := gopipeline.NewShardedPipeline(2,
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(4).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		gopipeline.ShardByKey(func(s string) string { return s }),
+		func(ctx context.Context, shard int, batch []string) error {
+			return errWrite
+		})
+
+	_, errs := p.Start(context.Background())
+	if err := p.Add(context.Background(), "k"); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	select {
+	case err := <-errs:
+		var serr *gopipeline.ShardFlushError
+		if !errors.As(err, &serr) || !errors.Is(err, errWrite) {
+			t.Fatalf("expected ShardFlushError wrapping the flush error, got %v", err)
+		}
+		if p.Shard(serr.Shard).Stats().AddedTotal != 1 {
+			t.Fatalf("expected the error to name the shard that received the item, got shard %d", serr.Shard)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a shard error")
+	}
+	p.Close()
+	<-p.Done()
+}
@@ -125,6 +125,129 @@ func BenchmarkPipelineLatency(b *testing.B) {
 	cancel()
 }
 
+// BenchmarkPipelineFlushSizeOne 测试 FlushSize == 1 时逐条 flush 的单条开销（同步模式）
+func BenchmarkPipelineFlushSizeOne(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var processedCount int64
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.PipelineConfig{
+			BufferSize:    1024,
+			FlushSize:     1,
+			FlushInterval: time.Second,
+		},
+		func(ctx context.Context, batchData []BenchmarkTestData) error {
+			processedCount += int64(len(batchData))
+			return nil
+		})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = pipeline.SyncPerform(ctx)
+	}()
+	dataChan := pipeline.DataChan()
+	item := BenchmarkTestData{Name: "FlushSizeOne", Address: "TestAddr", Age: 30}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dataChan <- item
+	}
+	close(dataChan)
+	<-done
+	b.StopTimer()
+
+	if processedCount != int64(b.N) {
+		b.Fatalf("expected %d items processed, got %d", b.N, processedCount)
+	}
+}
+
+// BenchmarkPipelineSyncStaticTuning 对比同步模式下通用循环与精简循环（StaticTuning）的单条开销
+func BenchmarkPipelineSyncStaticTuning(b *testing.B) {
+	for _, static := range []bool{false, true} {
+		name := "DynamicLoop"
+		if static {
+			name = "StaticLoop"
+		}
+		b.Run(name, func(b *testing.B) {
+			var processedCount int64
+			pipeline := gopipeline.NewStandardPipeline(
+				gopipeline.NewPipelineConfig().
+					WithBufferSize(1024).
+					WithFlushSize(100).
+					WithFlushInterval(time.Second).
+					WithStaticTuning(static),
+				func(ctx context.Context, batchData []BenchmarkTestData) error {
+					processedCount += int64(len(batchData))
+					return nil
+				})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = pipeline.SyncPerform(context.Background())
+			}()
+			dataChan := pipeline.DataChan()
+			item := BenchmarkTestData{Name: "SyncLoop", Address: "TestAddr", Age: 30}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				dataChan <- item
+			}
+			close(dataChan)
+			<-done
+			b.StopTimer()
+
+			if processedCount != int64(b.N) {
+				b.Fatalf("expected %d items processed, got %d", b.N, processedCount)
+			}
+		})
+	}
+}
+
+// BenchmarkPipelineSyncReceiveBatch 对比主循环每次 select 后连续接收不同条数（ReceiveBatch）时的单条开销
+func BenchmarkPipelineSyncReceiveBatch(b *testing.B) {
+	for _, k := range []uint32{0, 16, 64} {
+		b.Run(fmt.Sprintf("ReceiveBatch%d", k), func(b *testing.B) {
+			var processedCount int64
+			pipeline := gopipeline.NewStandardPipeline(
+				gopipeline.NewPipelineConfig().
+					WithBufferSize(1024).
+					WithFlushSize(100).
+					WithFlushInterval(time.Second).
+					WithReceiveBatch(k),
+				func(ctx context.Context, batchData []BenchmarkTestData) error {
+					processedCount += int64(len(batchData))
+					return nil
+				})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = pipeline.SyncPerform(context.Background())
+			}()
+			dataChan := pipeline.DataChan()
+			item := BenchmarkTestData{Name: "ReceiveBatch", Address: "TestAddr", Age: 30}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				dataChan <- item
+			}
+			close(dataChan)
+			<-done
+			b.StopTimer()
+
+			if processedCount != int64(b.N) {
+				b.Fatalf("expected %d items processed, got %d", b.N, processedCount)
+			}
+		})
+	}
+}
+
 // BenchmarkPipelineBatchEfficiency 测试不同批次大小的效率
 func BenchmarkPipelineBatchEfficiency(b *testing.B) {
 	batchSizes := []int{1, 10, 50, 100, 500, 1000}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -581,3 +582,757 @@ func newErrorWithData(data any, err error) errorWithData {
 		Err:  err,
 	}
 }
+
+// TestStandardPipelineFlushSizeOne 验证 FlushSize == 1 快速路径：逐条 flush，顺序与数量不变
+func TestStandardPipelineFlushSizeOne(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var got []int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData []int) error {
+			if len(batchData) != 1 {
+				t.Errorf("expected single-item batch, got %d", len(batchData))
+			}
+			got = append(got, batchData...)
+			return nil
+		})
+
+	dataChan := pipeline.DataChan()
+	go func() {
+		defer close(dataChan)
+		for i := 0; i < 100; i++ {
+			dataChan <- i
+		}
+	}()
+
+	if err := pipeline.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(got) != 100 {
+		t.Fatalf("expected 100 items flushed, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("expected items in arrival order, got %d at %d", v, i)
+		}
+	}
+}
+
+// TestStandardPipelineUnbufferedHandoff 验证 BufferSize == 0 时为无缓冲通道：生产者阻塞直到主循环取走数据
+func TestStandardPipelineUnbufferedHandoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(0).
+			WithFlushSize(10).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batchData []int) error { return nil })
+
+	dataChan := pipeline.DataChan()
+	if c := cap(dataChan); c != 0 {
+		t.Fatalf("expected unbuffered data channel, got cap=%d", c)
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		dataChan <- 1
+		close(sent)
+	}()
+
+	// 主循环尚未启动：发送必须保持阻塞
+	select {
+	case <-sent:
+		t.Fatal("send completed before the loop received it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(ctx) }()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("send did not complete after the loop started")
+	}
+	close(dataChan)
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+}
+
+// TestStandardPipelineSyncStaticTuning 验证 StaticTuning 精简循环的满批、定时与关闭语义与通用循环一致
+func TestStandardPipelineSyncStaticTuning(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var mux sync.Mutex
+	var batches [][]int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(4).
+			WithFlushInterval(20*time.Millisecond).
+			WithStaticTuning(true),
+		func(ctx context.Context, batchData []int) error {
+			mux.Lock()
+			batches = append(batches, batchData)
+			mux.Unlock()
+			return nil
+		})
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(ctx) }()
+
+	dataChan := pipeline.DataChan()
+	for i := 0; i < 4; i++ { // 满批
+		dataChan <- i
+	}
+	dataChan <- 4 // 未满，由定时器 flush
+	time.Sleep(60 * time.Millisecond)
+	dataChan <- 5 // 未满，由关闭路径 flush
+	close(dataChan)
+
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(batches) != 3 || len(batches[0]) != 4 || batches[1][0] != 4 || batches[2][0] != 5 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+}
+
+// TestStandardPipelineSizeThenInterval 测试 SizeThenInterval：定时触发时未达最小批大小则跳过，关闭时仍 flush 剩余数据
+func TestStandardPipelineSizeThenInterval(t *testing.T) {
+	var mux sync.Mutex
+	var batches [][]int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(5*time.Millisecond).
+			WithFlushCondition(gopipeline.SizeThenInterval, 3),
+		func(ctx context.Context, batch []int) error {
+			mux.Lock()
+			batches = append(batches, append([]int(nil), batch...))
+			mux.Unlock()
+			return nil
+		})
+	flushCount := func() int {
+		mux.Lock()
+		defer mux.Unlock()
+		return len(batches)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(context.Background()) }()
+
+	ch := pipeline.DataChan()
+	ch <- 1
+	ch <- 2
+	time.Sleep(30 * time.Millisecond) // 多次定时触发，但批大小不足 3
+	if n := flushCount(); n != 0 {
+		t.Fatalf("expected interval ticks to skip small batch, got %d flushes", n)
+	}
+
+	ch <- 3
+	deadline := time.Now().Add(time.Second)
+	for flushCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := flushCount(); n != 1 {
+		t.Fatalf("expected interval flush once min size reached, got %d flushes", n)
+	}
+
+	ch <- 4
+	close(ch)
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 1 {
+		t.Fatalf("expected batches [1 2 3] and [4], got %v", batches)
+	}
+}
+
+// TestStandardPipelineMinFlushSize 测试 WithMinFlushSize：高水位不会提前写出未达最小批大小的批次，关闭时仍 flush 剩余数据
+func TestStandardPipelineMinFlushSize(t *testing.T) {
+	cfg := gopipeline.NewPipelineConfig().
+		WithBufferSize(4).
+		WithFlushSize(4).
+		WithFlushInterval(time.Hour).
+		WithBufferHighWatermark(0.25).
+		WithMinFlushSize(3)
+	if cfg.FlushCondition != gopipeline.SizeThenInterval || cfg.MinFlushSize != 3 {
+		t.Fatalf("expected WithMinFlushSize to select SizeThenInterval, got %v/%d", cfg.FlushCondition, cfg.MinFlushSize)
+	}
+	if err := cfg.WithMinFlushSize(5).Validate(); !errors.Is(err, gopipeline.ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig for MinFlushSize > FlushSize, got %v", err)
+	}
+
+	var batches [][]int
+	pipeline := gopipeline.NewStandardPipeline(cfg, func(ctx context.Context, batch []int) error {
+		batches = append(batches, append([]int(nil), batch...))
+		return nil
+	})
+
+	// 预先填满缓冲：主循环收到前三条时占用率均达到高水位，但直到第三条批次才达最小大小
+	ch := pipeline.DataChan()
+	for i := 0; i < 4; i++ {
+		ch <- i
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ch <- 4
+		close(ch)
+	}()
+
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 2 {
+		t.Fatalf("expected a watermark flush of 3 then the final flush of 2, got %v", batches)
+	}
+}
+
+// TestStandardPipelineBufferHighWatermark 测试缓冲占用率达到高水位时提前 flush，低于水位后恢复按批累计
+func TestStandardPipelineBufferHighWatermark(t *testing.T) {
+	var batches [][]int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(10).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour).
+			WithBufferHighWatermark(0.5),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, append([]int(nil), batch...))
+			return nil
+		})
+
+	// 预先填满缓冲：前 5 次接收后剩余占用率仍不低于 50%，每条都会提前 flush
+	ch := pipeline.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 6 {
+		t.Fatalf("expected 5 early flushes plus the final flush, got %v", batches)
+	}
+	for i := 0; i < 5; i++ {
+		if len(batches[i]) != 1 || batches[i][0] != i {
+			t.Fatalf("expected early flush of item %d, got %v", i, batches)
+		}
+	}
+	if len(batches[5]) != 5 {
+		t.Fatalf("expected remaining items to accumulate below the watermark, got %v", batches)
+	}
+}
+
+// TestStandardPipelineFlushEmptyOnInterval 测试启用心跳后定时触发以空批次调用 flush 函数
+func TestStandardPipelineFlushEmptyOnInterval(t *testing.T) {
+	var empty, items int32
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(5*time.Millisecond).
+			WithFlushEmptyOnInterval(true),
+		func(ctx context.Context, batch []int) error {
+			if len(batch) == 0 {
+				atomic.AddInt32(&empty, 1)
+			}
+			atomic.AddInt32(&items, int32(len(batch)))
+			return nil
+		})
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(context.Background()) }()
+	pipeline.DataChan() <- 1
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&empty) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(pipeline.DataChan())
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if atomic.LoadInt32(&empty) < 3 || atomic.LoadInt32(&items) != 1 {
+		t.Fatalf("expected heartbeat flushes with empty batches, got %d empty, %d items", empty, items)
+	}
+}
+
+// TestStandardPipelineHeartbeatInterval 验证 FlushInterval 很长时心跳仍按固定周期 flush 未满的批次
+func TestStandardPipelineHeartbeatInterval(t *testing.T) {
+	flushed := make(chan int, 4)
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour).
+			WithHeartbeatInterval(10*time.Millisecond),
+		func(ctx context.Context, batch []int) error {
+			flushed <- len(batch)
+			return nil
+		})
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(context.Background()) }()
+	pipeline.DataChan() <- 1
+	pipeline.DataChan() <- 2
+
+	select {
+	case n := <-flushed:
+		if n != 2 {
+			t.Fatalf("expected heartbeat to flush the partial batch of 2, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected heartbeat flush before the flush interval")
+	}
+	close(pipeline.DataChan())
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if n := len(flushed); n != 0 {
+		t.Fatalf("expected no empty heartbeat flushes without FlushEmptyOnInterval, got %d", n)
+	}
+}
+
+// TestStandardPipelineManualFlushTrigger 验证手动触发通道按需 flush 当前批次，无需依赖真实定时
+func TestStandardPipelineManualFlushTrigger(t *testing.T) {
+	var flushed [][]int
+	trigger := make(chan struct{})
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(0).
+			WithFlushSize(100).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed = append(flushed, append([]int(nil), batch...))
+			return nil
+		})
+	pipeline.WithManualFlushTrigger(trigger)
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.SyncPerform(context.Background()) }()
+
+	// 无缓冲通道：发送返回即表示主循环已处理完上一个事件
+	ch := pipeline.DataChan()
+	ch <- 1
+	ch <- 2
+	trigger <- struct{}{}
+	ch <- 3
+	trigger <- struct{}{}
+	trigger <- struct{}{} // 空批次：不 flush
+	close(ch)
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(flushed) != 2 || len(flushed[0]) != 2 || len(flushed[1]) != 1 || flushed[1][0] != 3 {
+		t.Fatalf("expected triggered batches [1 2] and [3], got %v", flushed)
+	}
+}
+
+// TestStandardPipelineStartupGracePeriod 验证启动宽限期内只累计不 flush，宽限期结束后立即 flush 已累计的数据
+func TestStandardPipelineStartupGracePeriod(t *testing.T) {
+	const grace = 100 * time.Millisecond
+	var flushed atomic.Int32
+	firstFlush := make(chan time.Time, 1)
+
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour).
+			WithStartupGracePeriod(grace),
+		func(ctx context.Context, batch []int) error {
+			select {
+			case firstFlush <- time.Now():
+			default:
+			}
+			flushed.Add(int32(len(batch)))
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.SyncPerform(ctx) }()
+
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	time.Sleep(grace / 2)
+	if n := flushed.Load(); n != 0 {
+		t.Fatalf("expected no flush during the grace period, got %d items flushed", n)
+	}
+
+	select {
+	case at := <-firstFlush:
+		if at.Sub(start) < grace {
+			t.Fatalf("expected first flush after the grace period, got %v", at.Sub(start))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a flush once the grace period elapsed")
+	}
+	// 宽限期结束后按批大小正常 flush：5 条中的 4 条组成两个满批
+	deadline := time.Now().Add(time.Second)
+	for flushed.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := flushed.Load(); n != 4 {
+		t.Fatalf("expected 4 items flushed after the grace period, got %d", n)
+	}
+
+	close(ch)
+	if err := <-errCh; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if n := flushed.Load(); n != 5 {
+		t.Fatalf("expected the remaining item flushed on close, got %d", n)
+	}
+}
+
+// TestScratchPipeline 验证每次 flush 都收到已重置的临时缓冲区
+func TestScratchPipeline(t *testing.T) {
+	var outputs []string
+	p := gopipeline.NewScratchPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []string, scratch *gopipeline.Scratch) error {
+			if scratch.Len() != 0 {
+				t.Errorf("expected a reset scratch buffer, got %q", scratch.String())
+			}
+			for _, s := range batch {
+				scratch.WriteString(s)
+			}
+			outputs = append(outputs, scratch.String())
+			return nil
+		})
+
+	ch := p.DataChan()
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		ch <- s
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(outputs) != 3 || outputs[0] != "ab" || outputs[1] != "cd" || outputs[2] != "e" {
+		t.Fatalf("expected per-batch scratch output [ab cd e], got %v", outputs)
+	}
+}
+
+// TestResultsPipeline 验证每次 flush 都借到长度为 0、容量不小于 FlushSize 的结果缓冲区
+func TestResultsPipeline(t *testing.T) {
+	var sums []int
+	p := gopipeline.NewResultsPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int, out []int) ([]int, error) {
+			if len(out) != 0 || cap(out) < 2 {
+				t.Errorf("expected an empty results buffer with capacity >= 2, got len %d cap %d", len(out), cap(out))
+			}
+			for _, v := range batch {
+				out = append(out, v*10)
+			}
+			sum := 0
+			for _, r := range out {
+				sum += r
+			}
+			sums = append(sums, sum)
+			return out, nil
+		})
+
+	ch := p.DataChan()
+	for _, v := range []int{1, 2, 3, 4, 5} {
+		ch <- v
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(sums) != 3 || sums[0] != 30 || sums[1] != 70 || sums[2] != 50 {
+		t.Fatalf("expected per-batch results [30 70 50], got %v", sums)
+	}
+}
+
+// TestStandardPipelineReceiveBatch 验证连续接收不改变批大小语义，关闭时剩余数据照常 flush
+func TestStandardPipelineReceiveBatch(t *testing.T) {
+	var batches [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(10).
+			WithFlushInterval(time.Hour).
+			WithReceiveBatch(16),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, batch)
+			return nil
+		})
+
+	ch := p.DataChan()
+	for i := 0; i < 45; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 5 {
+		t.Fatalf("expected 5 batches, got %d", len(batches))
+	}
+	next := 0
+	for i, b := range batches {
+		want := 10
+		if i == 4 {
+			want = 5
+		}
+		if len(b) != want {
+			t.Fatalf("expected batch %d to hold %d items, got %d", i, want, len(b))
+		}
+		for _, v := range b {
+			if v != next {
+				t.Fatalf("expected items in order, got %v", batches)
+			}
+			next++
+		}
+	}
+}
+
+// TestStandardPipelineSkipIdenticalBatches 验证与上一次成功 flush 相同的批次被跳过并计数
+func TestStandardPipelineSkipIdenticalBatches(t *testing.T) {
+	var flushed [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed = append(flushed, batch)
+			return nil
+		}).WithSkipIdenticalBatches(func(prev, cur []int) bool {
+		if len(prev) != len(cur) {
+			return false
+		}
+		for i := range prev {
+			if prev[i] != cur[i] {
+				return false
+			}
+		}
+		return true
+	})
+
+	ch := p.DataChan()
+	for _, v := range []int{1, 2, 1, 2, 3, 4, 1, 2} {
+		ch <- v
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(flushed) != 3 || flushed[1][0] != 3 || flushed[2][0] != 1 {
+		t.Fatalf("expected only consecutive duplicates to be skipped, got %v", flushed)
+	}
+	if n := p.SkippedFlushes(); n != 1 {
+		t.Fatalf("expected 1 skipped flush, got %d", n)
+	}
+}
+
+// TestCountedPipeline 验证计数管道统计实际持久化条数，并将未报错的短写上报为 ShortFlushError
+func TestCountedPipeline(t *testing.T) {
+	p := gopipeline.NewCountedPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) (int, error) {
+			// 拒绝所有负数
+			n := 0
+			for _, v := range batch {
+				if v >= 0 {
+					n++
+				}
+			}
+			return n, nil
+		})
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for _, v := range []int{1, 2, 3, 4, -5, 6} {
+		ch <- v
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	stats := p.Stats()
+	if stats.PersistedTotal != 5 || stats.UnpersistedTotal != 1 {
+		t.Fatalf("expected 5 persisted and 1 unpersisted, got %+v", stats)
+	}
+	select {
+	case err := <-errs:
+		var serr *gopipeline.ShortFlushError
+		if !errors.As(err, &serr) || serr.Persisted != 2 || serr.Items != 3 {
+			t.Fatalf("expected ShortFlushError 2 of 3, got %v", err)
+		}
+	default:
+		t.Fatal("expected short flush to be reported")
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("expected a single short flush error, got extra %v", err)
+	default:
+	}
+}
+
+// TestStandardPipelineFilter 测试 WithFilter：谓词返回 false 的数据不入批、不计入批满，并计入 FilteredTotal
+func TestStandardPipelineFilter(t *testing.T) {
+	var batches [][]int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			batches = append(batches, append([]int(nil), batch...))
+			return nil
+		})
+	pipeline.WithFilter(func(v int) bool { return v%2 == 0 })
+
+	ch := pipeline.DataChan()
+	for i := 1; i <= 7; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || batches[0][0] != 2 || batches[0][1] != 4 || len(batches[1]) != 1 || batches[1][0] != 6 {
+		t.Fatalf("expected batches [[2 4] [6]], got %v", batches)
+	}
+	if n := pipeline.Stats().FilteredTotal; n != 4 {
+		t.Fatalf("expected 4 filtered items, got %d", n)
+	}
+}
+
+// TestStandardPipelineZeroCopyFlush 测试 WithZeroCopyFlush：同步 flush 直接复用同一底层数组，批次内容保持正确
+func TestStandardPipelineZeroCopyFlush(t *testing.T) {
+	var arrays []*int
+	var got [][]int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			arrays = append(arrays, &batch[:1][0])
+			got = append(got, append([]int(nil), batch...))
+			return nil
+		}).WithZeroCopyFlush(true)
+
+	ch := p.DataChan()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(got) != 3 || got[0][1] != 1 || got[1][0] != 2 || got[2][0] != 4 {
+		t.Fatalf("unexpected batches: %v", got)
+	}
+	for _, a := range arrays[1:] {
+		if a != arrays[0] {
+			t.Fatal("expected every sync flush to reuse the same backing array")
+		}
+	}
+}
+
+// TestStandardPipelineZeroCopyFlushDisableKeepsResetFunc 测试 WithZeroCopyFlush(false) 不会清除用户经 WithResetFunc 设置的重置函数
+func TestStandardPipelineZeroCopyFlushDisableKeepsResetFunc(t *testing.T) {
+	var resets int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error { return nil }).
+		WithZeroCopyFlush(true)
+	p.WithResetFunc(func(container any) any {
+		resets++
+		return container.([]int)[:0]
+	})
+	p.WithZeroCopyFlush(false)
+
+	ch := p.DataChan()
+	for i := 0; i < 4; i++ {
+		ch <- i
+	}
+	close(ch)
+	if err := p.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if resets != 2 {
+		t.Fatalf("expected the user reset func to run after each sync flush, got %d", resets)
+	}
+}
+
+// TestStandardPipelineCompact 测试 WithCompact：flush 前合并相邻重复数据，flush 函数收到压缩后的批次
+func TestStandardPipelineCompact(t *testing.T) {
+	var flushed [][]int
+	pipeline := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(6).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed = append(flushed, append([]int(nil), batch...))
+			return nil
+		}).WithCompact(func(batch []int) []int {
+		out := batch[:0]
+		for _, v := range batch {
+			if len(out) == 0 || v != out[len(out)-1] {
+				out = append(out, v)
+			}
+		}
+		return out
+	})
+
+	ch := pipeline.DataChan()
+	for _, v := range []int{1, 1, 2, 2, 2, 3, 4, 4} {
+		ch <- v
+	}
+	close(ch)
+	if err := pipeline.SyncPerform(context.Background()); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	if len(flushed) != 2 || len(flushed[0]) != 3 || flushed[0][2] != 3 || len(flushed[1]) != 1 || flushed[1][0] != 4 {
+		t.Fatalf("expected compacted batches [1 2 3] and [4], got %v", flushed)
+	}
+}
@@ -0,0 +1,222 @@
+package gopipeline_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestStopPipeline_Sync 验证 flush 返回 ErrStopPipeline 后同步循环停止，未满批次交给死信且不写入错误通道
+func TestStopPipeline_Sync(t *testing.T) {
+	var flushes int
+	var dead []int
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(32).
+			WithFlushSize(2).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushes++
+			if flushes == 2 {
+				return fmt.Errorf("sink closed: %w", gopipeline.ErrStopPipeline)
+			}
+			return nil
+		})
+	p.WithDeadLetter(func(ctx context.Context, batch any, err error) {
+		dead = append(dead, batch.([]int)...)
+	})
+	errs := p.ErrorChan(4)
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); !errors.Is(err, gopipeline.ErrStopPipeline) {
+		t.Fatalf("expected ErrStopPipeline, got %v", err)
+	}
+	if flushes != 2 {
+		t.Fatalf("expected no flush after stop, got %d flushes", flushes)
+	}
+	// 触发停止的批次交给死信，后续数据留在通道缓冲中
+	if len(dead) != 2 || dead[0] != 2 || dead[1] != 3 {
+		t.Fatalf("expected stopping batch in dead letter, got %v", dead)
+	}
+	if len(ch) != 6 {
+		t.Fatalf("expected remaining items to stay buffered, got %d", len(ch))
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("stop request must not be reported as an error, got %v", err)
+	default:
+	}
+}
+
+// TestStopPipeline_Async 验证异步 flush 返回 ErrStopPipeline 后空闲的主循环也会被唤醒退出
+func TestStopPipeline_Async(t *testing.T) {
+	var flushes int32
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			atomic.AddInt32(&flushes, 1)
+			return gopipeline.ErrStopPipeline
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- p.AsyncPerform(ctx) }()
+
+	p.DataChan() <- 1
+	select {
+	case err := <-done:
+		if !errors.Is(err, gopipeline.ErrStopPipeline) {
+			t.Fatalf("expected ErrStopPipeline, got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the loop to stop after ErrStopPipeline")
+	}
+	if n := atomic.LoadInt32(&flushes); n != 1 {
+		t.Fatalf("expected exactly one flush, got %d", n)
+	}
+}
+
+// TestStopPipeline_StaticTuningAsyncFlush 验证 StaticTuning 精简循环在运行中切换为异步 flush 后，
+// 异步 flush 返回的 ErrStopPipeline 同样能唤醒空闲的主循环
+func TestStopPipeline_StaticTuningAsyncFlush(t *testing.T) {
+	release := make(chan struct{})
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(8).
+			WithFlushSize(1).
+			WithFlushInterval(time.Hour).
+			WithStaticTuning(true),
+		func(ctx context.Context, batch []int) error {
+			<-release
+			return gopipeline.ErrStopPipeline
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- p.SyncPerform(ctx) }()
+
+	// 运行开始后再切换，确保精简循环已被选中
+	time.Sleep(20 * time.Millisecond)
+	p.SetAsync(true)
+	p.DataChan() <- 1
+	// 主循环处理完该条数据后才放行 flush，使停止请求在循环空闲时到达
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, gopipeline.ErrStopPipeline) {
+			t.Fatalf("expected ErrStopPipeline, got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the static loop to stop after an async ErrStopPipeline")
+	}
+}
+
+// TestClose_PersistentSignal 验证 Close 关闭数据通道并等待最终 flush，Closed() 跨运行保持同一通道且关闭后拒绝再次运行
+func TestClose_PersistentSignal(t *testing.T) {
+	var flushed atomic.Int64
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(10).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error {
+			flushed.Add(int64(len(batch)))
+			return nil
+		})
+	closed := p.Closed()
+
+	// 第一次运行正常结束（ctx 取消）不会关闭 Closed()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = p.SyncPerform(ctx)
+	select {
+	case <-closed:
+		t.Fatal("expected Closed() to stay open after a run ends")
+	default:
+	}
+
+	// 第二次运行：Close 触发最终 flush 并关闭同一个 Closed() 通道
+	done, _ := p.Start(context.Background())
+	for i := 0; i < 3; i++ {
+		if err := p.Add(context.Background(), i); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+	if err := p.Close(closeCtx); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	select {
+	case <-closed:
+	default:
+		t.Fatal("expected Closed() to be closed after Close returns")
+	}
+	<-done
+	if n := flushed.Load(); n != 3 {
+		t.Fatalf("expected final flush of 3 items, got %d", n)
+	}
+
+	// 关闭后：再次运行被拒绝，Start 的 done 仍会关闭，Close 幂等
+	if err := p.SyncPerform(context.Background()); !errors.Is(err, gopipeline.ErrPipelineClosed) {
+		t.Fatalf("expected ErrPipelineClosed, got %v", err)
+	}
+	done, _ = p.Start(context.Background())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Start on a closed pipeline to finish")
+	}
+	if err := p.Add(context.Background(), 4); !errors.Is(err, gopipeline.ErrChannelIsClosed) {
+		t.Fatalf("expected ErrChannelIsClosed after Close, got %v", err)
+	}
+	if err := p.Close(closeCtx); err != nil {
+		t.Fatalf("expected repeated Close to succeed, got %v", err)
+	}
+}
+
+// TestClose_AfterWriterCloseIsSilent 验证写入方已关闭数据通道后调用 Close 属于正常路径，不输出任何日志
+func TestClose_AfterWriterCloseIsSilent(t *testing.T) {
+	var buf bytes.Buffer
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(4).
+			WithFlushSize(10).
+			WithFlushInterval(time.Hour),
+		func(ctx context.Context, batch []int) error { return nil })
+	p.WithLogger(log.New(&buf, "", 0))
+
+	done, _ := p.Start(context.Background())
+	p.DataChan() <- 1
+	close(p.DataChan())
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected Close after the writer closed the channel to log nothing, got %q", buf.String())
+	}
+}
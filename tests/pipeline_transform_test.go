@@ -0,0 +1,58 @@
+package gopipeline_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestTransformPipeline_MapAndFilter 验证转换阶段在批处理前执行映射与过滤
+func TestTransformPipeline_MapAndFilter(t *testing.T) {
+	var flushed []string
+	var batches int
+	p := gopipeline.NewTransformPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(32).
+			WithFlushSize(3).
+			WithFlushInterval(time.Hour),
+		func(v int) (string, bool) {
+			if v%2 != 0 {
+				return "", false // 丢弃奇数
+			}
+			return "n" + strconv.Itoa(v), true
+		},
+		func(ctx context.Context, batch []string) error {
+			batches++
+			flushed = append(flushed, batch...)
+			return nil
+		})
+
+	ch := p.DataChan()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.SyncPerform(ctx); err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+
+	want := []string{"n0", "n2", "n4", "n6", "n8"}
+	if len(flushed) != len(want) {
+		t.Fatalf("expected %v, got %v", want, flushed)
+	}
+	for i := range want {
+		if flushed[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, flushed)
+		}
+	}
+	// 仅保留的 5 条按 FlushSize=3 组批：一个满批 + 关闭时的最终批
+	if batches != 2 {
+		t.Fatalf("expected 2 batches, got %d", batches)
+	}
+}
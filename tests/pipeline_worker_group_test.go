@@ -0,0 +1,68 @@
+package gopipeline_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+)
+
+// TestWorkerGroup_SharesOneSource 验证工作管道共同消费同一输入通道，每条数据恰好 flush 一次，关闭输入后全部退出
+func TestWorkerGroup_SharesOneSource(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[int]int{}
+	var busyWorkers atomic.Int32
+
+	g := gopipeline.NewWorkerGroup(4, func() *gopipeline.StandardPipeline[int] {
+		var used atomic.Bool
+		return gopipeline.NewStandardPipeline(
+			gopipeline.NewPipelineConfig().
+				WithBufferSize(8).
+				WithFlushSize(4).
+				WithFlushInterval(time.Hour),
+			func(ctx context.Context, batch []int) error {
+				if used.CompareAndSwap(false, true) {
+					busyWorkers.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				defer mu.Unlock()
+				for _, v := range batch {
+					seen[v]++
+				}
+				return nil
+			})
+	})
+	if g.Workers() != 4 {
+		t.Fatalf("expected 4 workers, got %d", g.Workers())
+	}
+
+	src := make(chan int)
+	done, _ := g.Start(context.Background(), src)
+	for i := 0; i < 200; i++ {
+		src <- i
+	}
+	close(src)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected all workers to exit after the source is closed")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 200 {
+		t.Fatalf("expected 200 distinct items flushed, got %d", len(seen))
+	}
+	for v, n := range seen {
+		if n != 1 {
+			t.Fatalf("expected item %d flushed exactly once, got %d", v, n)
+		}
+	}
+	if busyWorkers.Load() < 2 {
+		t.Fatalf("expected the source to be shared by several workers, got %d", busyWorkers.Load())
+	}
+}
@@ -0,0 +1,106 @@
+package gopipeline_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gopipeline "github.com/rushairer/go-pipeline/v2"
+	"github.com/rushairer/go-pipeline/v2/pipelinetest"
+)
+
+// TestRecordingSink_AsyncPipeline 验证 RecordingSink 在异步 flush 下线程安全地记录批次
+func TestRecordingSink_AsyncPipeline(t *testing.T) {
+	sink := pipelinetest.NewRecordingSink[int]()
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(64).
+			WithFlushSize(10).
+			WithFlushInterval(5*time.Millisecond),
+		sink.Flush)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.AsyncPerform(ctx) }()
+
+	for i := 0; i < 95; i++ {
+		p.DataChan() <- i
+	}
+	if err := sink.WaitFor(95, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int]bool)
+	for _, v := range sink.Items() {
+		seen[v] = true
+	}
+	if len(seen) != 95 {
+		t.Fatalf("expected 95 distinct items, got %d", len(seen))
+	}
+	total := 0
+	for _, b := range sink.Batches() {
+		if len(b) > 10 {
+			t.Fatalf("batch exceeds FlushSize: %d", len(b))
+		}
+		total += len(b)
+	}
+	if total != 95 {
+		t.Fatalf("expected batches to hold 95 items, got %d", total)
+	}
+}
+
+// TestRecordingSink_ErrorAndTimeout 验证 SetError 注入失败以及 WaitFor 超时返回错误
+func TestRecordingSink_ErrorAndTimeout(t *testing.T) {
+	sink := pipelinetest.NewRecordingSink[string]()
+	errDown := errors.New("downstream unavailable")
+	sink.SetError(errDown)
+
+	if err := sink.Flush(context.Background(), []string{"a"}); !errors.Is(err, errDown) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	if got := sink.Items(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("expected failed batch to be recorded, got %v", got)
+	}
+	if err := sink.WaitFor(2, 10*time.Millisecond); err == nil {
+		t.Fatal("expected WaitFor to time out")
+	}
+
+	sink.Reset()
+	if len(sink.Batches()) != 0 {
+		t.Fatal("expected Reset to clear recorded batches")
+	}
+	if err := sink.Flush(context.Background(), []string{"b"}); err != nil {
+		t.Fatalf("expected Reset to clear injected error, got %v", err)
+	}
+}
+
+// TestAssertOrdered_SyncRunPreservesArrivalOrder 验证同步模式运行内按到达顺序 flush，并覆盖 AssertOrdered 的违例报告
+func TestAssertOrdered_SyncRunPreservesArrivalOrder(t *testing.T) {
+	sink := pipelinetest.NewRecordingSink[int]()
+	p := gopipeline.NewStandardPipeline(
+		gopipeline.NewPipelineConfig().
+			WithBufferSize(16).
+			WithFlushSize(7).
+			WithFlushInterval(time.Millisecond),
+		sink.Flush)
+
+	done := make(chan error, 1)
+	go func() { done <- p.SyncPerform(context.Background()) }()
+	for i := 0; i < 100; i++ {
+		p.DataChan() <- i
+	}
+	close(p.DataChan())
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPerform returned error: %v", err)
+	}
+	if len(sink.Items()) != 100 {
+		t.Fatalf("expected 100 items, got %d", len(sink.Items()))
+	}
+	pipelinetest.AssertOrdered(t, sink.Items(), func(v int) int { return v })
+
+	probe := &testing.T{}
+	if pipelinetest.AssertOrdered(probe, []string{"a", "c", "b"}, func(s string) string { return s }) || !probe.Failed() {
+		t.Fatal("expected AssertOrdered to report out-of-order items")
+	}
+}